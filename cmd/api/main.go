@@ -7,6 +7,7 @@ import (
 	"time" // Add this import for cors.MaxAge
 
 	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/infrastructure/notification"
 	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
 	"Sheikh-Enterprise-Backend/internal/interfaces/http/handlers"
@@ -74,7 +75,10 @@ func initializeApp() (*App, error) {
 	repos := initializeRepositories(db)
 
 	// Initialize services
-	svcs := initializeServices(repos)
+	svcs := initializeServices(cfg, repos)
+
+	// Give back the stock of reservations that ran out
+	go expireStockReservations(svcs.StockReservation, cfg.Inventory.ReservationSweep)
 
 	// Initialize handlers
 	handlers := initializeHandlers(svcs)
@@ -100,47 +104,138 @@ func initializeDatabase() (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// Stock held before the movement ledger existed goes on it as opening
+	// balances, so the ledger always adds up to the inventory
+	seeded, err := repository.NewStockMovementRepository(db).SeedOpeningBalances()
+	if err != nil {
+		return nil, err
+	}
+	if seeded > 0 {
+		log.Printf("Added %d opening stock balances to the movement ledger", seeded)
+	}
+
 	return db, nil
 }
 
+// expireStockReservations expires reservations that ran out every interval
+// until the process exits
+func expireStockReservations(stockReservationService services.StockReservationService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired, err := stockReservationService.ExpireStockReservations()
+		if err != nil {
+			logger.Error("Failed to expire stock reservations: " + err.Error())
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d stock reservations", expired)
+		}
+	}
+}
+
 // initializeRepositories creates all repository instances
 func initializeRepositories(db *gorm.DB) *repository.Repositories {
 	return &repository.Repositories{
-		User:     repository.NewUserRepository(db),
-		Product:  repository.NewProductRepository(db),
-		Sales:    repository.NewSalesRepository(db),
-		Purchase: repository.NewPurchaseRepository(db),
-		Supplier: repository.NewSupplierRepository(db),
-		Company:  repository.NewCompanyRepository(db),
-		Shop:     repository.NewShopRepository(db),
+		User:              repository.NewUserRepository(db),
+		Product:           repository.NewProductRepository(db),
+		Sales:             repository.NewSalesRepository(db),
+		Purchase:          repository.NewPurchaseRepository(db),
+		PurchaseOrder:     repository.NewPurchaseOrderRepository(db),
+		LandedCost:        repository.NewLandedCostRepository(db),
+		Supplier:          repository.NewSupplierRepository(db),
+		SupplierProduct:   repository.NewSupplierProductRepository(db),
+		SupplierReturn:    repository.NewSupplierReturnRepository(db),
+		Payment:           repository.NewPaymentRepository(db),
+		Currency:          repository.NewCurrencyRepository(db),
+		SupplierScorecard: repository.NewSupplierScorecardRepository(db),
+		Replenishment:     repository.NewReplenishmentRepository(db),
+		Company:           repository.NewCompanyRepository(db),
+		Shop:              repository.NewShopRepository(db),
+		Inventory:         repository.NewInventoryRepository(db),
+		StockMovement:     repository.NewStockMovementRepository(db),
+		StockCount:        repository.NewStockCountRepository(db),
+		StockAdjustment:   repository.NewStockAdjustmentRepository(db),
+		StockReservation:  repository.NewStockReservationRepository(db),
+		StockLot:          repository.NewStockLotRepository(db),
+		SerialNumber:      repository.NewSerialNumberRepository(db),
+		ProductStyle:      repository.NewProductStyleRepository(db),
+		Notification:      repository.NewNotificationRepository(db),
+		StockTransfer:     repository.NewStockTransferRepository(db),
+		Warehouse:         repository.NewWarehouseRepository(db),
 	}
 }
 
 // initializeServices creates all service instances
-func initializeServices(repos *repository.Repositories) *services.Services {
+func initializeServices(cfg *config.Config, repos *repository.Repositories) *services.Services {
+	purchaseService := services.NewPurchaseService(repos.Purchase, repos.PurchaseOrder, repos.Currency, cfg.Purchase)
+	notificationService := services.NewNotificationService(repos.Notification, repos.Inventory, notification.NewChannels(cfg.Notification), cfg.Notification)
+
 	return &services.Services{
-		Auth:     services.NewAuthService(repos.Auth),
-		User:     services.NewUserService(repos.User),
-		Product:  services.NewProductService(repos.Product),
-		Sales:    services.NewSalesService(repos.Sales),
-		Purchase: services.NewPurchaseService(repos.Purchase),
-		Supplier: services.NewSupplierService(repos.Supplier),
-		Company:  services.NewCompanyService(repos.Company),
-		Shop:     services.NewShopService(repos.Shop),
+		Auth:              services.NewAuthService(repos.Auth),
+		User:              services.NewUserService(repos.User),
+		Product:           services.NewProductService(repos.Product),
+		Sales:             services.NewSalesService(repos.Sales, notificationService, cfg.Notification),
+		Purchase:          purchaseService,
+		PurchaseImport:    services.NewPurchaseImportService(purchaseService, repos.Purchase, repos.Product, repos.SupplierProduct, notificationService),
+		PurchaseOrder:     services.NewPurchaseOrderService(repos.PurchaseOrder),
+		LandedCost:        services.NewLandedCostService(repos.LandedCost, repos.Purchase),
+		Supplier:          services.NewSupplierService(repos.Supplier, repos.Purchase, repos.SupplierReturn, repos.Payment),
+		SupplierProduct:   services.NewSupplierProductService(repos.SupplierProduct),
+		SupplierReturn:    services.NewSupplierReturnService(repos.SupplierReturn, notificationService),
+		SupplierPayment:   services.NewSupplierPaymentService(repos.Payment, repos.Supplier, repos.Currency),
+		Currency:          services.NewCurrencyService(repos.Currency),
+		SupplierScorecard: services.NewSupplierScorecardService(repos.SupplierScorecard, repos.Supplier),
+		Replenishment:     services.NewReplenishmentService(repos.Replenishment, repos.Purchase, repos.SupplierProduct, repos.Product, notificationService),
+		Company:           services.NewCompanyService(repos.Company),
+		Shop:              services.NewShopService(repos.Shop),
+		Inventory:         services.NewInventoryService(repos.Inventory, repos.Product, repos.StockMovement),
+		StockAdjustment:   services.NewStockAdjustmentService(repos.StockAdjustment, notificationService, cfg.Inventory),
+		StockCount:        services.NewStockCountService(repos.StockCount, repos.Product),
+		StockReservation:  services.NewStockReservationService(repos.StockReservation, cfg.Inventory),
+		StockLot:          services.NewStockLotService(repos.StockLot),
+		SerialNumber:      services.NewSerialNumberService(repos.SerialNumber),
+		ProductStyle:      services.NewProductStyleService(repos.ProductStyle),
+		Notification:      notificationService,
+		StockTransfer:     services.NewStockTransferService(repos.StockTransfer, repos.Inventory, repos.Warehouse, notificationService),
+		Warehouse:         services.NewWarehouseService(repos.Warehouse, repos.Inventory),
 	}
 }
 
 // initializeHandlers creates all handler instances
 func initializeHandlers(svcs *services.Services) *handlers.Handlers {
 	return &handlers.Handlers{
-		Auth:     handlers.NewAuthHandler(svcs.Auth),
-		User:     handlers.NewUserHandler(svcs.User),
-		Product:  handlers.NewProductHandler(svcs.Product),
-		Sales:    handlers.NewSalesHandler(svcs.Sales),
-		Purchase: handlers.NewPurchaseHandler(svcs.Purchase),
-		Supplier: handlers.NewSupplierHandler(svcs.Supplier),
-		Company:  handlers.NewCompanyHandler(svcs.Company),
-		Shop:     handlers.NewShopHandler(svcs.Shop),
+		Auth:              handlers.NewAuthHandler(svcs.Auth),
+		User:              handlers.NewUserHandler(svcs.User),
+		Product:           handlers.NewProductHandler(svcs.Product),
+		Sales:             handlers.NewSalesHandler(svcs.Sales),
+		Purchase:          handlers.NewPurchaseHandler(svcs.Purchase),
+		PurchaseOrder:     handlers.NewPurchaseOrderHandler(svcs.PurchaseOrder),
+		LandedCost:        handlers.NewLandedCostHandler(svcs.LandedCost),
+		PurchaseImport:    handlers.NewPurchaseImportHandler(svcs.PurchaseImport),
+		Supplier:          handlers.NewSupplierHandler(svcs.Supplier),
+		SupplierProduct:   handlers.NewSupplierProductHandler(svcs.SupplierProduct),
+		SupplierReturn:    handlers.NewSupplierReturnHandler(svcs.SupplierReturn),
+		SupplierPayment:   handlers.NewSupplierPaymentHandler(svcs.SupplierPayment),
+		Currency:          handlers.NewCurrencyHandler(svcs.Currency),
+		SupplierScorecard: handlers.NewSupplierScorecardHandler(svcs.SupplierScorecard),
+		Replenishment:     handlers.NewReplenishmentHandler(svcs.Replenishment),
+		Company:           handlers.NewCompanyHandler(svcs.Company),
+		Shop:              handlers.NewShopHandler(svcs.Shop),
+		Inventory:         handlers.NewInventoryHandler(svcs.Inventory),
+		StockAdjustment:   handlers.NewStockAdjustmentHandler(svcs.StockAdjustment),
+		StockCount:        handlers.NewStockCountHandler(svcs.StockCount),
+		StockReservation:  handlers.NewStockReservationHandler(svcs.StockReservation),
+		StockLot:          handlers.NewStockLotHandler(svcs.StockLot),
+		SerialNumber:      handlers.NewSerialNumberHandler(svcs.SerialNumber),
+		ProductStyle:      handlers.NewProductStyleHandler(svcs.ProductStyle),
+		Notification:      handlers.NewNotificationHandler(svcs.Notification),
+		StockTransfer:     handlers.NewStockTransferHandler(svcs.StockTransfer),
+		Warehouse:         handlers.NewWarehouseHandler(svcs.Warehouse),
 	}
 }
 
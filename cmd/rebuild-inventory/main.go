@@ -0,0 +1,73 @@
+// Command rebuild-inventory recomputes inventory quantities from the stock
+// movement ledger and lists every product and shop whose quantity was wrong.
+//
+//	go run ./cmd/rebuild-inventory [-shop <shop id>] [-dry-run]
+package main
+
+import (
+	"flag"
+	"log"
+
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+	"Sheikh-Enterprise-Backend/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	shop := flag.String("shop", "", "Only rebuild this shop's inventory")
+	dryRun := flag.Bool("dry-run", false, "Report differences without changing inventory")
+	flag.Parse()
+
+	var shopID *uuid.UUID
+	if *shop != "" {
+		parsed, err := uuid.Parse(*shop)
+		if err != nil {
+			log.Fatalf("Invalid shop ID: %v", err)
+		}
+		shopID = &parsed
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found: %v", err)
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	inventoryService := services.NewInventoryService(
+		repository.NewInventoryRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewStockMovementRepository(db),
+	)
+
+	drifts, err := inventoryService.RebuildInventory(shopID, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to rebuild inventory: %v", err)
+	}
+
+	skipped := 0
+	for _, drift := range drifts {
+		if drift.Skipped {
+			skipped++
+			log.Printf("product %s shop %s: inventory %d, ledger %d is negative, left alone", drift.ProductID, drift.ShopID, drift.Quantity, drift.LedgerQuantity)
+			continue
+		}
+		log.Printf("product %s shop %s: inventory %d, ledger %d", drift.ProductID, drift.ShopID, drift.Quantity, drift.LedgerQuantity)
+	}
+	switch {
+	case len(drifts) == 0:
+		log.Println("Inventory matches the movement ledger")
+	case *dryRun:
+		log.Printf("%d inventory rows differ from the movement ledger, %d of them with a negative ledger balance", len(drifts), skipped)
+	default:
+		log.Printf("Corrected %d inventory rows from the movement ledger; %d with a negative ledger balance need a stock count", len(drifts)-skipped, skipped)
+	}
+}
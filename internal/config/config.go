@@ -4,16 +4,20 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Logger   LoggerConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	Logger       LoggerConfig
+	Purchase     PurchaseConfig
+	Inventory    InventoryConfig
+	Notification NotificationConfig
 }
 
 type ServerConfig struct {
@@ -41,6 +45,36 @@ type LoggerConfig struct {
 	File  string
 }
 
+type PurchaseConfig struct {
+	PriceWarningPercent float64 // Warn when a line is priced this far above the recent average
+	PriceLookbackDays   int     // How far back the recent average looks
+}
+
+type InventoryConfig struct {
+	AdjustmentApprovalValue float64       // Stock adjustments worth more than this need a manager's approval
+	ReservationExpiry       time.Duration // How long a reservation holds stock when it doesn't set its own expiry
+	ReservationSweep        time.Duration // How often reservations that ran out are expired
+}
+
+// NotificationConfig sets up the channels notifications go out through. A
+// channel without its settings, or every channel when Offline is set, is
+// replaced by a stand-in that only logs what it would have sent.
+type NotificationConfig struct {
+	Offline              bool
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	SMSGatewayURL        string
+	SMSGatewayKey        string
+	WebhookURL           string        // Used for users who turn webhooks on without a URL of their own
+	WebhookSecret        string        // Signs webhook bodies when set
+	WebhookHosts         []string      // Hosts users may point their own webhooks at, besides that of WebhookURL
+	LargeDiscountPercent float64       // Sales discounted by at least this share of their value raise an alert
+	LowStockRepeat       time.Duration // How long before low stock of the same product in a shop is reported again
+}
+
 func LoadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		return nil, fmt.Errorf("error loading .env file: %w", err)
@@ -61,6 +95,46 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_EXPIRES_IN_HOURS: %w", err)
 	}
 
+	priceWarningPercent, err := strconv.ParseFloat(getEnv("PURCHASE_PRICE_WARNING_PERCENT", "10"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PURCHASE_PRICE_WARNING_PERCENT: %w", err)
+	}
+
+	priceLookbackDays, err := strconv.Atoi(getEnv("PURCHASE_PRICE_LOOKBACK_DAYS", "90"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PURCHASE_PRICE_LOOKBACK_DAYS: %w", err)
+	}
+
+	adjustmentApprovalValue, err := strconv.ParseFloat(getEnv("STOCK_ADJUSTMENT_APPROVAL_VALUE", "5000"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STOCK_ADJUSTMENT_APPROVAL_VALUE: %w", err)
+	}
+
+	reservationExpiryHours, err := strconv.Atoi(getEnv("STOCK_RESERVATION_EXPIRY_HOURS", "48"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STOCK_RESERVATION_EXPIRY_HOURS: %w", err)
+	}
+
+	reservationSweepMinutes, err := strconv.Atoi(getEnv("STOCK_RESERVATION_SWEEP_MINUTES", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STOCK_RESERVATION_SWEEP_MINUTES: %w", err)
+	}
+
+	notifyOffline, err := strconv.ParseBool(getEnv("NOTIFY_OFFLINE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFY_OFFLINE: %w", err)
+	}
+
+	largeDiscountPercent, err := strconv.ParseFloat(getEnv("NOTIFY_LARGE_DISCOUNT_PERCENT", "20"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFY_LARGE_DISCOUNT_PERCENT: %w", err)
+	}
+
+	lowStockRepeatHours, err := strconv.Atoi(getEnv("NOTIFY_LOW_STOCK_REPEAT_HOURS", "24"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFY_LOW_STOCK_REPEAT_HOURS: %w", err)
+	}
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnv("SERVER_PORT", "8080"),
@@ -83,6 +157,30 @@ func LoadConfig() (*Config, error) {
 			Level: getEnv("LOG_LEVEL", "info"),
 			File:  getEnv("LOG_FILE", "app.log"),
 		},
+		Purchase: PurchaseConfig{
+			PriceWarningPercent: priceWarningPercent,
+			PriceLookbackDays:   priceLookbackDays,
+		},
+		Inventory: InventoryConfig{
+			AdjustmentApprovalValue: adjustmentApprovalValue,
+			ReservationExpiry:       time.Duration(reservationExpiryHours) * time.Hour,
+			ReservationSweep:        time.Duration(reservationSweepMinutes) * time.Minute,
+		},
+		Notification: NotificationConfig{
+			Offline:              notifyOffline,
+			SMTPHost:             getEnv("SMTP_HOST", ""),
+			SMTPPort:             getEnv("SMTP_PORT", "587"),
+			SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:             getEnv("SMTP_FROM", ""),
+			SMSGatewayURL:        getEnv("SMS_GATEWAY_URL", ""),
+			SMSGatewayKey:        getEnv("SMS_GATEWAY_KEY", ""),
+			WebhookURL:           getEnv("NOTIFY_WEBHOOK_URL", ""),
+			WebhookSecret:        getEnv("NOTIFY_WEBHOOK_SECRET", ""),
+			WebhookHosts:         splitList(getEnv("NOTIFY_WEBHOOK_HOSTS", "")),
+			LargeDiscountPercent: largeDiscountPercent,
+			LowStockRepeat:       time.Duration(lowStockRepeatHours) * time.Hour,
+		},
 	}, nil
 }
 
@@ -97,3 +195,14 @@ func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
 }
+
+// splitList reads a comma separated setting, skipping blank entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -8,27 +8,31 @@ import (
 )
 
 type CompanyDTO struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Address   string    `json:"address"`
-	Phone     string    `json:"phone"`
-	Email     string    `json:"email"`
-	Slogan    string    `json:"slogan"`
-	Remarks   string    `json:"remarks"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uuid.UUID              `json:"id"`
+	Name               string                 `json:"name"`
+	Address            string                 `json:"address"`
+	Phone              string                 `json:"phone"`
+	Email              string                 `json:"email"`
+	Slogan             string                 `json:"slogan"`
+	Remarks            string                 `json:"remarks"`
+	CostingMethod      entities.CostingMethod `json:"costing_method"`
+	CentralWarehouseID *uuid.UUID             `json:"central_warehouse_id,omitempty"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
 }
 
 func ToCompanyDTO(company *entities.Company) CompanyDTO {
 	return CompanyDTO{
-		ID:        company.ID,
-		Name:      company.Name,
-		Address:   company.Address,
-		Phone:     company.Phone,
-		Email:     company.Email,
-		Slogan:    company.Slogan,
-		Remarks:   company.Remarks,
-		CreatedAt: company.CreatedAt,
-		UpdatedAt: company.UpdatedAt,
+		ID:                 company.ID,
+		Name:               company.Name,
+		Address:            company.Address,
+		Phone:              company.Phone,
+		Email:              company.Email,
+		Slogan:             company.Slogan,
+		Remarks:            company.Remarks,
+		CostingMethod:      company.CostingMethod,
+		CentralWarehouseID: company.CentralWarehouseID,
+		CreatedAt:          company.CreatedAt,
+		UpdatedAt:          company.UpdatedAt,
 	}
 }
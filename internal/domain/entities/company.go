@@ -1,12 +1,18 @@
 package entities
 
+import (
+	"github.com/google/uuid"
+)
+
 type Company struct {
 	Base
-	Name    string `json:"name" binding:"required"`
-	Address string `json:"address" binding:"required"`
-	Phone   string `json:"phone" binding:"required"`
-	Email   string `json:"email" binding:"required,email"`
-	Slogan  string `json:"slogan"`
-	Remarks string `json:"remarks"`
-	Shops   []Shop `gorm:"foreignKey:CompanyID" json:"shops,omitempty"`
+	Name               string        `json:"name" binding:"required"`
+	Address            string        `json:"address" binding:"required"`
+	Phone              string        `json:"phone" binding:"required"`
+	Email              string        `json:"email" binding:"required,email"`
+	Slogan             string        `json:"slogan"`
+	Remarks            string        `json:"remarks"`
+	CostingMethod      CostingMethod `gorm:"type:varchar(20);not null;default:'AVERAGE'" json:"costing_method"`
+	CentralWarehouseID *uuid.UUID    `gorm:"type:uuid" json:"central_warehouse_id,omitempty"` // Where stock transfers without a source come from
+	Shops              []Shop        `gorm:"foreignKey:CompanyID" json:"shops,omitempty"`
 }
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CostingMethod string
+
+const (
+	CostingMethodAverage CostingMethod = "AVERAGE"
+	CostingMethodFIFO    CostingMethod = "FIFO"
+)
+
+type CostSourceType string
+
+const (
+	CostSourcePurchase     CostSourceType = "PURCHASE"
+	CostSourceGoodsReceipt CostSourceType = "GOODS_RECEIPT"
+	CostSourceSaleReversal CostSourceType = "SALE_REVERSAL"
+	CostSourceAdjustment   CostSourceType = "ADJUSTMENT"
+	CostSourceTransfer     CostSourceType = "TRANSFER"
+)
+
+// CostLayer is a batch of stock received at one unit cost. FIFO costing
+// consumes the oldest layers first.
+type CostLayer struct {
+	Base
+	ProductID         uuid.UUID      `gorm:"type:uuid;not null;index:idx_cost_layer_stock" json:"product_id"`
+	ShopID            uuid.UUID      `gorm:"type:uuid;not null;index:idx_cost_layer_stock" json:"shop_id"`
+	SourceType        CostSourceType `gorm:"type:varchar(20);not null" json:"source_type"`
+	SourceID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"source_id"`
+	ReceivedAt        time.Time      `gorm:"not null" json:"received_at"`
+	Quantity          int            `gorm:"not null" json:"quantity"`
+	RemainingQuantity int            `gorm:"not null" json:"remaining_quantity"`
+	UnitCost          float64        `gorm:"type:decimal(10,4);not null" json:"unit_cost"`
+}
+
+// GrossMargin is revenue against cost of goods sold for one invoice,
+// product or shop
+type GrossMargin struct {
+	Key             string  `json:"key"`
+	Name            string  `json:"name"`
+	Quantity        int     `json:"quantity"`
+	Revenue         float64 `json:"revenue"`
+	CostOfGoodsSold float64 `json:"cost_of_goods_sold"`
+	GrossMargin     float64 `json:"gross_margin"`
+	MarginPercent   float64 `json:"margin_percent"`
+}
@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BaseCurrency is the currency the books are kept in. Amounts without a
+// currency of their own are in taka.
+const BaseCurrency = "BDT"
+
+// Currency is a currency suppliers invoice or are paid in
+type Currency struct {
+	Base
+	Code   string `gorm:"type:varchar(3);not null;uniqueIndex" json:"code"` // ISO 4217 code, e.g. USD
+	Name   string `gorm:"type:varchar(100);not null" json:"name"`
+	Symbol string `gorm:"type:varchar(10)" json:"symbol"`
+}
+
+// ExchangeRate is what one unit of a currency was worth in taka on a day.
+// Rates are entered by hand; a document uses the latest rate on or before
+// its date unless it is given one.
+type ExchangeRate struct {
+	Base
+	CurrencyCode string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_currency_date" json:"currency_code"`
+	RateDate     time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_currency_date" json:"rate_date"`
+	Rate         float64   `gorm:"type:decimal(18,6);not null" json:"rate"`
+}
+
+// FxSettlement is the exchange gain or loss realised when part of a payment
+// settled a foreign currency invoice. A gain means the taka paid was less
+// than the taka the invoice was booked at.
+type FxSettlement struct {
+	PaymentAllocationID uuid.UUID `json:"payment_allocation_id"`
+	PaymentID           uuid.UUID `json:"payment_id"`
+	PaymentDateTime     time.Time `json:"payment_datetime"`
+	PurchaseInvoiceID   uuid.UUID `json:"purchase_invoice_id"`
+	PurchaseDateTime    time.Time `json:"purchase_datetime"`
+	SupplierID          uuid.UUID `json:"supplier_id"`
+	SupplierName        string    `json:"supplier_name"`
+	CurrencyCode        string    `json:"currency_code"`
+	CurrencyAmount      float64   `json:"currency_amount"`
+	InvoiceRate         float64   `json:"invoice_rate"`
+	PaymentRate         float64   `json:"payment_rate"`
+	BookedAmount        float64   `json:"booked_amount"` // Taka cleared from the invoice
+	PaidAmount          float64   `json:"paid_amount"`   // Taka actually paid
+	GainLoss            float64   `json:"gain_loss"`
+}
+
+// FxGainLossReport totals the realised exchange differences over a period
+type FxGainLossReport struct {
+	Settlements []FxSettlement `json:"settlements"`
+	TotalGain   float64        `json:"total_gain"`
+	TotalLoss   float64        `json:"total_loss"`
+	Net         float64        `json:"net"`
+}
@@ -2,14 +2,66 @@ package entities
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// Inventory represents the stock level of a product in a shop
+// Inventory represents the stock level of a product in a shop. Quantity is
+// what is on hand; ReservedQuantity of it is promised to active stock
+// reservations and the rest is available to sell.
 type Inventory struct {
 	Base
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
-	ShopID    uuid.UUID `json:"shop_id" gorm:"type:uuid;not null"`
-	Quantity  int       `json:"quantity" gorm:"not null;default:0"`
-	Product   *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
-	Shop      *Shop     `json:"shop,omitempty" gorm:"foreignKey:ShopID"`
+	ProductID         uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_inventory_product_shop,where:is_marked_to_delete = false"`
+	ShopID            uuid.UUID `json:"shop_id" gorm:"type:uuid;not null;uniqueIndex:idx_inventory_product_shop,where:is_marked_to_delete = false"`
+	Quantity          int       `json:"quantity" gorm:"not null;default:0;check:chk_inventory_quantity_non_negative,quantity >= 0"`
+	ReservedQuantity  int       `json:"reserved_quantity" gorm:"not null;default:0;check:chk_inventory_reserved_non_negative,reserved_quantity >= 0"`
+	AvailableQuantity int       `json:"available_quantity" gorm:"-"`
+	AverageCost       float64   `json:"average_cost" gorm:"type:decimal(10,4);not null;default:0"` // Weighted average unit cost of the stock on hand
+	Product           *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Shop              *Shop     `json:"shop,omitempty" gorm:"foreignKey:ShopID;references:ShopID"`
+}
+
+// Available is the stock on hand that isn't reserved. Stock lost or damaged
+// after it was reserved can leave less on hand than reserved, which leaves
+// nothing available rather than a negative quantity.
+func (i *Inventory) Available() int {
+	if i.Quantity <= i.ReservedQuantity {
+		return 0
+	}
+	return i.Quantity - i.ReservedQuantity
+}
+
+func (i *Inventory) AfterFind(tx *gorm.DB) error {
+	i.AvailableQuantity = i.Available()
+	return nil
+}
+
+// ProductStock is one product's stock across the shops that hold it
+type ProductStock struct {
+	Product       *Product    `json:"product"`
+	TotalQuantity int         `json:"total_quantity"`
+	TotalReserved int         `json:"total_reserved"`
+	Available     int         `json:"available"`
+	Shops         []Inventory `json:"shops"`
+}
+
+// StockLevelSummary counts a shop's products against their reorder settings.
+// LowStock is at or below the reorder point or minimum, BelowMin under the
+// minimum and OverMax above a set maximum.
+type StockLevelSummary struct {
+	OutOfStock int64 `json:"out_of_stock"`
+	LowStock   int64 `json:"low_stock"`
+	BelowMin   int64 `json:"below_min"`
+	OverMax    int64 `json:"over_max"`
+}
+
+// ShopStockTotal sums the stock one shop holds
+type ShopStockTotal struct {
+	ShopID        uuid.UUID    `json:"shop_id"`
+	ShopName      string       `json:"shop_name"`
+	LocationType  LocationType `json:"location_type"`
+	ProductCount  int          `json:"product_count"` // Products with stock on hand
+	TotalQuantity int          `json:"total_quantity"`
+	TotalReserved int          `json:"total_reserved"`
+	StockValue    float64      `json:"stock_value"`  // At average cost
+	RetailValue   float64      `json:"retail_value"` // At the selling price
 }
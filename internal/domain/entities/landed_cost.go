@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+type LandedCostType string
+
+const (
+	LandedCostTypeFreight     LandedCostType = "FREIGHT"
+	LandedCostTypeCustomsDuty LandedCostType = "CUSTOMS_DUTY"
+	LandedCostTypeClearing    LandedCostType = "CLEARING"
+	LandedCostTypeOther       LandedCostType = "OTHER"
+)
+
+type LandedCostAllocationMethod string
+
+const (
+	AllocateByValue    LandedCostAllocationMethod = "VALUE"
+	AllocateByQuantity LandedCostAllocationMethod = "QUANTITY"
+	AllocateByWeight   LandedCostAllocationMethod = "WEIGHT"
+)
+
+// LandedCost is a charge such as freight or duty incurred to bring a
+// purchase in, spread over the invoice lines by the chosen method
+type LandedCost struct {
+	Base
+	PurchaseInvoiceID uuid.UUID                  `gorm:"type:uuid;not null" json:"purchase_invoice_id"`
+	CostType          LandedCostType             `gorm:"type:varchar(20);not null" json:"cost_type"`
+	Amount            float64                    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	AllocationMethod  LandedCostAllocationMethod `gorm:"type:varchar(20);not null" json:"allocation_method"`
+	Remarks           string                     `gorm:"type:text" json:"remarks"`
+}
+
+// LandedCostLine shows how a purchase line's cost is built up
+type LandedCostLine struct {
+	PurchaseDetailID uuid.UUID `json:"purchase_detail_id"`
+	ProductID        uuid.UUID `json:"product_id"`
+	Quantity         int       `json:"quantity"`
+	PurchasePrice    float64   `json:"purchase_price"`
+	LandedCost       float64   `json:"landed_cost"`
+	LandedUnitCost   float64   `json:"landed_unit_cost"`
+	SalesPrice       float64   `json:"sales_price"`
+	UnitMargin       float64   `json:"unit_margin"`
+	MarginPercent    float64   `json:"margin_percent"`
+}
+
+// LandedCostSummary is the landed cost breakdown of a purchase invoice
+type LandedCostSummary struct {
+	PurchaseInvoiceID uuid.UUID        `json:"purchase_invoice_id"`
+	GoodsValue        float64          `json:"goods_value"`
+	LandedCostTotal   float64          `json:"landed_cost_total"`
+	Costs             []LandedCost     `json:"costs"`
+	Lines             []LandedCostLine `json:"lines"`
+}
@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationTypeLowStock         NotificationType = "LOW_STOCK"
+	NotificationTypeAwaitingApproval NotificationType = "AWAITING_APPROVAL"
+	NotificationTypeLargeDiscount    NotificationType = "LARGE_DISCOUNT"
+	NotificationTypeImportFailed     NotificationType = "IMPORT_FAILED"
+	NotificationTypeTest             NotificationType = "TEST"
+)
+
+// NotificationTypes are the alerts users can set preferences for
+var NotificationTypes = []NotificationType{
+	NotificationTypeLowStock,
+	NotificationTypeAwaitingApproval,
+	NotificationTypeLargeDiscount,
+	NotificationTypeImportFailed,
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp   NotificationChannel = "IN_APP"
+	NotificationChannelEmail   NotificationChannel = "EMAIL"
+	NotificationChannelSMS     NotificationChannel = "SMS"
+	NotificationChannelWebhook NotificationChannel = "WEBHOOK"
+)
+
+// NotificationChannels are the ways a notification can reach a user
+var NotificationChannels = []NotificationChannel{
+	NotificationChannelInApp,
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+	NotificationChannelWebhook,
+}
+
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliverySent   NotificationDeliveryStatus = "SENT"
+	NotificationDeliveryFailed NotificationDeliveryStatus = "FAILED"
+)
+
+// Alert is something worth telling the managers of a shop, or the admins
+// when it concerns no shop. Alerts with the same Reference are raised once
+// until the repeat interval has passed.
+type Alert struct {
+	Type       NotificationType
+	Title      string
+	Message    string
+	ShopID     *uuid.UUID
+	DocumentID *uuid.UUID
+	Reference  string
+}
+
+// Notification is an alert in one user's inbox. Users who turned the in-app
+// channel off for its type get it already read.
+type Notification struct {
+	Base
+	UserID     uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type       NotificationType `gorm:"type:varchar(30);not null" json:"type"`
+	Title      string           `gorm:"type:varchar(200);not null" json:"title"`
+	Message    string           `gorm:"type:text" json:"message"`
+	ShopID     *uuid.UUID       `gorm:"type:uuid" json:"shop_id,omitempty"`
+	DocumentID *uuid.UUID       `gorm:"type:uuid" json:"document_id,omitempty"` // The sale, adjustment or other document the alert is about
+	Reference  string           `gorm:"type:varchar(200);index" json:"-"`
+	ReadAt     *time.Time       `json:"read_at,omitempty"`
+
+	// Relations
+	Deliveries []NotificationDelivery `gorm:"foreignKey:NotificationID" json:"deliveries,omitempty"`
+}
+
+// NotificationDelivery records sending a notification through a channel
+// other than the inbox
+type NotificationDelivery struct {
+	Base
+	NotificationID uuid.UUID                  `gorm:"type:uuid;not null;index" json:"notification_id"`
+	Channel        NotificationChannel        `gorm:"type:varchar(20);not null" json:"channel"`
+	Target         string                     `gorm:"type:varchar(255)" json:"target"`
+	Status         NotificationDeliveryStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error          string                     `gorm:"type:text" json:"error,omitempty"`
+}
+
+// NotificationPreference turns a channel on or off for one type of alert for
+// a user. Without one only the in-app channel is on. Target replaces the
+// user's email address or phone number, and gives the URL for webhooks.
+type NotificationPreference struct {
+	Base
+	UserID  uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_notification_preference_user_type_channel" json:"user_id"`
+	Type    NotificationType    `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preference_user_type_channel" json:"type"`
+	Channel NotificationChannel `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_preference_user_type_channel" json:"channel"`
+	Enabled bool                `gorm:"not null;default:false" json:"enabled"`
+	Target  string              `gorm:"type:varchar(255)" json:"target,omitempty"`
+}
+
+// DefaultNotificationPreference is the setting used for a channel the user
+// has no preference for
+func DefaultNotificationPreference(userID uuid.UUID, notificationType NotificationType, channel NotificationChannel) NotificationPreference {
+	return NotificationPreference{
+		UserID:  userID,
+		Type:    notificationType,
+		Channel: channel,
+		Enabled: channel == NotificationChannelInApp,
+	}
+}
@@ -18,11 +18,57 @@ type Payment struct {
 	Type            PaymentEntityType `gorm:"type:varchar(20);not null" json:"type"`
 	SupplierID      *uuid.UUID        `gorm:"type:uuid" json:"supplier_id,omitempty"`
 	CustomerID      *uuid.UUID        `gorm:"type:uuid" json:"customer_id,omitempty"`
-	Amount          float64           `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Amount          float64           `gorm:"type:decimal(10,2);not null" json:"amount"` // In taka
+	CurrencyCode    string            `gorm:"type:varchar(3);not null;default:'BDT'" json:"currency_code"`
+	ExchangeRate    float64           `gorm:"type:decimal(18,6);not null;default:1" json:"exchange_rate"`   // Taka per unit of the payment currency
+	CurrencyAmount  float64           `gorm:"type:decimal(12,2);not null;default:0" json:"currency_amount"` // Amount in the payment currency
 	PaymentDateTime time.Time         `gorm:"not null" json:"payment_datetime"`
 	Remarks         string            `gorm:"type:text" json:"remarks"`
 
 	// Relations
-	Supplier *Supplier `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
-	Customer *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Supplier    *Supplier           `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Customer    *Customer           `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Allocations []PaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations,omitempty"`
+}
+
+// PaymentAllocation settles part of a supplier payment against a purchase
+// invoice. Amount is the taka cleared from the invoice at the invoice's rate;
+// the payment's rate may differ, which is the realised exchange gain or loss.
+type PaymentAllocation struct {
+	Base
+	PaymentID         uuid.UUID `gorm:"type:uuid;not null" json:"payment_id"`
+	PurchaseInvoiceID uuid.UUID `gorm:"type:uuid;not null" json:"purchase_invoice_id"`
+	Amount            float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CurrencyAmount    float64   `gorm:"type:decimal(12,2);not null;default:0" json:"currency_amount"` // In the payment and invoice currency
+	FxGainLoss        float64   `gorm:"type:decimal(10,2);not null;default:0" json:"fx_gain_loss"`    // Amount less the taka actually paid
+
+	// Relations
+	PurchaseInvoice *PurchaseInvoice `gorm:"foreignKey:PurchaseInvoiceID" json:"purchase_invoice,omitempty"`
+}
+
+// OutstandingInvoice is a credit purchase that is not yet fully settled
+type OutstandingInvoice struct {
+	PurchaseInvoiceID   uuid.UUID `json:"purchase_invoice_id"`
+	SupplierID          uuid.UUID `json:"supplier_id"`
+	PurchaseDateTime    time.Time `json:"purchase_datetime"`
+	Total               float64   `json:"total"`
+	Returned            float64   `json:"returned"`
+	Paid                float64   `json:"paid"`
+	Outstanding         float64   `json:"outstanding"`
+	CurrencyCode        string    `json:"currency_code"`
+	ExchangeRate        float64   `json:"exchange_rate"`
+	CurrencyOutstanding float64   `json:"currency_outstanding"` // Outstanding in the invoice currency
+	AgeDays             int       `json:"age_days"`
+}
+
+// SupplierAging buckets what we owe a supplier by invoice age
+type SupplierAging struct {
+	SupplierID   uuid.UUID `json:"supplier_id"`
+	SupplierName string    `json:"supplier_name"`
+	Current      float64   `json:"current"` // 0-30 days
+	Days31To60   float64   `json:"days_31_60"`
+	Days61To90   float64   `json:"days_61_90"`
+	Over90       float64   `json:"over_90"`
+	Unallocated  float64   `json:"unallocated"` // Paid but not yet matched to invoices
+	Outstanding  float64   `json:"outstanding"`
 }
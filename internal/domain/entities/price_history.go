@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceHistoryEntry is one price paid to a supplier for a product, taken
+// from a purchase invoice line or a goods receipt against an order
+type PriceHistoryEntry struct {
+	ProductID    uuid.UUID      `json:"product_id"`
+	SupplierID   uuid.UUID      `json:"supplier_id"`
+	SupplierName string         `json:"supplier_name"`
+	SourceType   CostSourceType `json:"source_type"`
+	SourceID     uuid.UUID      `json:"source_id"`
+	PurchasedAt  time.Time      `json:"purchased_at"`
+	Quantity     int            `json:"quantity"`
+	UnitPrice    float64        `json:"unit_price"`
+}
+
+type PriceTrend string
+
+const (
+	PriceTrendUp     PriceTrend = "UP"
+	PriceTrendDown   PriceTrend = "DOWN"
+	PriceTrendStable PriceTrend = "STABLE"
+)
+
+// SupplierPriceSummary compares what one supplier has charged for a product.
+// The trend compares the last price with the average of the earlier ones.
+type SupplierPriceSummary struct {
+	SupplierID      uuid.UUID  `json:"supplier_id"`
+	SupplierName    string     `json:"supplier_name"`
+	LastPrice       float64    `json:"last_price"`
+	LastPurchasedAt time.Time  `json:"last_purchased_at"`
+	MinPrice        float64    `json:"min_price"`
+	MaxPrice        float64    `json:"max_price"`
+	AveragePrice    float64    `json:"average_price"` // Weighted by quantity
+	PurchaseCount   int        `json:"purchase_count"`
+	Trend           PriceTrend `json:"trend"`
+	TrendPercent    float64    `json:"trend_percent"`
+}
+
+type ProductPriceHistory struct {
+	ProductID uuid.UUID              `json:"product_id"`
+	Suppliers []SupplierPriceSummary `json:"suppliers"`
+	History   []PriceHistoryEntry    `json:"history"`
+}
+
+// PriceWarning flags a purchase line priced well above what was recently
+// paid for the product
+type PriceWarning struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	UnitPrice    float64   `json:"unit_price"`
+	AveragePrice float64   `json:"average_price"`
+	PercentAbove float64   `json:"percent_above"`
+	LookbackDays int       `json:"lookback_days"`
+	Message      string    `json:"message"`
+}
@@ -27,6 +27,10 @@ type Product struct {
 	SalesType      SalesType  `json:"sales_type" gorm:"type:varchar(20);not null"`
 	ShopID         uuid.UUID  `json:"shop_id" gorm:"type:uuid;not null"`
 	Remarks        string     `json:"remarks" gorm:"type:text"`
+	StyleID        *uuid.UUID `json:"style_id,omitempty" gorm:"type:uuid;index"`   // The style this is a colour and size of
+	TrackLots      bool       `json:"track_lots" gorm:"not null;default:false"`    // Keep stock per dye lot or production batch
+	TrackSerials   bool       `json:"track_serials" gorm:"not null;default:false"` // Follow every unit by its serial number
+	WarrantyMonths int        `json:"warranty_months" gorm:"not null;default:0"`   // Warranty from the sale date for serialised units
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 	DeletedAt      *time.Time `json:"deleted_at,omitempty" gorm:"index"`
@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// ProductStyle is a garment style whose colours and sizes are sold as
+// variants. Each variant is a Product of its own for stock and sales, and
+// inherits the style's name, categories, prices and sales type.
+type ProductStyle struct {
+	Base
+	Code           string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"` // Also the style of every variant
+	Name           string    `gorm:"type:varchar(100);not null" json:"name"`
+	MasterCategory string    `gorm:"type:varchar(50);not null" json:"master_category"`
+	SubCategory    string    `gorm:"type:varchar(50);not null" json:"sub_category"`
+	PurchasePrice  float64   `gorm:"not null" json:"purchase_price"`
+	SalesPrice     float64   `gorm:"not null" json:"sales_price"`
+	SalesType      SalesType `gorm:"type:varchar(20);not null" json:"sales_type"`
+	ShopID         uuid.UUID `gorm:"type:uuid;not null" json:"shop_id"`
+	Remarks        string    `gorm:"type:text" json:"remarks"`
+
+	// Relations
+	Variants []Product `gorm:"foreignKey:StyleID" json:"variants,omitempty"`
+}
+
+// Inherit copies the style's shared attributes onto a variant
+func (s *ProductStyle) Inherit(variant *Product) {
+	styleID := s.ID
+	variant.StyleID = &styleID
+	variant.Name = s.Name
+	variant.Style = s.Code
+	variant.MasterCategory = s.MasterCategory
+	variant.SubCategory = s.SubCategory
+	variant.PurchasePrice = s.PurchasePrice
+	variant.SalesPrice = s.SalesPrice
+	variant.SalesType = s.SalesType
+	variant.ShopID = s.ShopID
+}
+
+// StockMatrixCell is the stock of one colour and size of a style
+type StockMatrixCell struct {
+	Size      string     `json:"size"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"` // Empty when the style has no such variant
+	Quantity  int        `json:"quantity"`
+	Reserved  int        `json:"reserved"`
+	Available int        `json:"available"`
+}
+
+// StockMatrixRow is one colour of a style across its sizes
+type StockMatrixRow struct {
+	Color string            `json:"color"`
+	Cells []StockMatrixCell `json:"cells"`
+	Total int               `json:"total"`
+}
+
+// StockMatrix lays out a style's stock with a row per colour and a column
+// per size
+type StockMatrix struct {
+	Style      *ProductStyle    `json:"style"`
+	ShopID     *uuid.UUID       `json:"shop_id,omitempty"` // Every shop when empty
+	Colors     []string         `json:"colors"`
+	Sizes      []string         `json:"sizes"`
+	Rows       []StockMatrixRow `json:"rows"`
+	SizeTotals []int            `json:"size_totals"`
+	Total      int              `json:"total"`
+}
+
+// StyleStock is the stock of all of a style's variants added together
+type StyleStock struct {
+	StyleID        uuid.UUID `json:"style_id"`
+	Code           string    `json:"code"`
+	Name           string    `json:"name"`
+	MasterCategory string    `json:"master_category"`
+	Variants       int       `json:"variants"`
+	TotalQuantity  int       `json:"total_quantity"`
+	TotalReserved  int       `json:"total_reserved"`
+	Available      int       `json:"available"`
+	StockValue     float64   `json:"stock_value"` // At average cost
+}
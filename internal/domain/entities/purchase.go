@@ -16,26 +16,75 @@ const (
 type PurchaseInvoice struct {
 	Base
 	SupplierID       uuid.UUID   `gorm:"type:uuid;not null" json:"supplier_id"`
+	ShopID           uuid.UUID   `gorm:"type:uuid;not null" json:"shop_id"`            // Shop receiving the goods
+	PurchaseOrderID  *uuid.UUID  `gorm:"type:uuid" json:"purchase_order_id,omitempty"` // Set when billed against an order
 	PurchaseDateTime time.Time   `gorm:"not null" json:"purchase_datetime"`
-	Total            float64     `gorm:"type:decimal(10,2);not null" json:"total"`
+	Total            float64     `gorm:"type:decimal(10,2);not null" json:"total"` // In taka
+	CurrencyCode     string      `gorm:"type:varchar(3);not null;default:'BDT'" json:"currency_code"`
+	ExchangeRate     float64     `gorm:"type:decimal(18,6);not null;default:1" json:"exchange_rate"`  // Taka per unit of the invoice currency
+	CurrencyTotal    float64     `gorm:"type:decimal(12,2);not null;default:0" json:"currency_total"` // Total in the invoice currency
 	PaymentType      PaymentType `gorm:"type:varchar(20);not null" json:"payment_type"`
 	EntryByID        uuid.UUID   `gorm:"type:uuid;not null" json:"entry_by_id"`
 	Remarks          string      `gorm:"type:text" json:"remarks"`
+	LockedAt         *time.Time  `json:"locked_at,omitempty"` // Locked purchases can no longer be edited
 
 	// Relations
-	Supplier        *Supplier        `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
-	EntryBy         *User            `gorm:"foreignKey:EntryByID" json:"entry_by,omitempty"`
-	PurchaseDetails []PurchaseDetail `gorm:"foreignKey:PurchaseInvoiceID" json:"purchase_details,omitempty"`
+	Supplier        *Supplier          `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Shop            *Shop              `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	EntryBy         *User              `gorm:"foreignKey:EntryByID" json:"entry_by,omitempty"`
+	PurchaseOrder   *PurchaseOrder     `gorm:"foreignKey:PurchaseOrderID" json:"purchase_order,omitempty"`
+	PurchaseDetails []PurchaseDetail   `gorm:"foreignKey:PurchaseInvoiceID" json:"purchase_details,omitempty"`
+	SupplierReturns []SupplierReturn   `gorm:"foreignKey:PurchaseInvoiceID" json:"supplier_returns,omitempty"`
+	LandedCosts     []LandedCost       `gorm:"foreignKey:PurchaseInvoiceID" json:"landed_costs,omitempty"`
+	Revisions       []PurchaseRevision `gorm:"foreignKey:PurchaseInvoiceID" json:"revisions,omitempty"`
+
+	PriceWarnings []PriceWarning `gorm:"-" json:"price_warnings,omitempty"` // Filled in when the purchase is created
 }
 
 type PurchaseDetail struct {
 	Base
-	PurchaseInvoiceID uuid.UUID `gorm:"type:uuid;not null" json:"purchase_invoice_id"`
-	ProductID         uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
-	Quantity          int       `gorm:"not null" json:"quantity"`
-	PurchasePrice     float64   `gorm:"type:decimal(10,2);not null" json:"purchase_price"`
+	PurchaseInvoiceID uuid.UUID  `gorm:"type:uuid;not null" json:"purchase_invoice_id"`
+	ProductID         uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity          int        `gorm:"not null" json:"quantity"`
+	PurchasePrice     float64    `gorm:"type:decimal(10,2);not null" json:"purchase_price"`             // In taka
+	CurrencyPrice     float64    `gorm:"type:decimal(12,4);not null;default:0" json:"currency_price"`   // In the invoice currency
+	Weight            float64    `gorm:"type:decimal(10,3);not null;default:0" json:"weight"`           // Line weight, used for weight based landed cost
+	LandedCost        float64    `gorm:"type:decimal(10,2);not null;default:0" json:"landed_cost"`      // Share of the invoice's landed costs
+	LandedUnitCost    float64    `gorm:"type:decimal(10,4);not null;default:0" json:"landed_unit_cost"` // Purchase price plus landed cost per unit
+	LotNumber         string     `gorm:"type:varchar(50)" json:"lot_number,omitempty"`                  // For products that track lots; generated when empty
+	LotID             *uuid.UUID `gorm:"type:uuid" json:"lot_id,omitempty"`                             // Set when the goods are received
+	SerialNumbers     []string   `gorm:"-" json:"serial_numbers,omitempty"`                             // One per unit received, for products that track serial numbers
 
 	// Relations
 	PurchaseInvoice *PurchaseInvoice `gorm:"foreignKey:PurchaseInvoiceID" json:"purchase_invoice,omitempty"`
 	Product         *Product         `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Lot             *StockLot        `gorm:"foreignKey:LotID" json:"lot,omitempty"`
+}
+
+// PurchaseRevision keeps a purchase as it was before an edit
+type PurchaseRevision struct {
+	Base
+	PurchaseInvoiceID uuid.UUID   `gorm:"type:uuid;not null;index" json:"purchase_invoice_id"`
+	Revision          int         `gorm:"not null" json:"revision"`
+	SupplierID        uuid.UUID   `gorm:"type:uuid;not null" json:"supplier_id"`
+	PurchaseDateTime  time.Time   `gorm:"not null" json:"purchase_datetime"`
+	Total             float64     `gorm:"type:decimal(10,2);not null" json:"total"`
+	PaymentType       PaymentType `gorm:"type:varchar(20);not null" json:"payment_type"`
+	Remarks           string      `gorm:"type:text" json:"remarks"`
+	EditedByID        uuid.UUID   `gorm:"type:uuid;not null" json:"edited_by_id"`
+	Reason            string      `gorm:"type:text" json:"reason"`
+
+	// Relations
+	EditedBy *User                  `gorm:"foreignKey:EditedByID" json:"edited_by,omitempty"`
+	Lines    []PurchaseRevisionLine `gorm:"foreignKey:PurchaseRevisionID" json:"lines,omitempty"`
+}
+
+type PurchaseRevisionLine struct {
+	Base
+	PurchaseRevisionID uuid.UUID `gorm:"type:uuid;not null" json:"purchase_revision_id"`
+	PurchaseDetailID   uuid.UUID `gorm:"type:uuid;not null" json:"purchase_detail_id"`
+	ProductID          uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity           int       `gorm:"not null" json:"quantity"`
+	PurchasePrice      float64   `gorm:"type:decimal(10,2);not null" json:"purchase_price"`
+	Weight             float64   `gorm:"type:decimal(10,3);not null;default:0" json:"weight"`
 }
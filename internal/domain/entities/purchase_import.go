@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+type ImportLineStatus string
+
+const (
+	ImportLineMatched   ImportLineStatus = "MATCHED"
+	ImportLineUnmatched ImportLineStatus = "UNMATCHED" // No product found, may be a new product
+	ImportLineInvalid   ImportLineStatus = "INVALID"
+)
+
+type ImportMatch string
+
+const (
+	ImportMatchProductCode  ImportMatch = "PRODUCT_CODE"
+	ImportMatchSupplierCode ImportMatch = "SUPPLIER_CODE"
+)
+
+// PurchaseImportLine is one row of a supplier's invoice file and the product
+// it was matched to. Price differences are against the last price paid to
+// the same supplier.
+type PurchaseImportLine struct {
+	Row                    int              `json:"row"`
+	SupplierCode           string           `json:"supplier_code"`
+	Description            string           `json:"description,omitempty"`
+	Quantity               int              `json:"quantity"`
+	UnitPrice              float64          `json:"unit_price"`
+	Status                 ImportLineStatus `json:"status"`
+	Error                  string           `json:"error,omitempty"`
+	ProductID              *uuid.UUID       `json:"product_id,omitempty"`
+	ProductCode            string           `json:"product_code,omitempty"`
+	ProductName            string           `json:"product_name,omitempty"`
+	MatchedBy              ImportMatch      `json:"matched_by,omitempty"`
+	LastPrice              *float64         `json:"last_price,omitempty"`
+	PriceDifference        float64          `json:"price_difference"`
+	PriceDifferencePercent float64          `json:"price_difference_percent"`
+}
+
+// PurchaseImportPreview is a parsed supplier invoice awaiting confirmation.
+// Nothing is saved until the lines are confirmed.
+type PurchaseImportPreview struct {
+	SupplierID     uuid.UUID            `json:"supplier_id"`
+	FileName       string               `json:"file_name"`
+	Lines          []PurchaseImportLine `json:"lines"`
+	MatchedCount   int                  `json:"matched_count"`
+	UnmatchedCount int                  `json:"unmatched_count"`
+	InvalidCount   int                  `json:"invalid_count"`
+	MatchedTotal   float64              `json:"matched_total"`
+}
@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "DRAFT"
+	PurchaseOrderStatusSent              PurchaseOrderStatus = "SENT"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "PARTIALLY_RECEIVED"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "CLOSED"
+)
+
+// PurchaseOrder is what we ask a supplier to deliver to a shop
+type PurchaseOrder struct {
+	Base
+	SupplierID       uuid.UUID           `gorm:"type:uuid;not null" json:"supplier_id"`
+	ShopID           uuid.UUID           `gorm:"type:uuid;not null" json:"shop_id"`
+	OrderDateTime    time.Time           `gorm:"not null" json:"order_datetime"`
+	ExpectedDateTime *time.Time          `json:"expected_datetime,omitempty"`
+	Status           PurchaseOrderStatus `gorm:"type:varchar(20);not null;default:'DRAFT'" json:"status"`
+	Total            float64             `gorm:"type:decimal(10,2);not null" json:"total"`
+	CreatedByID      uuid.UUID           `gorm:"type:uuid;not null" json:"created_by_id"`
+	SentAt           *time.Time          `json:"sent_at,omitempty"`
+	ClosedAt         *time.Time          `json:"closed_at,omitempty"`
+	Remarks          string              `gorm:"type:text" json:"remarks"`
+
+	// Relations
+	Supplier  *Supplier           `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Shop      *Shop               `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	CreatedBy *User               `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	Lines     []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID" json:"lines,omitempty"`
+}
+
+type PurchaseOrderLine struct {
+	Base
+	PurchaseOrderID  uuid.UUID `gorm:"type:uuid;not null" json:"purchase_order_id"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	OrderedQuantity  int       `gorm:"not null" json:"ordered_quantity"`
+	ReceivedQuantity int       `gorm:"not null;default:0" json:"received_quantity"`
+	UnitPrice        float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+
+	// Relations
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// GoodsReceipt records one delivery against a purchase order
+type GoodsReceipt struct {
+	Base
+	PurchaseOrderID  uuid.UUID `gorm:"type:uuid;not null" json:"purchase_order_id"`
+	ShopID           uuid.UUID `gorm:"type:uuid;not null" json:"shop_id"`
+	ReceivedDateTime time.Time `gorm:"not null" json:"received_datetime"`
+	ReceivedByID     uuid.UUID `gorm:"type:uuid;not null" json:"received_by_id"`
+	Remarks          string    `gorm:"type:text" json:"remarks"`
+
+	// Relations
+	PurchaseOrder *PurchaseOrder     `gorm:"foreignKey:PurchaseOrderID" json:"purchase_order,omitempty"`
+	ReceivedBy    *User              `gorm:"foreignKey:ReceivedByID" json:"received_by,omitempty"`
+	Lines         []GoodsReceiptLine `gorm:"foreignKey:GoodsReceiptID" json:"lines,omitempty"`
+}
+
+type GoodsReceiptLine struct {
+	Base
+	GoodsReceiptID      uuid.UUID  `gorm:"type:uuid;not null" json:"goods_receipt_id"`
+	PurchaseOrderLineID uuid.UUID  `gorm:"type:uuid;not null" json:"purchase_order_line_id"`
+	ProductID           uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity            int        `gorm:"not null" json:"quantity"`
+	LotNumber           string     `gorm:"type:varchar(50)" json:"lot_number,omitempty"` // For products that track lots; generated when empty
+	LotID               *uuid.UUID `gorm:"type:uuid" json:"lot_id,omitempty"`
+	SerialNumbers       []string   `gorm:"-" json:"serial_numbers,omitempty"` // One per unit received, for products that track serial numbers
+
+	// Relations
+	Product *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Lot     *StockLot `gorm:"foreignKey:LotID" json:"lot,omitempty"`
+}
+
+// PurchaseMatchLine compares one product across order, receipts and invoices
+type PurchaseMatchLine struct {
+	ProductID        uuid.UUID `json:"product_id"`
+	OrderedQuantity  int       `json:"ordered_quantity"`
+	ReceivedQuantity int       `json:"received_quantity"`
+	InvoicedQuantity int       `json:"invoiced_quantity"`
+	OrderPrice       float64   `json:"order_price"`
+	InvoicePrice     float64   `json:"invoice_price"`
+	Discrepancies    []string  `json:"discrepancies,omitempty"`
+}
+
+// PurchaseMatchReport is the three-way match of a purchase order
+type PurchaseMatchReport struct {
+	PurchaseOrderID uuid.UUID           `json:"purchase_order_id"`
+	OrderTotal      float64             `json:"order_total"`
+	ReceivedValue   float64             `json:"received_value"`
+	InvoicedTotal   float64             `json:"invoiced_total"`
+	Matched         bool                `json:"matched"`
+	Lines           []PurchaseMatchLine `json:"lines"`
+}
@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// ReorderRule holds a product's stock limits in one shop. Stock is
+// replenished up to MaxQuantity once it falls to the reorder point. Products
+// without a rule fall back to their category's default in the shop.
+type ReorderRule struct {
+	Base
+	ProductID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reorder_rule_product_shop" json:"product_id"`
+	ShopID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reorder_rule_product_shop" json:"shop_id"`
+	MinQuantity  int       `gorm:"not null;default:0" json:"min_quantity"`
+	MaxQuantity  int       `gorm:"not null;default:0" json:"max_quantity"`
+	ReorderPoint int       `gorm:"not null;default:0" json:"reorder_point"`
+
+	// Relations
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Shop    *Shop    `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+}
+
+// CategoryReorderDefault holds the stock limits for products of a master
+// category in one shop that have no reorder rule of their own there
+type CategoryReorderDefault struct {
+	Base
+	ShopID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_category_reorder_default_shop_category" json:"shop_id"`
+	MasterCategory string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_category_reorder_default_shop_category" json:"master_category"`
+	MinQuantity    int       `gorm:"not null;default:0" json:"min_quantity"`
+	MaxQuantity    int       `gorm:"not null;default:0" json:"max_quantity"`
+	ReorderPoint   int       `gorm:"not null;default:0" json:"reorder_point"`
+
+	// Relations
+	Shop *Shop `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+}
+
+// ReorderRuleImportError is a row of a reorder rule file that was not applied
+type ReorderRuleImportError struct {
+	Row         int    `json:"row"`
+	ProductCode string `json:"product_code"`
+	Error       string `json:"error"`
+}
+
+// ReorderRuleImportResult reports a bulk reorder rule import. Valid rows are
+// saved even when others are rejected.
+type ReorderRuleImportResult struct {
+	ShopID   uuid.UUID                `json:"shop_id"`
+	FileName string                   `json:"file_name"`
+	Applied  int                      `json:"applied"`
+	Rejected int                      `json:"rejected"`
+	Errors   []ReorderRuleImportError `json:"errors"`
+}
+
+// ReplenishmentParams controls a replenishment run. Sales velocity is the
+// average daily quantity sold over the last VelocityDays; suggestions cover
+// the supplier lead time plus CoverDays of sales. A nil LeadTimeDays takes
+// the default; zero means same-day supply.
+type ReplenishmentParams struct {
+	VelocityDays int  `json:"velocity_days"`
+	LeadTimeDays *int `json:"lead_time_days"`
+	CoverDays    int  `json:"cover_days"`
+}
+
+type ReorderSuggestion struct {
+	ProductID         uuid.UUID  `json:"product_id"`
+	ProductCode       string     `json:"product_code"`
+	ProductName       string     `json:"product_name"`
+	OnHand            int        `json:"on_hand"`
+	OnOrder           int        `json:"on_order"` // Still to arrive on open purchase orders
+	MinQuantity       int        `json:"min_quantity"`
+	MaxQuantity       int        `json:"max_quantity"`
+	ReorderPoint      int        `json:"reorder_point"` // Effective point after sales velocity
+	DailyVelocity     float64    `json:"daily_velocity"`
+	LeadTimeDays      int        `json:"lead_time_days"`
+	SuggestedQuantity int        `json:"suggested_quantity"`
+	SupplierID        *uuid.UUID `json:"supplier_id,omitempty"`
+	SupplierCode      string     `json:"supplier_code,omitempty"`
+	UnitPrice         float64    `json:"unit_price"`
+}
+
+// ReorderSuggestionGroup is the suggestions for one supplier. Products with
+// no preferred supplier that were never bought before are grouped without a
+// supplier.
+type ReorderSuggestionGroup struct {
+	SupplierID     *uuid.UUID          `json:"supplier_id,omitempty"`
+	SupplierName   string              `json:"supplier_name"`
+	EstimatedTotal float64             `json:"estimated_total"`
+	Lines          []ReorderSuggestion `json:"lines"`
+}
+
+type ReplenishmentPlan struct {
+	ShopID uuid.UUID                `json:"shop_id"`
+	Params ReplenishmentParams      `json:"params"`
+	Groups []ReorderSuggestionGroup `json:"groups"`
+}
@@ -48,6 +48,51 @@ type CreateProductRequest struct {
 	SalesPrice     float64 `json:"sales_price" binding:"required,min=0,gtefield=PurchasePrice"`
 	SalesType      string  `json:"sales_type" binding:"required,oneof=retail wholesale"`
 	ShopID         string  `json:"shop_id" binding:"required,uuid"`
+	TrackLots      bool    `json:"track_lots"`
+	TrackSerials   bool    `json:"track_serials"`
+	WarrantyMonths int     `json:"warranty_months" binding:"min=0,max=120"`
+}
+
+// CreateProductStyleRequest represents the request body for a style whose
+// colours and sizes are sold as variants. Given colours and sizes, every
+// combination of them is created as a variant straight away.
+type CreateProductStyleRequest struct {
+	Code           string   `json:"code" binding:"required,min=3,max=40"`
+	Name           string   `json:"name" binding:"required,min=3,max=100"`
+	MasterCategory string   `json:"master_category" binding:"required,min=2,max=50"`
+	SubCategory    string   `json:"sub_category" binding:"required,min=2,max=50"`
+	PurchasePrice  float64  `json:"purchase_price" binding:"required,min=0"`
+	SalesPrice     float64  `json:"sales_price" binding:"required,min=0,gtefield=PurchasePrice"`
+	SalesType      string   `json:"sales_type" binding:"required,oneof=retail wholesale"`
+	ShopID         string   `json:"shop_id" binding:"required,uuid"`
+	Remarks        string   `json:"remarks" binding:"max=500"`
+	Colors         []string `json:"colors" binding:"required_with=Sizes,dive,required,max=30"`
+	Sizes          []string `json:"sizes" binding:"required_with=Colors,dive,required,max=20"`
+}
+
+// UpdateProductStyleRequest represents the request body for changing a
+// style's shared attributes, which are copied to all of its variants
+type UpdateProductStyleRequest struct {
+	Name           string  `json:"name" binding:"required,min=3,max=100"`
+	MasterCategory string  `json:"master_category" binding:"required,min=2,max=50"`
+	SubCategory    string  `json:"sub_category" binding:"required,min=2,max=50"`
+	PurchasePrice  float64 `json:"purchase_price" binding:"required,min=0"`
+	SalesPrice     float64 `json:"sales_price" binding:"required,min=0,gtefield=PurchasePrice"`
+	SalesType      string  `json:"sales_type" binding:"required,oneof=retail wholesale"`
+	Remarks        string  `json:"remarks" binding:"max=500"`
+}
+
+// GenerateVariantsRequest represents a size by colour grid of variants to
+// add to a style. Variants the style already has are left as they are.
+type GenerateVariantsRequest struct {
+	Colors []string `json:"colors" binding:"required,min=1,dive,required,max=30"`
+	Sizes  []string `json:"sizes" binding:"required,min=1,dive,required,max=20"`
+}
+
+// AttachVariantsRequest represents existing products to make variants of a
+// style, keeping their own colour and size
+type AttachVariantsRequest struct {
+	ProductIDs []string `json:"product_ids" binding:"required,min=1,dive,uuid"`
 }
 
 // CreateSaleRequest represents the create sale request body
@@ -64,10 +109,13 @@ type CreateSaleRequest struct {
 
 // SaleItemRequest represents a sale item in the create sale request
 type SaleItemRequest struct {
-	ProductID string  `json:"product_id" binding:"required,uuid"`
-	Quantity  int     `json:"quantity" binding:"required,min=1"`
-	UnitPrice float64 `json:"unit_price" binding:"required,min=0"`
-	Discount  float64 `json:"discount" binding:"min=0"`
+	ProductID     string   `json:"product_id" binding:"required,uuid"`
+	Quantity      int      `json:"quantity" binding:"required,min=1"`
+	UnitPrice     float64  `json:"unit_price" binding:"required,min=0"`
+	Discount      float64  `json:"discount" binding:"min=0"`
+	ReservationID string   `json:"reservation_id" binding:"omitempty,uuid"`        // Sell the stock held by this reservation
+	LotID         string   `json:"lot_id" binding:"omitempty,uuid"`                // Sell from this lot rather than the oldest
+	SerialNumbers []string `json:"serial_numbers" binding:"dive,required,max=100"` // One per unit, for products that track serial numbers
 }
 
 // SalePaymentRequest represents a payment in the create sale request
@@ -92,14 +140,105 @@ type CreateSupplierRequest struct {
 	Remarks string `json:"remarks" binding:"max=500"`
 }
 
+// CreatePurchaseOrderRequest represents the request body for creating a purchase order
+type CreatePurchaseOrderRequest struct {
+	SupplierID       string                     `json:"supplier_id" binding:"required,uuid"`
+	ShopID           string                     `json:"shop_id" binding:"required,uuid"` // A shop or a warehouse
+	ExpectedDateTime *time.Time                 `json:"expected_datetime"`
+	Items            []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	Remarks          string                     `json:"remarks" binding:"max=500"`
+}
+
+// PurchaseOrderItemRequest represents an ordered line in the create purchase order request
+type PurchaseOrderItemRequest struct {
+	ProductID string  `json:"product_id" binding:"required,uuid"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice float64 `json:"unit_price" binding:"min=0"`
+}
+
+// CreateGoodsReceiptRequest represents the request body for receiving goods against a purchase order
+type CreateGoodsReceiptRequest struct {
+	Items   []GoodsReceiptItemRequest `json:"items" binding:"required,min=1,dive"`
+	Remarks string                    `json:"remarks" binding:"max=500"`
+}
+
+// GoodsReceiptItemRequest represents a received line in the goods receipt request
+type GoodsReceiptItemRequest struct {
+	PurchaseOrderLineID string   `json:"purchase_order_line_id" binding:"required,uuid"`
+	Quantity            int      `json:"quantity" binding:"required,min=1"`
+	LotNumber           string   `json:"lot_number" binding:"max=50"`                    // For products that track lots; generated when empty
+	SerialNumbers       []string `json:"serial_numbers" binding:"dive,required,max=100"` // One per unit, for products that track serial numbers
+}
+
+// CreateSupplierReturnRequest represents the request body for returning purchased goods to a supplier
+type CreateSupplierReturnRequest struct {
+	PurchaseInvoiceID string                      `json:"purchase_invoice_id" binding:"required,uuid"`
+	Reason            string                      `json:"reason" binding:"required,max=255"`
+	Items             []SupplierReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+	Remarks           string                      `json:"remarks" binding:"max=500"`
+}
+
+// SupplierReturnItemRequest represents a returned purchase line in the supplier return request
+type SupplierReturnItemRequest struct {
+	PurchaseDetailID string   `json:"purchase_detail_id" binding:"required,uuid"`
+	Quantity         int      `json:"quantity" binding:"required,min=1"`
+	SerialNumbers    []string `json:"serial_numbers" binding:"dive,required,max=100"` // One per unit, for products that track serial numbers
+}
+
+// CreateSupplierPaymentRequest represents the request body for recording a payment to a supplier
+type CreateSupplierPaymentRequest struct {
+	SupplierID      string                     `json:"supplier_id" binding:"required,uuid"`
+	Amount          float64                    `json:"amount" binding:"required,gt=0"`          // In the payment currency
+	CurrencyCode    string                     `json:"currency_code" binding:"omitempty,len=3"` // Taka by default
+	ExchangeRate    float64                    `json:"exchange_rate" binding:"omitempty,gt=0"`  // Taken from the rate table when left out
+	PaymentDateTime *time.Time                 `json:"payment_datetime"`
+	Remarks         string                     `json:"remarks" binding:"max=500"`
+	Allocations     []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+}
+
+// AllocatePaymentRequest represents the request body for allocating an existing supplier payment
+type AllocatePaymentRequest struct {
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"required,min=1,dive"`
+}
+
+// PaymentAllocationRequest represents an allocation of a payment to a purchase invoice.
+// The amount is in the payment's currency.
+type PaymentAllocationRequest struct {
+	PurchaseInvoiceID string  `json:"purchase_invoice_id" binding:"required,uuid"`
+	Amount            float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// CreateCurrencyRequest represents the request body for setting up a currency
+type CreateCurrencyRequest struct {
+	Code   string `json:"code" binding:"required,len=3,alpha"`
+	Name   string `json:"name" binding:"required,max=100"`
+	Symbol string `json:"symbol" binding:"max=10"`
+}
+
+// SaveExchangeRateRequest represents a currency's rate in taka for a day
+type SaveExchangeRateRequest struct {
+	CurrencyCode string    `json:"currency_code" binding:"required,len=3"`
+	RateDate     time.Time `json:"rate_date" binding:"required"`
+	Rate         float64   `json:"rate" binding:"required,gt=0"`
+}
+
+// CreateLandedCostRequest represents the request body for adding a landed cost to a purchase
+type CreateLandedCostRequest struct {
+	CostType         string  `json:"cost_type" binding:"required,oneof=FREIGHT CUSTOMS_DUTY CLEARING OTHER"`
+	Amount           float64 `json:"amount" binding:"required,gt=0"`
+	AllocationMethod string  `json:"allocation_method" binding:"required,oneof=VALUE QUANTITY WEIGHT"`
+	Remarks          string  `json:"remarks" binding:"max=500"`
+}
+
 // CreateCompanyRequest represents the request body for creating a new company
 type CreateCompanyRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Address string `json:"address" binding:"required"`
-	Phone   string `json:"phone" binding:"required"`
-	Email   string `json:"email" binding:"required,email"`
-	Slogan  string `json:"slogan"`
-	Remarks string `json:"remarks"`
+	Name          string `json:"name" binding:"required"`
+	Address       string `json:"address" binding:"required"`
+	Phone         string `json:"phone" binding:"required"`
+	Email         string `json:"email" binding:"required,email"`
+	Slogan        string `json:"slogan"`
+	Remarks       string `json:"remarks"`
+	CostingMethod string `json:"costing_method" binding:"omitempty,oneof=AVERAGE FIFO"`
 }
 
 // CreateShopRequest represents the request body for creating a new shop
@@ -114,14 +253,31 @@ type CreateShopRequest struct {
 	Remarks      string `json:"remarks"`
 }
 
+// CreateWarehouseRequest represents a warehouse. The company's first
+// warehouse becomes its central warehouse.
+type CreateWarehouseRequest struct {
+	CompanyID    string `json:"company_id" binding:"required,uuid"`
+	Name         string `json:"name" binding:"required"`
+	Address      string `json:"address" binding:"required"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email" binding:"omitempty,email"`
+	ManagerName  string `json:"manager_name"`
+	ManagerPhone string `json:"manager_phone"`
+	Remarks      string `json:"remarks"`
+	Central      bool   `json:"central"` // Make it the company's central warehouse
+}
+
 // Stock Transfer Requests
 type CreateStockTransferRequest struct {
-	FromShopID       string    `json:"from_shop_id" binding:"required,uuid"`
+	FromShopID       string    `json:"from_shop_id" binding:"omitempty,uuid"` // The company's central warehouse when empty
 	ToShopID         string    `json:"to_shop_id" binding:"required,uuid"`
 	ProductID        string    `json:"product_id" binding:"required,uuid"`
 	Quantity         int       `json:"quantity" binding:"required,min=1"`
 	TransferDateTime time.Time `json:"transfer_datetime" binding:"required"`
 	Remarks          string    `json:"remarks"`
+	ReservationID    string    `json:"reservation_id" binding:"omitempty,uuid"`        // Stock reserved at the source shop for the transfer
+	LotID            string    `json:"lot_id" binding:"omitempty,uuid"`                // Send this lot rather than the oldest
+	SerialNumbers    []string  `json:"serial_numbers" binding:"dive,required,max=100"` // One per unit, for products that track serial numbers
 }
 
 type UpdateStockTransferRequest struct {
@@ -129,6 +285,176 @@ type UpdateStockTransferRequest struct {
 	Remarks string `json:"remarks"`
 }
 
+// UpdatePurchaseRequest represents an edit to a purchase. The lines replace
+// the purchase's lines; existing lines are kept by passing their ID.
+type UpdatePurchaseRequest struct {
+	SupplierID       string                      `json:"supplier_id" binding:"omitempty,uuid"`
+	PurchaseDateTime *time.Time                  `json:"purchase_datetime"`
+	PaymentType      string                      `json:"payment_type" binding:"omitempty,oneof=CASH CREDIT"`
+	Remarks          *string                     `json:"remarks" binding:"omitempty,max=500"`
+	Reason           string                      `json:"reason" binding:"required,max=500"`
+	Lines            []UpdatePurchaseLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// UpdatePurchaseLineRequest represents a line of an edited purchase
+type UpdatePurchaseLineRequest struct {
+	ID            string   `json:"id" binding:"omitempty,uuid"`
+	ProductID     string   `json:"product_id" binding:"required,uuid"`
+	Quantity      int      `json:"quantity" binding:"required,min=1"`
+	PurchasePrice float64  `json:"purchase_price" binding:"min=0"` // Taka invoices
+	CurrencyPrice float64  `json:"currency_price" binding:"min=0"` // Foreign currency invoices
+	Weight        float64  `json:"weight" binding:"min=0"`
+	LotNumber     string   `json:"lot_number" binding:"max=50"`                    // Lot of a new line, for products that track lots
+	SerialNumbers []string `json:"serial_numbers" binding:"dive,required,max=100"` // Units the line gains or, optionally, loses, for products that track serial numbers
+}
+
+// SupplierProductRequest represents a supplier catalog entry
+type SupplierProductRequest struct {
+	SupplierID       string  `json:"supplier_id" binding:"required,uuid"`
+	ProductID        string  `json:"product_id" binding:"required,uuid"`
+	SupplierCode     string  `json:"supplier_code" binding:"max=100"`
+	LastPrice        float64 `json:"last_price" binding:"min=0"`
+	MinOrderQuantity int     `json:"min_order_quantity" binding:"min=0"`
+	PackSize         int     `json:"pack_size" binding:"omitempty,min=1"`
+	LeadTimeDays     int     `json:"lead_time_days" binding:"min=0,max=365"`
+	IsPreferred      bool    `json:"is_preferred"`
+}
+
+// ConfirmPurchaseImportRequest represents the reviewed lines of an imported supplier invoice
+type ConfirmPurchaseImportRequest struct {
+	SupplierID   string                      `json:"supplier_id" binding:"required,uuid"`
+	ShopID       string                      `json:"shop_id" binding:"omitempty,uuid"`
+	PaymentType  string                      `json:"payment_type" binding:"required,oneof=CASH CREDIT"`
+	CurrencyCode string                      `json:"currency_code" binding:"omitempty,len=3"` // Unit prices are in this currency, taka by default
+	ExchangeRate float64                     `json:"exchange_rate" binding:"omitempty,gt=0"`
+	Remarks      string                      `json:"remarks" binding:"max=500"`
+	Lines        []PurchaseImportLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// PurchaseImportLineRequest represents a confirmed line. Giving a product for
+// a supplier code that did not match remembers the pairing for next time.
+type PurchaseImportLineRequest struct {
+	SupplierCode string  `json:"supplier_code"`
+	ProductID    string  `json:"product_id" binding:"required,uuid"`
+	Quantity     int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice    float64 `json:"unit_price" binding:"min=0"`
+}
+
+// Replenishment Requests
+type UpsertReorderRuleRequest struct {
+	ShopID       string `json:"shop_id" binding:"required,uuid"`
+	ProductID    string `json:"product_id" binding:"required,uuid"`
+	MinQuantity  int    `json:"min_quantity" binding:"min=0"`
+	MaxQuantity  int    `json:"max_quantity" binding:"min=0,gtefield=MinQuantity"`
+	ReorderPoint int    `json:"reorder_point" binding:"min=0"`
+}
+
+type UpsertCategoryReorderDefaultRequest struct {
+	ShopID         string `json:"shop_id" binding:"required,uuid"`
+	MasterCategory string `json:"master_category" binding:"required"`
+	MinQuantity    int    `json:"min_quantity" binding:"min=0"`
+	MaxQuantity    int    `json:"max_quantity" binding:"min=0,gtefield=MinQuantity"`
+	ReorderPoint   int    `json:"reorder_point" binding:"min=0"`
+}
+
+type ReplenishmentRequest struct {
+	ShopID       string   `json:"shop_id" form:"shop_id" binding:"omitempty,uuid"`
+	VelocityDays int      `json:"velocity_days" form:"velocity_days" binding:"omitempty,min=1,max=365"`
+	LeadTimeDays *int     `json:"lead_time_days" form:"lead_time_days" binding:"omitempty,min=0,max=365"`
+	CoverDays    int      `json:"cover_days" form:"cover_days" binding:"omitempty,min=1,max=365"`
+	ProductIDs   []string `json:"product_ids" binding:"omitempty,dive,uuid"` // Limits draft orders to these products
+}
+
+// CreateStockAdjustmentRequest represents the request body for adjusting a shop's stock
+type CreateStockAdjustmentRequest struct {
+	ShopID string                       `json:"shop_id" binding:"omitempty,uuid"` // The user's shop by default
+	Reason string                       `json:"reason" binding:"required,oneof=DAMAGE THEFT LOSS COUNT_CORRECTION GIFT SAMPLE OTHER"`
+	Notes  string                       `json:"notes" binding:"max=500"`
+	Lines  []StockAdjustmentLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// StockAdjustmentLineRequest represents a product whose stock is adjusted.
+// A negative quantity takes stock out.
+type StockAdjustmentLineRequest struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	Quantity  int    `json:"quantity" binding:"required,ne=0"`
+}
+
+// RejectStockAdjustmentRequest represents the request body for rejecting a stock adjustment
+type RejectStockAdjustmentRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// StartStockCountRequest represents the request body for starting a stock count
+type StartStockCountRequest struct {
+	ShopID      string `json:"shop_id" binding:"omitempty,uuid"` // The user's shop by default
+	Scope       string `json:"scope" binding:"required,oneof=FULL CATEGORY"`
+	Category    string `json:"category" binding:"required_if=Scope CATEGORY"`
+	SubCategory string `json:"sub_category"`
+	Notes       string `json:"notes" binding:"max=500"`
+}
+
+// StockCountEntriesRequest represents tallies from one counter or scanner.
+// Each entry names the product by ID or by code.
+type StockCountEntriesRequest struct {
+	Device  string                   `json:"device" binding:"max=100"`
+	Entries []StockCountEntryRequest `json:"entries" binding:"required,min=1,dive"`
+}
+
+type StockCountEntryRequest struct {
+	ProductID string `json:"product_id" binding:"required_without=Code,omitempty,uuid"`
+	Code      string `json:"code" binding:"required_without=ProductID"`
+	Quantity  int    `json:"quantity" binding:"required,ne=0"` // Negative to correct an earlier tally
+}
+
+// ApproveStockCountRequest represents the request body for approving a stock count
+type ApproveStockCountRequest struct {
+	ZeroUncounted bool `json:"zero_uncounted"` // Treat products nobody counted as missing
+}
+
+// CreateStockReservationRequest represents the request body for holding
+// stock for a document that isn't posted yet
+type CreateStockReservationRequest struct {
+	ShopID     string     `json:"shop_id" binding:"omitempty,uuid"` // The user's shop by default
+	ProductID  string     `json:"product_id" binding:"required,uuid"`
+	Quantity   int        `json:"quantity" binding:"required,min=1"`
+	SourceType string     `json:"source_type" binding:"required,oneof=DRAFT_SALE LAYAWAY ONLINE_ORDER TRANSFER"`
+	SourceID   string     `json:"source_id" binding:"omitempty,uuid"`
+	Reference  string     `json:"reference" binding:"max=100"`
+	CustomerID string     `json:"customer_id" binding:"omitempty,uuid"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Notes      string     `json:"notes" binding:"max=500"`
+}
+
+// SetSerialTrackingRequest turns serial number tracking on or off for a
+// product and sets the warranty its units are sold with
+type SetSerialTrackingRequest struct {
+	Enabled        *bool `json:"enabled" binding:"required"`
+	WarrantyMonths *int  `json:"warranty_months" binding:"omitempty,min=0,max=120"` // Left as it is when omitted
+}
+
+// RegisterSerialNumbersRequest records the serial numbers of units a shop
+// held before their product tracked serial numbers
+type RegisterSerialNumbersRequest struct {
+	ProductID     string   `json:"product_id" binding:"required,uuid"`
+	ShopID        string   `json:"shop_id" binding:"omitempty,uuid"` // The user's shop by default
+	SerialNumbers []string `json:"serial_numbers" binding:"required,min=1,dive,required,max=100"`
+}
+
+// SetLotTrackingRequest turns lot tracking on or off for a product
+type SetLotTrackingRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SaveNotificationPreferenceRequest turns a channel on or off for one type
+// of alert
+type SaveNotificationPreferenceRequest struct {
+	Type    string `json:"type" binding:"required,oneof=LOW_STOCK AWAITING_APPROVAL LARGE_DISCOUNT IMPORT_FAILED"`
+	Channel string `json:"channel" binding:"required,oneof=IN_APP EMAIL SMS WEBHOOK"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Target  string `json:"target" binding:"omitempty,max=255"` // Email, phone number or webhook URL instead of the user's own
+}
+
 type StockTransferFilter struct {
 	FromShopID string    `form:"from_shop_id"`
 	ToShopID   string    `form:"to_shop_id"`
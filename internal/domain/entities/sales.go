@@ -8,14 +8,15 @@ import (
 
 type SalesInvoice struct {
 	Base
-	ShopID       uuid.UUID  `gorm:"type:uuid;not null" json:"shop_id"`
-	CustomerID   *uuid.UUID `gorm:"type:uuid" json:"customer_id,omitempty"`
-	SalesByID    uuid.UUID  `gorm:"type:uuid;not null" json:"sales_by_id"`
-	SaleDateTime time.Time  `gorm:"not null" json:"sale_datetime"`
-	Total        float64    `gorm:"type:decimal(10,2);not null" json:"total"`
-	Discount     float64    `gorm:"type:decimal(10,2);not null;default:0" json:"discount"`
-	DiscountByID *uuid.UUID `gorm:"type:uuid" json:"discount_by_id,omitempty"`
-	Remarks      string     `gorm:"type:text" json:"remarks"`
+	ShopID          uuid.UUID  `gorm:"type:uuid;not null" json:"shop_id"`
+	CustomerID      *uuid.UUID `gorm:"type:uuid" json:"customer_id,omitempty"`
+	SalesByID       uuid.UUID  `gorm:"type:uuid;not null" json:"sales_by_id"`
+	SaleDateTime    time.Time  `gorm:"not null" json:"sale_datetime"`
+	Total           float64    `gorm:"type:decimal(10,2);not null" json:"total"`
+	Discount        float64    `gorm:"type:decimal(10,2);not null;default:0" json:"discount"`
+	DiscountByID    *uuid.UUID `gorm:"type:uuid" json:"discount_by_id,omitempty"`
+	Remarks         string     `gorm:"type:text" json:"remarks"`
+	CostOfGoodsSold float64    `gorm:"type:decimal(10,2);not null;default:0" json:"cost_of_goods_sold"`
 
 	// Relations
 	Shop         *Shop         `gorm:"foreignKey:ShopID" json:"shop,omitempty"`
@@ -27,11 +28,16 @@ type SalesInvoice struct {
 
 type SalesDetail struct {
 	Base
-	InvoiceID  uuid.UUID `gorm:"type:uuid;not null" json:"invoice_id"`
-	ProductID  uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
-	Quantity   int       `gorm:"not null" json:"quantity"`
-	SalesPrice float64   `gorm:"type:decimal(10,2);not null" json:"sales_price"`
-	Subtotal   float64   `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	InvoiceID       uuid.UUID  `gorm:"type:uuid;not null" json:"invoice_id"`
+	ProductID       uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity        int        `gorm:"not null" json:"quantity"`
+	SalesPrice      float64    `gorm:"type:decimal(10,2);not null" json:"sales_price"`
+	Subtotal        float64    `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	UnitCost        float64    `gorm:"type:decimal(10,4);not null;default:0" json:"unit_cost"`          // Set when the sale is posted
+	CostOfGoodsSold float64    `gorm:"type:decimal(10,2);not null;default:0" json:"cost_of_goods_sold"` // Set when the sale is posted
+	ReservationID   *uuid.UUID `gorm:"type:uuid" json:"reservation_id,omitempty"`                       // The draft, layaway or order reservation the line was sold from
+	LotID           *uuid.UUID `gorm:"type:uuid" json:"lot_id,omitempty"`                               // The lot chosen for the line; the oldest stock is sold when empty
+	SerialNumbers   []string   `gorm:"-" json:"serial_numbers,omitempty"`                               // The units sold, for products that track serial numbers
 
 	// Relations
 	SalesInvoice *SalesInvoice `gorm:"foreignKey:InvoiceID" json:"sales_invoice,omitempty"`
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SerialStatus string
+
+const (
+	SerialStatusInStock   SerialStatus = "IN_STOCK"
+	SerialStatusInTransit SerialStatus = "IN_TRANSIT"
+	SerialStatusSold      SerialStatus = "SOLD"
+	SerialStatusReturned  SerialStatus = "RETURNED_TO_SUPPLIER"
+	SerialStatusRemoved   SerialStatus = "REMOVED" // Written off by an adjustment or count
+)
+
+// SerialNumber is one unit of a product that tracks serial numbers, such as
+// a watch or a phone charger, followed from receipt to sale for warranty
+type SerialNumber struct {
+	Base
+	ProductID         uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_serial_product_number" json:"product_id"`
+	SerialNumber      string       `gorm:"type:varchar(100);not null;uniqueIndex:idx_serial_product_number" json:"serial_number"` // Trimmed and upper case
+	Status            SerialStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	ShopID            *uuid.UUID   `gorm:"type:uuid;index" json:"shop_id,omitempty"` // The shop holding the unit, or that last held it
+	ReceivedAt        time.Time    `gorm:"not null" json:"received_at"`
+	LastDocumentID    *uuid.UUID   `gorm:"type:uuid;index" json:"last_document_id,omitempty"` // The document that last moved the unit
+	SalesInvoiceID    *uuid.UUID   `gorm:"type:uuid" json:"sales_invoice_id,omitempty"`
+	SoldAt            *time.Time   `json:"sold_at,omitempty"`
+	WarrantyExpiresAt *time.Time   `json:"warranty_expires_at,omitempty"` // Sale date plus the product's warranty period
+
+	// Relations
+	Product      *Product            `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Shop         *Shop               `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	SalesInvoice *SalesInvoice       `gorm:"foreignKey:SalesInvoiceID" json:"sales_invoice,omitempty"`
+	Events       []SerialNumberEvent `gorm:"foreignKey:SerialNumberID" json:"events,omitempty"`
+}
+
+// UnderWarranty reports whether a sold unit is still covered at the given time
+func (s *SerialNumber) UnderWarranty(at time.Time) bool {
+	return s.Status == SerialStatusSold && s.WarrantyExpiresAt != nil && at.Before(*s.WarrantyExpiresAt)
+}
+
+// SerialNumberEvent is one movement of a serialised unit. Like the stock
+// movement ledger it is only ever inserted.
+type SerialNumberEvent struct {
+	ID             uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SerialNumberID uuid.UUID         `gorm:"type:uuid;not null;index" json:"serial_number_id"`
+	ShopID         uuid.UUID         `gorm:"type:uuid;not null" json:"shop_id"`
+	MovedAt        time.Time         `gorm:"not null" json:"moved_at"`
+	MovementType   StockMovementType `gorm:"type:varchar(20);not null" json:"movement_type"`
+	DocumentID     *uuid.UUID        `gorm:"type:uuid;index" json:"document_id,omitempty"`
+	UserID         *uuid.UUID        `gorm:"type:uuid" json:"user_id,omitempty"`
+	Status         SerialStatus      `gorm:"type:varchar(20);not null" json:"status"` // The unit's status after the event
+	CreatedAt      time.Time         `gorm:"not null" json:"created_at"`
+
+	// Relations
+	Shop *Shop `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// SerialLookup is a serial number with its full history and warranty cover
+type SerialLookup struct {
+	SerialNumber
+	UnderWarranty bool `json:"under_warranty"`
+}
@@ -7,9 +7,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// LocationType tells a shop that sells to customers from a warehouse that
+// only holds stock for the company's shops
+type LocationType string
+
+const (
+	LocationTypeShop      LocationType = "SHOP"
+	LocationTypeWarehouse LocationType = "WAREHOUSE"
+)
+
+// Shop is a location that holds stock: a shop, or a warehouse when its type
+// says so. Inventory, lots, serial numbers and the movement ledger are all
+// kept per location.
 type Shop struct {
 	ShopID       uuid.UUID      `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"shop_id"`
 	CompanyID    uuid.UUID      `gorm:"type:uuid;not null" json:"company_id"`
+	Type         LocationType   `gorm:"type:varchar(20);not null;default:'SHOP'" json:"type"`
 	Name         string         `json:"name" binding:"required"`
 	Address      string         `json:"address" binding:"required"`
 	Phone        string         `json:"phone" binding:"required"`
@@ -22,3 +35,15 @@ type Shop struct {
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 	Company      Company        `gorm:"foreignKey:CompanyID;references:ID" json:"company,omitempty"`
 }
+
+// IsWarehouse tells whether the location is a warehouse rather than a shop
+func (s *Shop) IsWarehouse() bool {
+	return s.Type == LocationTypeWarehouse
+}
+
+// WarehouseDetail is a warehouse with the stock it holds
+type WarehouseDetail struct {
+	Shop
+	Central bool            `json:"central"` // The company's central warehouse, which transfers without a source come from
+	Stock   *ShopStockTotal `json:"stock,omitempty"`
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdjustmentReason string
+
+const (
+	AdjustmentReasonDamage          AdjustmentReason = "DAMAGE"
+	AdjustmentReasonTheft           AdjustmentReason = "THEFT"
+	AdjustmentReasonLoss            AdjustmentReason = "LOSS"
+	AdjustmentReasonCountCorrection AdjustmentReason = "COUNT_CORRECTION"
+	AdjustmentReasonGift            AdjustmentReason = "GIFT"
+	AdjustmentReasonSample          AdjustmentReason = "SAMPLE"
+	AdjustmentReasonOther           AdjustmentReason = "OTHER"
+)
+
+type StockAdjustmentStatus string
+
+const (
+	StockAdjustmentStatusPending  StockAdjustmentStatus = "PENDING_APPROVAL"
+	StockAdjustmentStatusApproved StockAdjustmentStatus = "APPROVED"
+	StockAdjustmentStatusRejected StockAdjustmentStatus = "REJECTED"
+)
+
+// StockAdjustment corrects a shop's stock outside of purchases, sales and
+// transfers. Stock only changes once the adjustment is approved.
+type StockAdjustment struct {
+	Base
+	ShopID             uuid.UUID             `gorm:"type:uuid;not null;index" json:"shop_id"`
+	AdjustmentDateTime time.Time             `gorm:"not null" json:"adjustment_datetime"`
+	Reason             AdjustmentReason      `gorm:"type:varchar(20);not null" json:"reason"`
+	Notes              string                `gorm:"type:text" json:"notes"`
+	Status             StockAdjustmentStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Value              float64               `gorm:"type:decimal(12,2);not null;default:0" json:"value"` // Net change at cost, negative for a loss
+	CreatedByID        uuid.UUID             `gorm:"type:uuid;not null" json:"created_by_id"`
+	ApprovedByID       *uuid.UUID            `gorm:"type:uuid" json:"approved_by_id,omitempty"` // Also set on rejection; empty when approved automatically under the approval value
+	ApprovedAt         *time.Time            `json:"approved_at,omitempty"`
+	RejectionReason    string                `gorm:"type:text" json:"rejection_reason,omitempty"`
+
+	// Relations
+	Shop       *Shop                 `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	CreatedBy  *User                 `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	ApprovedBy *User                 `gorm:"foreignKey:ApprovedByID" json:"approved_by,omitempty"`
+	Lines      []StockAdjustmentLine `gorm:"foreignKey:StockAdjustmentID" json:"lines,omitempty"`
+}
+
+type StockAdjustmentLine struct {
+	Base
+	StockAdjustmentID uuid.UUID `gorm:"type:uuid;not null" json:"stock_adjustment_id"`
+	ProductID         uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity          int       `gorm:"not null" json:"quantity"`                               // Positive adds stock, negative removes it
+	UnitCost          float64   `gorm:"type:decimal(10,4);not null;default:0" json:"unit_cost"` // Cost the stock moved at
+	Value             float64   `gorm:"type:decimal(12,2);not null;default:0" json:"value"`
+
+	// Relations
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// ShrinkageSummary totals approved adjustments for one shop and reason
+type ShrinkageSummary struct {
+	ShopID        uuid.UUID        `json:"shop_id"`
+	ShopName      string           `json:"shop_name"`
+	Reason        AdjustmentReason `json:"reason"`
+	Adjustments   int              `json:"adjustments"`
+	QuantityLost  int              `json:"quantity_lost"`
+	QuantityFound int              `json:"quantity_found"`
+	ValueLost     float64          `json:"value_lost"`
+	ValueFound    float64          `json:"value_found"`
+	NetValue      float64          `json:"net_value"`
+}
+
+// ShrinkageReport is stock lost and found through adjustments over a period
+type ShrinkageReport struct {
+	Rows          []ShrinkageSummary `json:"rows"`
+	TotalLost     float64            `json:"total_lost"`
+	TotalFound    float64            `json:"total_found"`
+	TotalNetValue float64            `json:"total_net_value"`
+}
@@ -0,0 +1,107 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StockCountScope string
+
+const (
+	StockCountScopeFull     StockCountScope = "FULL"
+	StockCountScopeCategory StockCountScope = "CATEGORY"
+)
+
+type StockCountStatus string
+
+const (
+	StockCountStatusOpen      StockCountStatus = "OPEN"
+	StockCountStatusApproved  StockCountStatus = "APPROVED"
+	StockCountStatusCancelled StockCountStatus = "CANCELLED"
+)
+
+// StockCount is a stock-take of a shop, or of one category in it. Expected
+// quantities are snapshotted when the count starts and differences are
+// posted as a count correction adjustment on approval.
+type StockCount struct {
+	Base
+	ShopID            uuid.UUID        `gorm:"type:uuid;not null;index" json:"shop_id"`
+	Scope             StockCountScope  `gorm:"type:varchar(20);not null" json:"scope"`
+	Category          string           `json:"category,omitempty"`     // Master category counted when the scope is CATEGORY
+	SubCategory       string           `json:"sub_category,omitempty"` // Optionally narrows a category count
+	Status            StockCountStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Notes             string           `gorm:"type:text" json:"notes"`
+	StartedAt         time.Time        `gorm:"not null" json:"started_at"`
+	StartedByID       uuid.UUID        `gorm:"type:uuid;not null" json:"started_by_id"`
+	ApprovedByID      *uuid.UUID       `gorm:"type:uuid" json:"approved_by_id,omitempty"`
+	ApprovedAt        *time.Time       `json:"approved_at,omitempty"`
+	StockAdjustmentID *uuid.UUID       `gorm:"type:uuid" json:"stock_adjustment_id,omitempty"` // The adjustment that posted the differences
+
+	// Relations
+	Shop            *Shop            `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	StartedBy       *User            `gorm:"foreignKey:StartedByID" json:"started_by,omitempty"`
+	ApprovedBy      *User            `gorm:"foreignKey:ApprovedByID" json:"approved_by,omitempty"`
+	StockAdjustment *StockAdjustment `gorm:"foreignKey:StockAdjustmentID" json:"stock_adjustment,omitempty"`
+	Lines           []StockCountLine `gorm:"foreignKey:StockCountID" json:"lines,omitempty"`
+}
+
+// StockCountLine is one product in a count. CountedQuantity is the sum of
+// every counter's entries and stays nil until the product is counted.
+type StockCountLine struct {
+	Base
+	StockCountID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_stock_count_line_product" json:"stock_count_id"`
+	ProductID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_stock_count_line_product" json:"product_id"`
+	ExpectedQuantity int        `gorm:"not null" json:"expected_quantity"` // On hand when the count started
+	CountedQuantity  *int       `json:"counted_quantity"`
+	LastCountedAt    *time.Time `json:"last_counted_at,omitempty"`
+	UnitCost         float64    `gorm:"type:decimal(10,4);not null;default:0" json:"unit_cost"`
+	RetailPrice      float64    `gorm:"type:decimal(10,2);not null;default:0" json:"retail_price"`
+	MovedDuringCount int        `gorm:"not null;default:0" json:"moved_during_count"` // Sales and other movements before the product was counted, set on approval
+
+	// Relations
+	Product *Product          `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Entries []StockCountEntry `gorm:"foreignKey:StockCountLineID" json:"entries,omitempty"`
+}
+
+// StockCountEntry is one counter's or scanner's tally of a product
+type StockCountEntry struct {
+	Base
+	StockCountLineID uuid.UUID `gorm:"type:uuid;not null;index" json:"stock_count_line_id"`
+	CountedByID      uuid.UUID `gorm:"type:uuid;not null" json:"counted_by_id"`
+	Device           string    `gorm:"type:varchar(100)" json:"device,omitempty"`
+	Quantity         int       `gorm:"not null" json:"quantity"`
+	CountedAt        time.Time `gorm:"not null" json:"counted_at"`
+
+	// Relations
+	CountedBy *User `gorm:"foreignKey:CountedByID" json:"counted_by,omitempty"`
+}
+
+// StockCountVarianceLine compares a counted product with what the shop
+// should have held when it was counted
+type StockCountVarianceLine struct {
+	ProductID        uuid.UUID `json:"product_id"`
+	Code             string    `json:"code"`
+	Name             string    `json:"name"`
+	ExpectedQuantity int       `json:"expected_quantity"`
+	MovedDuringCount int       `json:"moved_during_count"`
+	CountedQuantity  *int      `json:"counted_quantity"`
+	Variance         int       `json:"variance"`
+	VarianceAtCost   float64   `json:"variance_at_cost"`
+	VarianceAtRetail float64   `json:"variance_at_retail"`
+}
+
+// StockCountVariance is the variance report of a count
+type StockCountVariance struct {
+	StockCountID     uuid.UUID                `json:"stock_count_id"`
+	Status           StockCountStatus         `json:"status"`
+	LinesCounted     int                      `json:"lines_counted"`
+	LinesUncounted   int                      `json:"lines_uncounted"`
+	Lines            []StockCountVarianceLine `json:"lines"`
+	TotalAtCost      float64                  `json:"total_at_cost"`
+	TotalAtRetail    float64                  `json:"total_at_retail"`
+	ShortageAtCost   float64                  `json:"shortage_at_cost"`
+	SurplusAtCost    float64                  `json:"surplus_at_cost"`
+	ShortageAtRetail float64                  `json:"shortage_at_retail"`
+	SurplusAtRetail  float64                  `json:"surplus_at_retail"`
+}
@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockLot is a dye lot or production batch of a product. Goods from the
+// same lot match in colour, so products that track lots keep their stock
+// per lot as well as per shop. A lot number received again adds to the lot.
+type StockLot struct {
+	Base
+	ProductID  uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_stock_lot_product_number" json:"product_id"`
+	LotNumber  string            `gorm:"type:varchar(50);not null;uniqueIndex:idx_stock_lot_product_number" json:"lot_number"`
+	ReceivedAt time.Time         `gorm:"not null" json:"received_at"` // First receipt, which orders lots for FIFO
+	SupplierID *uuid.UUID        `gorm:"type:uuid" json:"supplier_id,omitempty"`
+	SourceType StockMovementType `gorm:"type:varchar(20)" json:"source_type,omitempty"` // PURCHASE or GOODS_RECEIPT
+	SourceID   *uuid.UUID        `gorm:"type:uuid" json:"source_id,omitempty"`          // The purchase or goods receipt that first brought it in
+	Notes      string            `gorm:"type:text" json:"notes"`
+
+	// Relations
+	Product  *Product       `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Supplier *Supplier      `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Stock    []LotInventory `gorm:"foreignKey:LotID" json:"stock,omitempty"`
+}
+
+// LotInventory is the quantity of a lot held by a shop. Stock a shop held
+// before its product tracked lots, or added by adjustments, is in no lot, so
+// a shop's lots may add up to less than its inventory but never more.
+type LotInventory struct {
+	Base
+	LotID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lot_inventory_lot_shop" json:"lot_id"`
+	ShopID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lot_inventory_lot_shop" json:"shop_id"`
+	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	Quantity  int       `gorm:"not null;default:0;check:chk_lot_inventory_quantity_non_negative,quantity >= 0" json:"quantity"`
+
+	// Relations
+	Lot  *StockLot `gorm:"foreignKey:LotID" json:"lot,omitempty"`
+	Shop *Shop     `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+}
+
+// StockLotMovement is the part of a stock movement that came out of or went
+// into a lot. Like the stock movement ledger it is only ever inserted.
+type StockLotMovement struct {
+	ID           uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LotID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"lot_id"`
+	ProductID    uuid.UUID         `gorm:"type:uuid;not null" json:"product_id"`
+	ShopID       uuid.UUID         `gorm:"type:uuid;not null" json:"shop_id"`
+	MovedAt      time.Time         `gorm:"not null" json:"moved_at"`
+	MovementType StockMovementType `gorm:"type:varchar(20);not null" json:"movement_type"`
+	DocumentID   *uuid.UUID        `gorm:"type:uuid;index" json:"document_id,omitempty"`
+	Quantity     int               `gorm:"not null" json:"quantity"` // Positive in, negative out
+	CreatedAt    time.Time         `gorm:"not null" json:"created_at"`
+
+	// Relations
+	Shop *Shop `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+}
+
+// LotSale is an invoice that sold goods from a lot
+type LotSale struct {
+	InvoiceID    uuid.UUID  `json:"invoice_id"`
+	SaleDateTime time.Time  `json:"sale_datetime"`
+	ShopID       uuid.UUID  `json:"shop_id"`
+	ShopName     string     `json:"shop_name"`
+	CustomerID   *uuid.UUID `json:"customer_id,omitempty"`
+	CustomerName string     `json:"customer_name"`
+	Quantity     int        `json:"quantity"` // Net of goods returned from the lot
+}
+
+// LotTrace follows a lot from its receipt to every shop holding it and
+// every invoice that sold it
+type LotTrace struct {
+	Lot       *StockLot          `json:"lot"`
+	Received  int                `json:"received"`
+	Sold      int                `json:"sold"`
+	OnHand    int                `json:"on_hand"`
+	Stock     []LotInventory     `json:"stock"`
+	Sales     []LotSale          `json:"sales"`
+	Movements []StockLotMovement `json:"movements"`
+}
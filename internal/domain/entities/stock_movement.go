@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StockMovementType string
+
+const (
+	MovementOpeningBalance   StockMovementType = "OPENING_BALANCE"
+	MovementPurchase         StockMovementType = "PURCHASE"
+	MovementPurchaseReversal StockMovementType = "PURCHASE_REVERSAL"
+	MovementGoodsReceipt     StockMovementType = "GOODS_RECEIPT"
+	MovementSupplierReturn   StockMovementType = "SUPPLIER_RETURN"
+	MovementSale             StockMovementType = "SALE"
+	MovementSaleReturn       StockMovementType = "SALE_RETURN"
+	MovementTransferOut      StockMovementType = "TRANSFER_OUT"
+	MovementTransferIn       StockMovementType = "TRANSFER_IN"
+	MovementAdjustment       StockMovementType = "ADJUSTMENT"
+)
+
+// StockMovement is one change to a product's stock in a shop. Rows are only
+// ever inserted, so the ledger explains every inventory quantity and can
+// rebuild it.
+type StockMovement struct {
+	ID             uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProductID      uuid.UUID         `gorm:"type:uuid;not null;index:idx_stock_movement_card" json:"product_id"`
+	ShopID         uuid.UUID         `gorm:"type:uuid;not null;index:idx_stock_movement_card" json:"shop_id"`
+	MovedAt        time.Time         `gorm:"not null;index:idx_stock_movement_card" json:"moved_at"`
+	MovementType   StockMovementType `gorm:"type:varchar(20);not null" json:"movement_type"`
+	DocumentID     *uuid.UUID        `gorm:"type:uuid;index" json:"document_id,omitempty"` // The sale, purchase, receipt, return, transfer or adjustment
+	UserID         *uuid.UUID        `gorm:"type:uuid" json:"user_id,omitempty"`
+	Quantity       int               `gorm:"not null" json:"quantity"` // Positive in, negative out
+	QuantityBefore int               `gorm:"not null" json:"quantity_before"`
+	QuantityAfter  int               `gorm:"not null" json:"quantity_after"`
+	UnitCost       float64           `gorm:"type:decimal(10,4);not null;default:0" json:"unit_cost"`
+	CreatedAt      time.Time         `gorm:"not null" json:"created_at"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// StockMovementRef names the document and user behind a stock change. For
+// products that track lots, LotID is the lot the stock goes into or must
+// come out of. For products that track serial numbers, SerialNumbers are the
+// units moved.
+type StockMovementRef struct {
+	Type          StockMovementType
+	DocumentID    uuid.UUID
+	UserID        *uuid.UUID
+	LotID         *uuid.UUID
+	SerialNumbers []string
+}
+
+// StockCardEntry is a movement with the shop's balance after it
+type StockCardEntry struct {
+	StockMovement
+	Balance int `json:"balance"`
+}
+
+// StockCard is the movement history of one product in one shop over a
+// period, with running balances
+type StockCard struct {
+	ProductID      uuid.UUID        `json:"product_id"`
+	ShopID         uuid.UUID        `json:"shop_id"`
+	Product        *Product         `json:"product,omitempty"`
+	OpeningBalance int              `json:"opening_balance"`
+	QuantityIn     int              `json:"quantity_in"`
+	QuantityOut    int              `json:"quantity_out"`
+	ClosingBalance int              `json:"closing_balance"`
+	OnHand         int              `json:"on_hand"` // Current inventory quantity, which should match the ledger
+	Entries        []StockCardEntry `json:"entries"`
+}
+
+// InventoryDrift is an inventory quantity that disagreed with the ledger
+// when it was rebuilt
+type InventoryDrift struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	ShopID         uuid.UUID `json:"shop_id"`
+	Quantity       int       `json:"quantity"`
+	LedgerQuantity int       `json:"ledger_quantity"`
+	Skipped        bool      `json:"skipped,omitempty"` // Left alone because the ledger balance is negative
+}
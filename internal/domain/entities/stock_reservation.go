@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationSource is the kind of document stock was promised to
+type ReservationSource string
+
+const (
+	ReservationSourceDraftSale   ReservationSource = "DRAFT_SALE"
+	ReservationSourceLayaway     ReservationSource = "LAYAWAY"
+	ReservationSourceOnlineOrder ReservationSource = "ONLINE_ORDER"
+	ReservationSourceTransfer    ReservationSource = "TRANSFER"
+)
+
+type StockReservationStatus string
+
+const (
+	StockReservationStatusActive    StockReservationStatus = "ACTIVE"
+	StockReservationStatusFulfilled StockReservationStatus = "FULFILLED"
+	StockReservationStatusReleased  StockReservationStatus = "RELEASED"
+	StockReservationStatusExpired   StockReservationStatus = "EXPIRED"
+)
+
+// StockReservation holds stock in a shop for a document that has not been
+// posted yet. While it is active its quantity counts as reserved on the
+// shop's inventory and can't be sold to anyone else.
+type StockReservation struct {
+	Base
+	ShopID      uuid.UUID              `gorm:"type:uuid;not null;index" json:"shop_id"`
+	ProductID   uuid.UUID              `gorm:"type:uuid;not null;index" json:"product_id"`
+	Quantity    int                    `gorm:"not null;check:chk_stock_reservation_quantity_positive,quantity > 0" json:"quantity"`
+	SourceType  ReservationSource      `gorm:"type:varchar(20);not null" json:"source_type"`
+	SourceID    *uuid.UUID             `gorm:"type:uuid;index" json:"source_id,omitempty"` // The draft, layaway, order or transfer, when it has an ID
+	Reference   string                 `gorm:"type:varchar(100)" json:"reference,omitempty"`
+	CustomerID  *uuid.UUID             `gorm:"type:uuid" json:"customer_id,omitempty"`
+	Status      StockReservationStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	ExpiresAt   *time.Time             `gorm:"index" json:"expires_at,omitempty"` // Nil holds the stock until it is released
+	Notes       string                 `gorm:"type:text" json:"notes"`
+	CreatedByID uuid.UUID              `gorm:"type:uuid;not null" json:"created_by_id"`
+	ClosedAt    *time.Time             `json:"closed_at,omitempty"` // When it was fulfilled, released or expired
+
+	// Relations
+	Shop      *Shop     `gorm:"foreignKey:ShopID;references:ShopID" json:"shop,omitempty"`
+	Product   *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Customer  *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	CreatedBy *User     `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+}
@@ -36,6 +36,9 @@ type StockTransfer struct {
 	RejectedBy        *uuid.UUID          `gorm:"type:uuid" json:"rejected_by"`
 	RejectedAt        *time.Time          `json:"rejected_at"`
 	RejectionReason   string              `json:"rejection_reason"`
+	ReservationID     *uuid.UUID          `gorm:"type:uuid" json:"reservation_id,omitempty"` // Stock held at the source shop for this transfer
+	LotID             *uuid.UUID          `gorm:"type:uuid" json:"lot_id,omitempty"`         // The lot chosen for the transfer; the oldest stock is sent when empty
+	SerialNumbers     []string            `gorm:"-" json:"serial_numbers,omitempty"`         // The units sent, for products that track serial numbers
 	FromShop          *Shop               `gorm:"foreignKey:FromShopID;references:ShopID" json:"from_shop,omitempty"`
 	ToShop            *Shop               `gorm:"foreignKey:ToShopID;references:ShopID" json:"to_shop,omitempty"`
 	Product           *Product            `gorm:"foreignKey:ProductID" json:"product,omitempty"`
@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// SupplierProduct is a supplier's catalog entry for one of our products: the
+// code the supplier uses for it on their own documents and their ordering
+// terms. Each product has at most one preferred supplier, which a partial
+// unique index enforces.
+type SupplierProduct struct {
+	Base
+	SupplierID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_supplier_product" json:"supplier_id"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_supplier_product;uniqueIndex:idx_supplier_product_preferred,where:is_preferred AND is_marked_to_delete = false" json:"product_id"`
+	SupplierCode     string    `gorm:"type:varchar(100);index" json:"supplier_code"`
+	LastPrice        float64   `gorm:"type:decimal(10,2);not null;default:0" json:"last_price"`
+	MinOrderQuantity int       `gorm:"not null;default:0" json:"min_order_quantity"`
+	PackSize         int       `gorm:"not null;default:1" json:"pack_size"` // Orders are placed in multiples of this
+	LeadTimeDays     int       `gorm:"not null;default:0" json:"lead_time_days"`
+	IsPreferred      bool      `gorm:"not null;default:false" json:"is_preferred"`
+
+	// Relations
+	Supplier *Supplier `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Product  *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupplierReturn sends goods from a purchase back to the supplier. Its total
+// is the debit note amount deducted from what we owe the supplier.
+type SupplierReturn struct {
+	Base
+	SupplierID        uuid.UUID `gorm:"type:uuid;not null" json:"supplier_id"`
+	PurchaseInvoiceID uuid.UUID `gorm:"type:uuid;not null" json:"purchase_invoice_id"`
+	ShopID            uuid.UUID `gorm:"type:uuid;not null" json:"shop_id"`
+	ReturnDateTime    time.Time `gorm:"not null" json:"return_datetime"`
+	DebitNoteAmount   float64   `gorm:"type:decimal(10,2);not null" json:"debit_note_amount"`
+	ReturnedByID      uuid.UUID `gorm:"type:uuid;not null" json:"returned_by_id"`
+	Reason            string    `gorm:"type:varchar(255);not null" json:"reason"`
+	Remarks           string    `gorm:"type:text" json:"remarks"`
+
+	// Relations
+	Supplier        *Supplier            `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	PurchaseInvoice *PurchaseInvoice     `gorm:"foreignKey:PurchaseInvoiceID" json:"purchase_invoice,omitempty"`
+	ReturnedBy      *User                `gorm:"foreignKey:ReturnedByID" json:"returned_by,omitempty"`
+	Lines           []SupplierReturnLine `gorm:"foreignKey:SupplierReturnID" json:"lines,omitempty"`
+}
+
+type SupplierReturnLine struct {
+	Base
+	SupplierReturnID uuid.UUID `gorm:"type:uuid;not null" json:"supplier_return_id"`
+	PurchaseDetailID uuid.UUID `gorm:"type:uuid;not null" json:"purchase_detail_id"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity         int       `gorm:"not null" json:"quantity"`
+	UnitPrice        float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	Subtotal         float64   `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	SerialNumbers    []string  `gorm:"-" json:"serial_numbers,omitempty"` // The units sent back, for products that track serial numbers
+
+	// Relations
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// SupplierLedgerEntry is one movement on a supplier's account. Credit raises
+// what we owe the supplier and debit lowers it.
+type SupplierLedgerEntry struct {
+	Date         time.Time `json:"date"`
+	DocumentType string    `json:"document_type"`
+	DocumentID   uuid.UUID `json:"document_id"`
+	Description  string    `json:"description"`
+	Debit        float64   `json:"debit"`
+	Credit       float64   `json:"credit"`
+	Balance      float64   `json:"balance"`
+}
+
+// SupplierLedger is a supplier's account statement
+type SupplierLedger struct {
+	SupplierID uuid.UUID             `json:"supplier_id"`
+	Entries    []SupplierLedgerEntry `json:"entries"`
+	Balance    float64               `json:"balance"`
+}
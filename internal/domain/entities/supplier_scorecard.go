@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupplierScorecard rates a supplier's deliveries, returns and prices over a
+// period. Rates are percentages and are left empty when the period has
+// nothing to measure them on.
+type SupplierScorecard struct {
+	SupplierID        uuid.UUID `json:"supplier_id"`
+	SupplierName      string    `json:"supplier_name"`
+	OrderCount        int       `json:"order_count"`
+	DeliveryCount     int       `json:"delivery_count"`
+	OnTimeDeliveries  int       `json:"on_time_deliveries"`
+	OnTimeRate        *float64  `json:"on_time_rate"`      // Deliveries by the order's expected date
+	AverageLeadDays   *float64  `json:"average_lead_days"` // Days from order to delivery
+	OrderedQuantity   int       `json:"ordered_quantity"`  // On orders that are closed or past their expected date
+	ReceivedQuantity  int       `json:"received_quantity"`
+	FillRate          *float64  `json:"fill_rate"` // Ordered quantity actually delivered, on orders that are due
+	PurchasedQuantity int       `json:"purchased_quantity"`
+	ReturnedQuantity  int       `json:"returned_quantity"`
+	ReturnRate        *float64  `json:"return_rate"`    // Purchased quantity sent back
+	PriceVariance     *float64  `json:"price_variance"` // Above (+) or below (-) the average paid to all suppliers for the same products
+	PurchaseValue     float64   `json:"purchase_value"` // In taka
+	Score             *float64  `json:"score"`          // 0-100, higher is better
+	Rank              int       `json:"rank,omitempty"`
+}
+
+// SupplierCategoryRanking ranks the suppliers of one product category by
+// their scorecard for that category's products
+type SupplierCategoryRanking struct {
+	Category  string              `json:"category"`
+	Suppliers []SupplierScorecard `json:"suppliers"`
+}
+
+// SupplierScorecardReport holds the scorecards of a period
+type SupplierScorecardReport struct {
+	DateFrom   time.Time           `json:"date_from"`
+	DateTo     time.Time           `json:"date_to"`
+	Category   string              `json:"category,omitempty"`
+	Scorecards []SupplierScorecard `json:"scorecards"`
+}
@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"context"
+
+	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+)
+
+// Message is one notification on its way to one recipient
+type Message struct {
+	To           string // Email address, phone number or webhook URL
+	Subject      string
+	Body         string
+	Notification *entities.Notification
+}
+
+// Channel sends notifications outside the application. The in-app inbox is
+// not a channel; notifications are in it once they are saved.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Channels are the channels notifications can go out through
+type Channels map[entities.NotificationChannel]Channel
+
+// NewChannels sets up email, SMS and webhook delivery from the config. Any
+// channel that isn't configured, or every channel when the config is
+// offline, gets a stand-in that logs the message instead.
+func NewChannels(cfg config.NotificationConfig) Channels {
+	channels := Channels{
+		entities.NotificationChannelEmail:   NewLogChannel(entities.NotificationChannelEmail),
+		entities.NotificationChannelSMS:     NewLogChannel(entities.NotificationChannelSMS),
+		entities.NotificationChannelWebhook: NewLogChannel(entities.NotificationChannelWebhook),
+	}
+	if cfg.Offline {
+		return channels
+	}
+
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" {
+		channels[entities.NotificationChannelEmail] = NewSMTPChannel(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	if cfg.SMSGatewayURL != "" {
+		channels[entities.NotificationChannelSMS] = NewSMSChannel(NewHTTPSMSGateway(cfg.SMSGatewayURL, cfg.SMSGatewayKey))
+	}
+	channels[entities.NotificationChannelWebhook] = NewWebhookChannel(cfg.WebhookSecret)
+
+	return channels
+}
@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SMSGateway sends text messages. Each SMS provider gets an implementation
+// of its own.
+type SMSGateway interface {
+	SendSMS(ctx context.Context, to, text string) error
+}
+
+// SMSChannel texts notifications through a gateway
+type SMSChannel struct {
+	gateway SMSGateway
+}
+
+func NewSMSChannel(gateway SMSGateway) *SMSChannel {
+	return &SMSChannel{
+		gateway: gateway,
+	}
+}
+
+func (c *SMSChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("no phone number")
+	}
+	return c.gateway.SendSMS(ctx, msg.To, msg.Subject+": "+msg.Body)
+}
+
+// HTTPSMSGateway posts {"to", "message"} as JSON to a gateway URL, with the
+// key as a bearer token
+type HTTPSMSGateway struct {
+	url    string
+	key    string
+	client *http.Client
+}
+
+func NewHTTPSMSGateway(url, key string) *HTTPSMSGateway {
+	return &HTTPSMSGateway{
+		url:    url,
+		key:    key,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (g *HTTPSMSGateway) SendSMS(ctx context.Context, to, text string) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      to,
+		"message": text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.key != "" {
+		req.Header.Set("Authorization", "Bearer "+g.key)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway answered %s", resp.Status)
+	}
+	return nil
+}
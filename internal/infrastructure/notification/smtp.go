@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPChannel emails notifications through an SMTP server
+type SMTPChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPChannel(host, port, username, password, from string) *SMTPChannel {
+	channel := &SMTPChannel{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+	}
+	if username != "" {
+		channel.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return channel
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("no email address")
+	}
+	// Line breaks would let the address or subject add headers of their own
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("line break in email address or subject")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", c.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(msg.Body)
+
+	// net/smtp has no context, so the send runs on and only the wait ends
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(c.addr, c.auth, c.from, []string{msg.To}, []byte(body.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,58 @@
+package notification
+
+import (
+	"context"
+	"sync"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	"Sheikh-Enterprise-Backend/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// LogChannel stands in for a channel that isn't set up by logging what would
+// have been sent
+type LogChannel struct {
+	channel entities.NotificationChannel
+}
+
+func NewLogChannel(channel entities.NotificationChannel) *LogChannel {
+	return &LogChannel{
+		channel: channel,
+	}
+}
+
+func (c *LogChannel) Send(ctx context.Context, msg Message) error {
+	logger.Info("Notification not sent, channel is a stand-in",
+		zap.String("channel", string(c.channel)),
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// Outbox stands in for any channel by keeping what it was given, so delivery
+// can be checked without a mail server, gateway or webhook
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Sent returns the messages sent so far
+func (o *Outbox) Sent() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
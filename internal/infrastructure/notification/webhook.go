@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookChannel posts notifications as JSON to a URL. With a secret the body
+// is signed with HMAC-SHA256 in the X-Signature header.
+type WebhookChannel struct {
+	secret string
+	client *http.Client
+}
+
+func NewWebhookChannel(secret string) *WebhookChannel {
+	return &WebhookChannel{
+		secret: secret,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+			// Redirects could lead away from the hosts webhooks are allowed on
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("no webhook URL")
+	}
+
+	payload, err := json.Marshal(msg.Notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.To, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(payload)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The functions below are the only place stock quantities and costs change.
+// They take the caller's transaction handle so the stock movement commits or
+// rolls back together with the document that caused it, and every change of
+// quantity is written to the stock movement ledger.
+
+// costingMethodForShop returns the costing method of the company owning the shop
+func costingMethodForShop(tx *gorm.DB, shopID uuid.UUID) (entities.CostingMethod, error) {
+	var method string
+	err := tx.Table("shops").
+		Select("companies.costing_method").
+		Joins("JOIN companies ON companies.id = shops.company_id").
+		Where("shops.shop_id = ?", shopID).
+		Scan(&method).Error
+	if err != nil {
+		return "", err
+	}
+	if method == "" {
+		return entities.CostingMethodAverage, nil
+	}
+	return entities.CostingMethod(method), nil
+}
+
+// lockInventory loads and locks a product's inventory row in a shop with
+// SELECT ... FOR UPDATE. It returns nil if the shop has never held the product.
+func lockInventory(tx *gorm.DB, productID, shopID uuid.UUID) (*entities.Inventory, error) {
+	var inventory entities.Inventory
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", productID, shopID, false).
+		First(&inventory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// lockOrCreateInventory locks a product's inventory row in a shop, first
+// inserting an empty one if the shop has never held the product. The insert
+// relies on the unique (product_id, shop_id) index, so concurrent first
+// receipts end up sharing one row.
+func lockOrCreateInventory(tx *gorm.DB, productID, shopID uuid.UUID) (*entities.Inventory, error) {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "product_id"}, {Name: "shop_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "is_marked_to_delete", Value: false}}},
+		DoNothing:   true,
+	}).Create(&entities.Inventory{ProductID: productID, ShopID: shopID}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := lockInventory(tx, productID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if inventory == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return inventory, nil
+}
+
+// deductStock takes quantity off a locked inventory row. The update only
+// applies while enough stock is left, so it can never drive the quantity
+// below zero even if the row changed since it was read.
+func deductStock(tx *gorm.DB, inventory *entities.Inventory, quantity int, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["quantity"] = gorm.Expr("quantity - ?", quantity)
+
+	result := tx.Model(&entities.Inventory{}).
+		Where("id = ? AND quantity >= ?", inventory.ID, quantity).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &InsufficientStockError{
+			ProductID: inventory.ProductID,
+			ShopID:    inventory.ShopID,
+			Requested: quantity,
+			Available: inventory.Quantity,
+		}
+	}
+	return nil
+}
+
+// receiveStock adds stock at a unit cost, updating the weighted average cost
+// and opening a new cost layer for FIFO
+func receiveStock(tx *gorm.DB, productID, shopID uuid.UUID, quantity int, unitCost float64, sourceType entities.CostSourceType, sourceID uuid.UUID, ref entities.StockMovementRef) error {
+	inventory, err := lockOrCreateInventory(tx, productID, shopID)
+	if err != nil {
+		return err
+	}
+
+	averageCost := unitCost
+	if total := inventory.Quantity + quantity; inventory.Quantity > 0 && total > 0 {
+		averageCost = (float64(inventory.Quantity)*inventory.AverageCost + float64(quantity)*unitCost) / float64(total)
+	}
+	err = tx.Model(inventory).Updates(map[string]interface{}{
+		"quantity":     gorm.Expr("quantity + ?", quantity),
+		"average_cost": averageCost,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := recordMovement(tx, productID, shopID, inventory.Quantity, quantity, unitCost, ref); err != nil {
+		return err
+	}
+
+	return tx.Create(&entities.CostLayer{
+		ProductID:         productID,
+		ShopID:            shopID,
+		SourceType:        sourceType,
+		SourceID:          sourceID,
+		ReceivedAt:        time.Now(),
+		Quantity:          quantity,
+		RemainingQuantity: quantity,
+		UnitCost:          unitCost,
+	}).Error
+}
+
+// issueAvailableStock issues stock that isn't reserved, failing with an
+// InsufficientStockError when reservations hold back what is asked for
+func issueAvailableStock(tx *gorm.DB, productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) (float64, error) {
+	if _, err := lockAvailableStock(tx, productID, shopID, quantity); err != nil {
+		return 0, err
+	}
+	return issueStock(tx, productID, shopID, quantity, ref)
+}
+
+// issueStock takes stock out for a sale and returns its total cost under the
+// company's costing method. It fails with ErrInsufficientStock rather than
+// letting the quantity go negative. Callers check reservations first.
+func issueStock(tx *gorm.DB, productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) (float64, error) {
+	inventory, err := lockInventory(tx, productID, shopID)
+	if err != nil {
+		return 0, err
+	}
+	if inventory == nil {
+		return 0, &InsufficientStockError{ProductID: productID, ShopID: shopID, Requested: quantity}
+	}
+	if inventory.Quantity < quantity {
+		return 0, &InsufficientStockError{ProductID: productID, ShopID: shopID, Requested: quantity, Available: inventory.Quantity}
+	}
+
+	method, err := costingMethodForShop(tx, shopID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Layers are consumed under both methods so switching method later
+	// starts from the right batches
+	fifoCost, err := consumeLayers(tx, productID, shopID, nil, quantity, inventory.AverageCost)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := deductStock(tx, inventory, quantity, nil); err != nil {
+		return 0, err
+	}
+
+	cost := inventory.AverageCost * float64(quantity)
+	if method == entities.CostingMethodFIFO {
+		cost = fifoCost
+	}
+
+	unitCost := inventory.AverageCost
+	if quantity > 0 {
+		unitCost = cost / float64(quantity)
+	}
+	err = recordMovement(tx, productID, shopID, inventory.Quantity, -quantity, unitCost, ref)
+	if err != nil {
+		return 0, err
+	}
+	return cost, nil
+}
+
+// reverseReceipt takes back stock that was received from a specific
+// document at a known unit cost, as when a purchase is deleted or goods go
+// back to the supplier. The average cost is unwound and the document's own
+// cost layers are consumed first. Reserved stock can't be taken back.
+func reverseReceipt(tx *gorm.DB, productID, shopID uuid.UUID, quantity int, unitCost float64, sourceID uuid.UUID, ref entities.StockMovementRef) error {
+	inventory, err := lockAvailableStock(tx, productID, shopID, quantity)
+	if err != nil {
+		return err
+	}
+
+	averageCost := inventory.AverageCost
+	if remaining := inventory.Quantity - quantity; remaining > 0 {
+		averageCost = (float64(inventory.Quantity)*inventory.AverageCost - float64(quantity)*unitCost) / float64(remaining)
+		if averageCost < 0 {
+			averageCost = 0
+		}
+	}
+
+	if _, err := consumeLayers(tx, productID, shopID, &sourceID, quantity, unitCost); err != nil {
+		return err
+	}
+
+	if err := deductStock(tx, inventory, quantity, map[string]interface{}{"average_cost": averageCost}); err != nil {
+		return err
+	}
+
+	return recordMovement(tx, productID, shopID, inventory.Quantity, -quantity, unitCost, ref)
+}
+
+// revalueReceipt changes the unit cost of stock received from a document,
+// as when landed costs are added after the goods arrived. Only the quantity
+// still on hand affects the average cost.
+func revalueReceipt(tx *gorm.DB, productID, shopID, sourceID uuid.UUID, unitCost float64) (int, error) {
+	var layers []entities.CostLayer
+	err := tx.Where("source_id = ? AND product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", sourceID, productID, shopID, false).
+		Find(&layers).Error
+	if err != nil || len(layers) == 0 {
+		return 0, err
+	}
+
+	inventory, err := lockInventory(tx, productID, shopID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, layer := range layers {
+		if inventory != nil && inventory.Quantity > 0 {
+			inventory.AverageCost += (unitCost - layer.UnitCost) * float64(layer.RemainingQuantity) / float64(inventory.Quantity)
+		}
+		if err := tx.Model(&layer).Update("unit_cost", unitCost).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if inventory == nil {
+		return len(layers), nil
+	}
+	return len(layers), tx.Model(inventory).Update("average_cost", inventory.AverageCost).Error
+}
+
+// consumeLayers takes quantity out of the open cost layers, oldest first,
+// starting with the given source's layers when one is passed. Stock that
+// predates cost layers is valued at fallbackCost.
+func consumeLayers(tx *gorm.DB, productID, shopID uuid.UUID, sourceID *uuid.UUID, quantity int, fallbackCost float64) (float64, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND shop_id = ? AND remaining_quantity > 0 AND is_marked_to_delete = ?", productID, shopID, false)
+	if sourceID != nil {
+		query = query.Order(clause.OrderBy{
+			Expression: clause.Expr{SQL: "CASE WHEN source_id = ? THEN 0 ELSE 1 END", Vars: []interface{}{*sourceID}},
+		})
+	}
+
+	var layers []entities.CostLayer
+	if err := query.Order("received_at").Order("created_at").Find(&layers).Error; err != nil {
+		return 0, err
+	}
+
+	var cost float64
+	remaining := quantity
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+		take := layer.RemainingQuantity
+		if take > remaining {
+			take = remaining
+		}
+		cost += float64(take) * layer.UnitCost
+		remaining -= take
+
+		err := tx.Model(&layer).Update("remaining_quantity", layer.RemainingQuantity-take).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return cost + float64(remaining)*fallbackCost, nil
+}
+
+// transferStock moves stock between shops. The goods arrive at the cost they
+// left the source shop at, in the lots they left it from and with the same
+// serial numbers. Products that track lots send lotID, or the oldest stock
+// when it is nil. Reserved stock stays in the source shop.
+func transferStock(tx *gorm.DB, transferID, fromShopID, toShopID, productID uuid.UUID, quantity int, lotID *uuid.UUID, serialNumbers []string, userID *uuid.UUID) error {
+	cost, err := issueAvailableStock(tx, productID, fromShopID, quantity, entities.StockMovementRef{
+		Type:          entities.MovementTransferOut,
+		DocumentID:    transferID,
+		UserID:        userID,
+		LotID:         lotID,
+		SerialNumbers: serialNumbers,
+	})
+	if err != nil {
+		return err
+	}
+
+	return receiveStock(tx, productID, toShopID, quantity, cost/float64(quantity), entities.CostSourceTransfer, transferID, entities.StockMovementRef{
+		Type:       entities.MovementTransferIn,
+		DocumentID: transferID,
+		UserID:     userID,
+	})
+}
+
+// recordMovement appends a change of quantity to the stock movement ledger
+// and, for products that track lots or serial numbers, moves their lots and
+// units. before is the quantity on hand before the change.
+func recordMovement(tx *gorm.DB, productID, shopID uuid.UUID, before, quantity int, unitCost float64, ref entities.StockMovementRef) error {
+	if err := moveLots(tx, productID, shopID, before, quantity, ref); err != nil {
+		return err
+	}
+	if err := moveSerials(tx, productID, shopID, quantity, ref); err != nil {
+		return err
+	}
+
+	movement := &entities.StockMovement{
+		ProductID:      productID,
+		ShopID:         shopID,
+		MovedAt:        time.Now(),
+		MovementType:   ref.Type,
+		UserID:         ref.UserID,
+		Quantity:       quantity,
+		QuantityBefore: before,
+		QuantityAfter:  before + quantity,
+		UnitCost:       unitCost,
+	}
+	if ref.DocumentID != uuid.Nil {
+		documentID := ref.DocumentID
+		movement.DocumentID = &documentID
+	}
+	return tx.Create(movement).Error
+}
+
+// receivedUnitCost is the cost a purchase line was brought into stock at.
+// Lines saved before landed costs existed have no landed unit cost.
+func receivedUnitCost(detail entities.PurchaseDetail) float64 {
+	if detail.LandedUnitCost > 0 {
+		return detail.LandedUnitCost
+	}
+	return detail.PurchasePrice
+}
@@ -0,0 +1,109 @@
+package persistence
+
+import (
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CurrencyRepository interface {
+	BaseRepository[entities.Currency]
+	GetCurrencies() ([]entities.Currency, error)
+	GetByCode(code string) (*entities.Currency, error)
+	GetRatesWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.ExchangeRate, int64, error)
+	SaveRate(rate *entities.ExchangeRate) error
+	GetRateOn(code string, date time.Time) (*entities.ExchangeRate, error)
+}
+
+type currencyRepository struct {
+	BaseRepositoryImpl[entities.Currency]
+}
+
+func NewCurrencyRepository(db *gorm.DB) CurrencyRepository {
+	return &currencyRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.Currency]{DB: db},
+	}
+}
+
+func (r *currencyRepository) GetCurrencies() ([]entities.Currency, error) {
+	var currencies []entities.Currency
+	err := r.DB.Where("is_marked_to_delete = ?", false).
+		Order("code").
+		Find(&currencies).Error
+	return currencies, err
+}
+
+func (r *currencyRepository) GetByCode(code string) (*entities.Currency, error) {
+	var currency entities.Currency
+	err := r.DB.Where("code = ? AND is_marked_to_delete = ?", code, false).
+		First(&currency).Error
+	if err != nil {
+		return nil, err
+	}
+	return &currency, nil
+}
+
+func (r *currencyRepository) GetRatesWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.ExchangeRate, int64, error) {
+	var rates []entities.ExchangeRate
+	var total int64
+
+	query := r.DB.Model(&entities.ExchangeRate{}).
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "currency_code":
+			query = query.Where("currency_code = ?", value)
+		case "date_from":
+			query = query.Where("rate_date >= ?", value)
+		case "date_to":
+			query = query.Where("rate_date <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+	if len(sorts) == 0 {
+		query = query.Order("rate_date DESC").Order("currency_code")
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Find(&rates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return rates, total, nil
+}
+
+// SaveRate records a currency's rate for a day, replacing any rate already
+// entered for that day
+func (r *currencyRepository) SaveRate(rate *entities.ExchangeRate) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency_code"}, {Name: "rate_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "is_marked_to_delete", "updated_at"}),
+	}).Create(rate).Error
+}
+
+// GetRateOn returns the latest rate entered on or before the given day
+func (r *currencyRepository) GetRateOn(code string, date time.Time) (*entities.ExchangeRate, error) {
+	var rate entities.ExchangeRate
+	err := r.DB.Where("currency_code = ? AND rate_date <= ? AND is_marked_to_delete = ?", code, date, false).
+		Order("rate_date DESC").
+		First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
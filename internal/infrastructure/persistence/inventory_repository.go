@@ -1,21 +1,95 @@
 package persistence
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// InsufficientStockError reports a shop holding less of a product than a
+// movement needs. It matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	ProductID uuid.UUID
+	ShopID    uuid.UUID
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock of product %s in shop %s: %d requested, %d available", e.ProductID, e.ShopID, e.Requested, e.Available)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
 type InventoryRepository interface {
+	WithTx(tx *gorm.DB) InventoryRepository
 	GetByProductAndShop(productID, shopID uuid.UUID) (*entities.Inventory, error)
 	GetInventoryByShopID(shopID uuid.UUID) ([]entities.Inventory, error)
-	UpdateStock(productID, shopID uuid.UUID, quantity int) error
-	TransferStock(fromShopID, toShopID, productID uuid.UUID, quantity int) error
+	UpdateStock(productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) error
+	TransferStock(transferID, fromShopID, toShopID, productID uuid.UUID, quantity int, lotID *uuid.UUID, serialNumbers []string, userID *uuid.UUID) error
 	GetLowStockItems(shopID uuid.UUID, threshold int) ([]entities.Inventory, error)
+	GetLowStockByProducts(shopID uuid.UUID, productIDs []uuid.UUID, threshold int) ([]entities.Inventory, error)
 	GetInventoryWithFilters(filters map[string]interface{}, sortBy, sortOrder string, page, pageSize int) ([]entities.Inventory, int64, error)
+	GetByProduct(productID uuid.UUID, shopID *uuid.UUID) ([]entities.Inventory, error)
+	GetShopTotals(shopID *uuid.UUID) ([]entities.ShopStockTotal, error)
+	GetStockLevelSummary(shopID *uuid.UUID, threshold int) (*entities.StockLevelSummary, error)
+}
+
+// inventorySortColumns are the columns stock lists may be sorted by
+var inventorySortColumns = map[string]string{
+	"quantity":     "inventories.quantity",
+	"average_cost": "inventories.average_cost",
+	"updated_at":   "inventories.updated_at",
+	"code":         "products.code",
+	"name":         "products.name",
+	"category":     "products.master_category",
 }
 
+// lowStockCondition treats stock as low once it falls to the higher of the
+// reorder point and minimum of the product's reorder rule in the shop. A
+// product without a rule falls back to its category's default in the shop,
+// then to the given threshold. A rule or default applies even when it is all
+// zeros, the same as in replenishment. It needs products joined.
+const lowStockCondition = `inventories.quantity <= COALESCE((
+	SELECT GREATEST(reorder_rules.reorder_point, reorder_rules.min_quantity)
+	FROM reorder_rules
+	WHERE reorder_rules.product_id = inventories.product_id
+		AND reorder_rules.shop_id = inventories.shop_id
+		AND reorder_rules.is_marked_to_delete = false
+), (
+	SELECT GREATEST(category_reorder_defaults.reorder_point, category_reorder_defaults.min_quantity)
+	FROM category_reorder_defaults
+	WHERE category_reorder_defaults.shop_id = inventories.shop_id
+		AND category_reorder_defaults.master_category = products.master_category
+		AND category_reorder_defaults.is_marked_to_delete = false
+), ?)`
+
+// stockLevelSummarySQL counts stock against the reorder settings that apply
+// to it. A product's own rule wins over its category's default.
+const stockLevelSummarySQL = `
+	SELECT COUNT(*) FILTER (WHERE inventories.quantity = 0) AS out_of_stock,
+		COUNT(*) FILTER (WHERE ` + lowStockCondition + `) AS low_stock,
+		COUNT(*) FILTER (WHERE inventories.quantity < COALESCE(reorder_rules.min_quantity, category_reorder_defaults.min_quantity, 0)) AS below_min,
+		COUNT(*) FILTER (WHERE COALESCE(reorder_rules.max_quantity, category_reorder_defaults.max_quantity, 0) > 0
+			AND inventories.quantity > COALESCE(reorder_rules.max_quantity, category_reorder_defaults.max_quantity)) AS over_max
+	FROM inventories
+	JOIN products ON inventories.product_id = products.id AND products.deleted_at IS NULL
+	LEFT JOIN reorder_rules ON reorder_rules.product_id = inventories.product_id
+		AND reorder_rules.shop_id = inventories.shop_id AND reorder_rules.is_marked_to_delete = false
+	LEFT JOIN category_reorder_defaults ON category_reorder_defaults.shop_id = inventories.shop_id
+		AND category_reorder_defaults.master_category = products.master_category
+		AND category_reorder_defaults.is_marked_to_delete = false
+	WHERE inventories.is_marked_to_delete = false`
+
 type inventoryRepository struct {
 	db *gorm.DB
 }
@@ -26,9 +100,17 @@ func NewInventoryRepository(db *gorm.DB) InventoryRepository {
 	}
 }
 
+// WithTx returns a repository whose stock changes join the caller's
+// transaction
+func (r *inventoryRepository) WithTx(tx *gorm.DB) InventoryRepository {
+	return &inventoryRepository{
+		db: tx,
+	}
+}
+
 func (r *inventoryRepository) GetByProductAndShop(productID, shopID uuid.UUID) (*entities.Inventory, error) {
 	var inventory entities.Inventory
-	err := r.db.Where("product_id = ? AND shop_id = ?", productID, shopID).First(&inventory).Error
+	err := r.db.Where("product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", productID, shopID, false).First(&inventory).Error
 	if err != nil {
 		return nil, err
 	}
@@ -37,54 +119,47 @@ func (r *inventoryRepository) GetByProductAndShop(productID, shopID uuid.UUID) (
 
 func (r *inventoryRepository) GetInventoryByShopID(shopID uuid.UUID) ([]entities.Inventory, error) {
 	var inventory []entities.Inventory
-	err := r.db.Where("shop_id = ?", shopID).Find(&inventory).Error
+	err := r.db.Preload("Product").Where("shop_id = ? AND is_marked_to_delete = ?", shopID, false).Find(&inventory).Error
 	if err != nil {
 		return nil, err
 	}
 	return inventory, nil
 }
 
-func (r *inventoryRepository) UpdateStock(productID, shopID uuid.UUID, quantity int) error {
-	return r.db.Exec(`
-		INSERT INTO inventory (product_id, shop_id, quantity)
-		VALUES (?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-		quantity = quantity + ?
-	`, productID, shopID, quantity, quantity).Error
+// UpdateStock adds or, for a negative quantity, removes stock and records
+// the change on the ledger. Stock added comes in at the shop's average cost.
+// It fails with an InsufficientStockError rather than going below zero or
+// taking reserved stock.
+func (r *inventoryRepository) UpdateStock(productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if quantity < 0 {
+			_, err := issueAvailableStock(tx, productID, shopID, -quantity, ref)
+			return err
+		}
+		unitCost, err := adjustmentUnitCost(tx, productID, shopID)
+		if err != nil {
+			return err
+		}
+		return receiveStock(tx, productID, shopID, quantity, unitCost, entities.CostSourceAdjustment, ref.DocumentID, ref)
+	})
 }
 
-func (r *inventoryRepository) TransferStock(fromShopID, toShopID, productID uuid.UUID, quantity int) error {
-	tx := r.db.Begin()
-
-	// Decrease stock in source shop
-	if err := tx.Exec(`
-		UPDATE inventory
-		SET quantity = quantity - ?
-		WHERE shop_id = ? AND product_id = ? AND quantity >= ?
-	`, quantity, fromShopID, productID, quantity).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Increase stock in destination shop
-	if err := tx.Exec(`
-		INSERT INTO inventory (product_id, shop_id, quantity)
-		VALUES (?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-		quantity = quantity + ?
-	`, productID, toShopID, quantity, quantity).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return tx.Commit().Error
+// TransferStock moves stock between shops in one transaction, or as part of
+// the caller's when the repository was made with WithTx
+func (r *inventoryRepository) TransferStock(transferID, fromShopID, toShopID, productID uuid.UUID, quantity int, lotID *uuid.UUID, serialNumbers []string, userID *uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return transferStock(tx, transferID, fromShopID, toShopID, productID, quantity, lotID, serialNumbers, userID)
+	})
 }
 
 func (r *inventoryRepository) GetLowStockItems(shopID uuid.UUID, threshold int) ([]entities.Inventory, error) {
 	var inventory []entities.Inventory
 	err := r.db.
-		Joins("JOIN products ON inventory.product_id = products.product_id").
-		Where("inventory.shop_id = ? AND inventory.quantity <= ?", shopID, threshold).
+		Preload("Product").
+		Joins("JOIN products ON inventories.product_id = products.id AND products.deleted_at IS NULL").
+		Where("inventories.shop_id = ? AND inventories.is_marked_to_delete = ?", shopID, false).
+		Where(lowStockCondition, threshold).
+		Order("inventories.quantity").
 		Find(&inventory).Error
 	if err != nil {
 		return nil, err
@@ -92,16 +167,51 @@ func (r *inventoryRepository) GetLowStockItems(shopID uuid.UUID, threshold int)
 	return inventory, nil
 }
 
+// GetLowStockByProducts returns which of the given products are low in the
+// shop
+func (r *inventoryRepository) GetLowStockByProducts(shopID uuid.UUID, productIDs []uuid.UUID, threshold int) ([]entities.Inventory, error) {
+	var inventory []entities.Inventory
+	if len(productIDs) == 0 {
+		return inventory, nil
+	}
+	err := r.db.
+		Preload("Product").
+		Preload("Shop").
+		Joins("JOIN products ON inventories.product_id = products.id AND products.deleted_at IS NULL").
+		Where("inventories.shop_id = ? AND inventories.product_id IN ? AND inventories.is_marked_to_delete = ?", shopID, productIDs, false).
+		Where(lowStockCondition, threshold).
+		Find(&inventory).Error
+	return inventory, err
+}
+
 func (r *inventoryRepository) GetInventoryWithFilters(filters map[string]interface{}, sortBy, sortOrder string, page, pageSize int) ([]entities.Inventory, int64, error) {
 	var inventories []entities.Inventory
 	var total int64
 
-	query := r.db.Model(&entities.Inventory{})
+	query := r.db.Model(&entities.Inventory{}).
+		Preload("Product").
+		Preload("Shop").
+		Joins("JOIN products ON inventories.product_id = products.id AND products.deleted_at IS NULL").
+		Where("inventories.is_marked_to_delete = ?", false)
 
 	// Apply filters
-	for key, value := range filters {
-		if value != nil {
-			query = query.Where(key, value)
+	for field, value := range filters {
+		switch field {
+		case "shop_id", "product_id":
+			query = query.Where("inventories."+field+" = ?", value)
+		case "category":
+			query = query.Where("products.master_category = ?", value)
+		case "sub_category", "size", "color":
+			query = query.Where("products."+field+" = ?", value)
+		case "low_stock_threshold":
+			query = query.Where(lowStockCondition, value)
+		case "in_stock":
+			query = query.Where("inventories.quantity > 0")
+		case "location_type":
+			query = query.Where("inventories.shop_id IN (SELECT shop_id FROM shops WHERE type = ?)", value)
+		case "search":
+			search := "%" + value.(string) + "%"
+			query = query.Where("products.code ILIKE ? OR products.name ILIKE ?", search, search)
 		}
 	}
 
@@ -111,8 +221,13 @@ func (r *inventoryRepository) GetInventoryWithFilters(filters map[string]interfa
 	}
 
 	// Apply sorting
-	if sortBy != "" {
-		query = query.Order(sortBy + " " + sortOrder)
+	if column, ok := inventorySortColumns[sortBy]; ok {
+		if strings.EqualFold(sortOrder, "desc") {
+			column += " DESC"
+		}
+		query = query.Order(column)
+	} else {
+		query = query.Order("products.code")
 	}
 
 	// Apply pagination
@@ -128,3 +243,64 @@ func (r *inventoryRepository) GetInventoryWithFilters(filters map[string]interfa
 
 	return inventories, total, nil
 }
+
+// GetByProduct returns a product's stock in every shop that holds it, or in
+// one shop only
+func (r *inventoryRepository) GetByProduct(productID uuid.UUID, shopID *uuid.UUID) ([]entities.Inventory, error) {
+	var inventory []entities.Inventory
+
+	query := r.db.Preload("Shop").
+		Where("product_id = ? AND is_marked_to_delete = ?", productID, false)
+	if shopID != nil {
+		query = query.Where("shop_id = ?", *shopID)
+	}
+
+	err := query.Order("quantity DESC").Find(&inventory).Error
+	return inventory, err
+}
+
+// GetShopTotals sums the stock held by each shop or warehouse, valued at average cost and
+// at the selling price
+func (r *inventoryRepository) GetShopTotals(shopID *uuid.UUID) ([]entities.ShopStockTotal, error) {
+	var totals []entities.ShopStockTotal
+
+	query := r.db.Table("inventories").
+		Select(`inventories.shop_id,
+			shops.name AS shop_name,
+			shops.type AS location_type,
+			COUNT(*) FILTER (WHERE inventories.quantity > 0) AS product_count,
+			COALESCE(SUM(inventories.quantity), 0) AS total_quantity,
+			COALESCE(SUM(inventories.reserved_quantity), 0) AS total_reserved,
+			COALESCE(SUM(inventories.quantity * inventories.average_cost), 0) AS stock_value,
+			COALESCE(SUM(inventories.quantity * products.sales_price), 0) AS retail_value`).
+		Joins("JOIN products ON inventories.product_id = products.id AND products.deleted_at IS NULL").
+		Joins("JOIN shops ON shops.shop_id = inventories.shop_id").
+		Where("inventories.is_marked_to_delete = ?", false).
+		Group("inventories.shop_id, shops.name, shops.type").
+		Order("shops.name")
+	if shopID != nil {
+		query = query.Where("inventories.shop_id = ?", *shopID)
+	}
+
+	err := query.Scan(&totals).Error
+	return totals, err
+}
+
+// GetStockLevelSummary counts stock that is out, low, under its minimum or
+// over its maximum in one shop or every shop. threshold is the low stock
+// level for products without reorder settings.
+func (r *inventoryRepository) GetStockLevelSummary(shopID *uuid.UUID, threshold int) (*entities.StockLevelSummary, error) {
+	var summary entities.StockLevelSummary
+
+	query, args := stockLevelSummarySQL, []interface{}{threshold}
+	if shopID != nil {
+		query += " AND inventories.shop_id = ?"
+		args = append(args, *shopID)
+	}
+
+	err := r.db.Raw(query, args...).Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
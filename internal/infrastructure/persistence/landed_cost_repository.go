@@ -0,0 +1,188 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoAllocationBasis = errors.New("purchase lines have no value, quantity or weight to allocate the cost by")
+	ErrNoReceivedStock   = errors.New("no received stock to carry the landed cost into")
+)
+
+type LandedCostRepository interface {
+	GetByPurchaseID(purchaseInvoiceID uuid.UUID) ([]entities.LandedCost, error)
+	CreateAndAllocate(cost *entities.LandedCost) error
+	DeleteAndAllocate(purchaseInvoiceID, id uuid.UUID) error
+}
+
+type landedCostRepository struct {
+	db *gorm.DB
+}
+
+func NewLandedCostRepository(db *gorm.DB) LandedCostRepository {
+	return &landedCostRepository{
+		db: db,
+	}
+}
+
+func (r *landedCostRepository) GetByPurchaseID(purchaseInvoiceID uuid.UUID) ([]entities.LandedCost, error) {
+	var costs []entities.LandedCost
+	err := r.db.Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchaseInvoiceID, false).
+		Order("created_at").
+		Find(&costs).Error
+	return costs, err
+}
+
+// CreateAndAllocate adds a landed cost to a purchase and re-spreads all of
+// the purchase's landed costs over its lines
+func (r *landedCostRepository) CreateAndAllocate(cost *entities.LandedCost) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		purchase, err := lockPurchase(tx, cost.PurchaseInvoiceID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(cost).Error; err != nil {
+			return err
+		}
+		return reallocateLandedCosts(tx, purchase)
+	})
+}
+
+// DeleteAndAllocate removes a landed cost and re-spreads what is left
+func (r *landedCostRepository) DeleteAndAllocate(purchaseInvoiceID, id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var cost entities.LandedCost
+		err := tx.Where("id = ? AND purchase_invoice_id = ? AND is_marked_to_delete = ?", id, purchaseInvoiceID, false).
+			First(&cost).Error
+		if err != nil {
+			return err
+		}
+		purchase, err := lockPurchase(tx, cost.PurchaseInvoiceID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&cost).Update("is_marked_to_delete", true).Error; err != nil {
+			return err
+		}
+		return reallocateLandedCosts(tx, purchase)
+	})
+}
+
+func lockPurchase(tx *gorm.DB, purchaseInvoiceID uuid.UUID) (*entities.PurchaseInvoice, error) {
+	var purchase entities.PurchaseInvoice
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_marked_to_delete = ?", purchaseInvoiceID, false).
+		First(&purchase).Error
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// reallocateLandedCosts recomputes every line's landed cost and landed unit
+// cost from the purchase's current landed cost entries. Rounding is pushed
+// onto the last line so the allocations add up to the cost exactly.
+func reallocateLandedCosts(tx *gorm.DB, purchase *entities.PurchaseInvoice) error {
+	var details []entities.PurchaseDetail
+	if err := tx.Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).
+		Order("created_at").
+		Find(&details).Error; err != nil {
+		return err
+	}
+	if len(details) == 0 {
+		return nil
+	}
+
+	var costs []entities.LandedCost
+	if err := tx.Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).
+		Find(&costs).Error; err != nil {
+		return err
+	}
+
+	allocated := make([]float64, len(details))
+	for _, cost := range costs {
+		basis := make([]float64, len(details))
+		var basisTotal float64
+		for i, detail := range details {
+			switch cost.AllocationMethod {
+			case entities.AllocateByQuantity:
+				basis[i] = float64(detail.Quantity)
+			case entities.AllocateByWeight:
+				basis[i] = detail.Weight
+			default:
+				basis[i] = detail.PurchasePrice * float64(detail.Quantity)
+			}
+			basisTotal += basis[i]
+		}
+		if basisTotal <= 0 {
+			return ErrNoAllocationBasis
+		}
+
+		var spread float64
+		for i := range details {
+			share := math.Round(cost.Amount*basis[i]/basisTotal*100) / 100
+			if i == len(details)-1 {
+				share = cost.Amount - spread
+			}
+			spread += share
+			allocated[i] += share
+		}
+	}
+
+	for i, detail := range details {
+		landedUnitCost := detail.PurchasePrice
+		if detail.Quantity > 0 {
+			landedUnitCost += allocated[i] / float64(detail.Quantity)
+		}
+		err := tx.Model(&detail).Updates(map[string]interface{}{
+			"landed_cost":      allocated[i],
+			"landed_unit_cost": landedUnitCost,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Carry the new cost into stock still on hand from this line. Goods
+		// billed against an order came in on the order's goods receipts.
+		sources := []uuid.UUID{detail.ID}
+		if purchase.PurchaseOrderID != nil {
+			sources, err = receiptLineIDs(tx, *purchase.PurchaseOrderID, detail.ProductID)
+			if err != nil {
+				return err
+			}
+		}
+		var revalued int
+		for _, sourceID := range sources {
+			layers, err := revalueReceipt(tx, detail.ProductID, purchase.ShopID, sourceID, landedUnitCost)
+			if err != nil {
+				return err
+			}
+			revalued += layers
+		}
+		if revalued == 0 && allocated[i] != 0 {
+			return fmt.Errorf("%w: product %s", ErrNoReceivedStock, detail.ProductID)
+		}
+	}
+
+	return nil
+}
+
+// receiptLineIDs returns the goods receipt lines the product came in on
+// against the purchase order
+func receiptLineIDs(tx *gorm.DB, purchaseOrderID, productID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&entities.GoodsReceiptLine{}).
+		Joins("JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id").
+		Where("goods_receipts.purchase_order_id = ? AND goods_receipt_lines.product_id = ?", purchaseOrderID, productID).
+		Where("goods_receipts.is_marked_to_delete = ? AND goods_receipt_lines.is_marked_to_delete = ?", false, false).
+		Pluck("goods_receipt_lines.id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,158 @@
+package persistence
+
+import (
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	GetNotificationsWithFilters(userID uuid.UUID, filters map[string]interface{}, page, pageSize int) ([]entities.Notification, int64, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	MarkRead(id, userID uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) (int64, error)
+	CreateNotifications(notifications []entities.Notification) error
+	RecordDelivery(delivery *entities.NotificationDelivery) error
+	HasRecent(reference string, since time.Time) (bool, error)
+	GetRecipients(shopID *uuid.UUID) ([]entities.User, error)
+	GetUser(id uuid.UUID) (*entities.User, error)
+	GetPreferences(userIDs []uuid.UUID) ([]entities.NotificationPreference, error)
+	UpsertPreference(preference *entities.NotificationPreference) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+func (r *notificationRepository) GetNotificationsWithFilters(userID uuid.UUID, filters map[string]interface{}, page, pageSize int) ([]entities.Notification, int64, error) {
+	var notifications []entities.Notification
+	var total int64
+
+	query := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND is_marked_to_delete = ?", userID, false)
+
+	for field, value := range filters {
+		switch field {
+		case "type", "shop_id":
+			query = query.Where(field+" = ?", value)
+		case "unread":
+			query = query.Where("read_at IS NULL")
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	err := query.Preload("Deliveries").Order("created_at DESC").Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *notificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND is_marked_to_delete = ?", userID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications read. Notifications of
+// other users are not found.
+func (r *notificationRepository) MarkRead(id, userID uuid.UUID) error {
+	result := r.db.Model(&entities.Notification{}).
+		Where("id = ? AND user_id = ? AND is_marked_to_delete = ?", id, userID, false).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID uuid.UUID) (int64, error) {
+	result := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND is_marked_to_delete = ?", userID, false).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) CreateNotifications(notifications []entities.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.Create(&notifications).Error
+}
+
+func (r *notificationRepository) RecordDelivery(delivery *entities.NotificationDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// HasRecent tells whether an alert with the reference was raised since the
+// given time
+func (r *notificationRepository) HasRecent(reference string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.Notification{}).
+		Where("reference = ? AND created_at >= ?", reference, since).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetRecipients returns the active admins, and the active managers of the
+// shop when there is one
+func (r *notificationRepository) GetRecipients(shopID *uuid.UUID) ([]entities.User, error) {
+	var users []entities.User
+
+	query := r.db.Where("active = ? AND deleted_at IS NULL", true)
+	if shopID != nil {
+		query = query.Where("role = ? OR (role = ? AND shop_id = ?)", entities.RoleAdmin, entities.RoleManager, *shopID)
+	} else {
+		query = query.Where("role = ?", entities.RoleAdmin)
+	}
+
+	err := query.Find(&users).Error
+	return users, err
+}
+
+func (r *notificationRepository) GetUser(id uuid.UUID) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *notificationRepository) GetPreferences(userIDs []uuid.UUID) ([]entities.NotificationPreference, error) {
+	var preferences []entities.NotificationPreference
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+	err := r.db.Where("user_id IN ? AND is_marked_to_delete = ?", userIDs, false).
+		Find(&preferences).Error
+	return preferences, err
+}
+
+// UpsertPreference creates the user's preference for the type and channel
+// or replaces it
+func (r *notificationRepository) UpsertPreference(preference *entities.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "target", "is_marked_to_delete", "updated_at"}),
+	}).Create(preference).Error
+}
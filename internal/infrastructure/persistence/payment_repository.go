@@ -0,0 +1,324 @@
+package persistence
+
+import (
+	"errors"
+	"math"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAllocationExceedsPayment = errors.New("allocations exceed the unallocated payment amount")
+	ErrAllocationExceedsInvoice = errors.New("allocation exceeds the invoice's outstanding amount")
+	ErrInvoiceNotPayable        = errors.New("invoice is not a credit purchase from this supplier")
+	ErrCurrencyMismatch         = errors.New("payment and invoice are in different currencies")
+)
+
+// outstandingInvoicesSQL lists credit purchases with what has been returned
+// and paid against each of them
+const outstandingInvoicesSQL = `
+	SELECT pi.id AS purchase_invoice_id,
+		pi.supplier_id,
+		pi.purchase_date_time AS purchase_date_time,
+		pi.total,
+		pi.currency_code,
+		pi.exchange_rate,
+		COALESCE((
+			SELECT SUM(sr.debit_note_amount)
+			FROM supplier_returns sr
+			WHERE sr.purchase_invoice_id = pi.id AND sr.is_marked_to_delete = false
+		), 0) AS returned,
+		COALESCE((
+			SELECT SUM(pa.amount)
+			FROM payment_allocations pa
+			JOIN payments p ON p.id = pa.payment_id
+			WHERE pa.purchase_invoice_id = pi.id
+				AND pa.is_marked_to_delete = false
+				AND p.is_marked_to_delete = false
+		), 0) AS paid
+	FROM purchase_invoices pi
+	WHERE pi.is_marked_to_delete = false AND pi.payment_type = ?
+`
+
+type PaymentRepository interface {
+	BaseRepository[entities.Payment]
+	GetPaymentsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.Payment, int64, error)
+	GetWithAllocations(id uuid.UUID) (*entities.Payment, error)
+	CreateWithAllocations(payment *entities.Payment) error
+	AddAllocations(paymentID uuid.UUID, allocations []entities.PaymentAllocation) error
+	GetOutstandingInvoices(supplierID *uuid.UUID) ([]entities.OutstandingInvoice, error)
+	GetUnallocatedAmounts(supplierID *uuid.UUID) (map[uuid.UUID]float64, error)
+	GetFxSettlements(filters map[string]interface{}) ([]entities.FxSettlement, error)
+}
+
+type paymentRepository struct {
+	BaseRepositoryImpl[entities.Payment]
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.Payment]{DB: db},
+	}
+}
+
+func (r *paymentRepository) GetPaymentsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.Payment, int64, error) {
+	var payments []entities.Payment
+	var total int64
+
+	query := r.DB.Model(&entities.Payment{}).
+		Preload("Supplier").
+		Preload("Allocations", "is_marked_to_delete = ?", false).
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "type", "supplier_id", "customer_id":
+			query = query.Where(field+" = ?", value)
+		case "date_from":
+			query = query.Where("payment_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("payment_date_time <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&payments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return payments, total, nil
+}
+
+func (r *paymentRepository) GetWithAllocations(id uuid.UUID) (*entities.Payment, error) {
+	var payment entities.Payment
+	err := r.DB.Preload("Supplier").
+		Preload("Allocations", "is_marked_to_delete = ?", false).
+		Preload("Allocations.PurchaseInvoice").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CreateWithAllocations saves a supplier payment together with any
+// allocations given up front
+func (r *paymentRepository) CreateWithAllocations(payment *entities.Payment) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		allocations := payment.Allocations
+		payment.Allocations = nil
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		if err := allocate(tx, payment, allocations); err != nil {
+			return err
+		}
+		payment.Allocations = allocations
+		return nil
+	})
+}
+
+// AddAllocations allocates more of an existing payment to invoices
+func (r *paymentRepository) AddAllocations(paymentID uuid.UUID, allocations []entities.PaymentAllocation) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var payment entities.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_marked_to_delete = ?", paymentID, false).
+			First(&payment).Error
+		if err != nil {
+			return err
+		}
+
+		return allocate(tx, &payment, allocations)
+	})
+}
+
+// allocate checks each allocation against the payment's unallocated amount
+// and the invoice's outstanding amount before saving it. Allocations come in
+// the payment's currency; the invoice is cleared at its own rate and the
+// difference to what was paid is kept as the exchange gain or loss.
+func allocate(tx *gorm.DB, payment *entities.Payment, allocations []entities.PaymentAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	// Taka of the payment already used, at the payment's rate
+	var allocated float64
+	err := tx.Model(&entities.PaymentAllocation{}).
+		Where("payment_id = ? AND is_marked_to_delete = ?", payment.ID, false).
+		Select("COALESCE(SUM(amount - fx_gain_loss), 0)").
+		Scan(&allocated).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range allocations {
+		allocation := &allocations[i]
+		allocation.CurrencyAmount = allocation.Amount
+		paid := math.Round(allocation.CurrencyAmount*payment.ExchangeRate*100) / 100
+		allocated += paid
+		if allocated > payment.Amount+0.005 {
+			return ErrAllocationExceedsPayment
+		}
+
+		// Lock the invoice so two payments cannot settle the same balance
+		var invoice entities.PurchaseInvoice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_marked_to_delete = ?", allocation.PurchaseInvoiceID, false).
+			First(&invoice).Error
+		if err != nil {
+			return err
+		}
+		if payment.SupplierID == nil || invoice.SupplierID != *payment.SupplierID || invoice.PaymentType != entities.PaymentTypeCredit {
+			return ErrInvoiceNotPayable
+		}
+		if invoice.CurrencyCode != payment.CurrencyCode {
+			return ErrCurrencyMismatch
+		}
+
+		var outstanding []entities.OutstandingInvoice
+		err = tx.Raw(outstandingInvoicesSQL+" AND pi.id = ?", entities.PaymentTypeCredit, invoice.ID).
+			Scan(&outstanding).Error
+		if err != nil {
+			return err
+		}
+		if len(outstanding) == 0 {
+			return ErrInvoiceNotPayable
+		}
+		remaining := outstanding[0].Total - outstanding[0].Returned - outstanding[0].Paid
+		if allocation.CurrencyAmount > remaining/invoice.ExchangeRate+0.005 {
+			return ErrAllocationExceedsInvoice
+		}
+
+		// Settling the whole balance clears it exactly, whatever the rounding
+		allocation.Amount = math.Min(math.Round(allocation.CurrencyAmount*invoice.ExchangeRate*100)/100, remaining)
+		allocation.FxGainLoss = allocation.Amount - paid
+		allocation.PaymentID = payment.ID
+		if err := tx.Create(allocation).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetOutstandingInvoices returns credit purchases that still have a balance,
+// oldest first
+func (r *paymentRepository) GetOutstandingInvoices(supplierID *uuid.UUID) ([]entities.OutstandingInvoice, error) {
+	var invoices []entities.OutstandingInvoice
+
+	query := outstandingInvoicesSQL
+	args := []interface{}{entities.PaymentTypeCredit}
+	if supplierID != nil {
+		query += " AND pi.supplier_id = ?"
+		args = append(args, *supplierID)
+	}
+	query += " ORDER BY pi.purchase_date_time"
+
+	if err := r.DB.Raw(query, args...).Scan(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	outstanding := invoices[:0]
+	for _, invoice := range invoices {
+		invoice.Outstanding = invoice.Total - invoice.Returned - invoice.Paid
+		invoice.CurrencyOutstanding = math.Round(invoice.Outstanding/invoice.ExchangeRate*100) / 100
+		if invoice.Outstanding > 0.005 {
+			outstanding = append(outstanding, invoice)
+		}
+	}
+	return outstanding, nil
+}
+
+// GetUnallocatedAmounts returns, per supplier, how much has been paid but not
+// yet allocated to any invoice
+func (r *paymentRepository) GetUnallocatedAmounts(supplierID *uuid.UUID) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		SupplierID  uuid.UUID
+		Unallocated float64
+	}
+
+	query := r.DB.Table("payments p").
+		Select(`p.supplier_id,
+			SUM(p.amount - COALESCE((
+				SELECT SUM(pa.amount - pa.fx_gain_loss) FROM payment_allocations pa
+				WHERE pa.payment_id = p.id AND pa.is_marked_to_delete = false
+			), 0)) AS unallocated`).
+		Where("p.type = ? AND p.is_marked_to_delete = ?", entities.PaymentEntityTypeSupplier, false).
+		Group("p.supplier_id")
+	if supplierID != nil {
+		query = query.Where("p.supplier_id = ?", *supplierID)
+	}
+
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		result[row.SupplierID] = row.Unallocated
+	}
+	return result, nil
+}
+
+// GetFxSettlements lists the allocations of foreign currency payments with
+// the rates on both sides, oldest first
+func (r *paymentRepository) GetFxSettlements(filters map[string]interface{}) ([]entities.FxSettlement, error) {
+	var settlements []entities.FxSettlement
+
+	query := r.DB.Table("payment_allocations pa").
+		Select(`pa.id AS payment_allocation_id,
+			p.id AS payment_id,
+			p.payment_date_time AS payment_date_time,
+			pi.id AS purchase_invoice_id,
+			pi.purchase_date_time AS purchase_date_time,
+			p.supplier_id,
+			s.name AS supplier_name,
+			p.currency_code,
+			pa.currency_amount,
+			pi.exchange_rate AS invoice_rate,
+			p.exchange_rate AS payment_rate,
+			pa.amount AS booked_amount,
+			pa.amount - pa.fx_gain_loss AS paid_amount,
+			pa.fx_gain_loss AS gain_loss`).
+		Joins("JOIN payments p ON p.id = pa.payment_id").
+		Joins("JOIN purchase_invoices pi ON pi.id = pa.purchase_invoice_id").
+		Joins("LEFT JOIN suppliers s ON s.id = p.supplier_id").
+		Where("pa.is_marked_to_delete = ? AND p.is_marked_to_delete = ?", false, false).
+		Where("p.currency_code <> ?", entities.BaseCurrency)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "supplier_id", "currency_code":
+			query = query.Where("p."+field+" = ?", value)
+		case "date_from":
+			query = query.Where("p.payment_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("p.payment_date_time <= ?", value)
+		}
+	}
+
+	err := query.Order("p.payment_date_time").Scan(&settlements).Error
+	return settlements, err
+}
@@ -3,6 +3,7 @@ package persistence
 import (
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -10,6 +11,7 @@ type ProductRepository interface {
 	BaseRepository[entities.Product]
 	GetProductsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.Product, int64, error)
 	BulkCreate(products []entities.Product) error
+	GetByCodes(codes []string) ([]entities.Product, error)
 }
 
 type productRepository struct {
@@ -22,6 +24,17 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	}
 }
 
+// GetByID skips deleted products. Products are deleted through deleted_at
+// rather than is_marked_to_delete.
+func (r *productRepository) GetByID(id uuid.UUID) (*entities.Product, error) {
+	var product entities.Product
+	err := r.DB.Where("id = ? AND deleted_at IS NULL", id).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 func (r *productRepository) GetProductsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.Product, int64, error) {
 	var products []entities.Product
 	var total int64
@@ -31,7 +44,7 @@ func (r *productRepository) GetProductsWithFilters(filters map[string]interface{
 	// Apply filters
 	for field, value := range filters {
 		switch field {
-		case "code", "name", "style", "master_category", "sub_category", "color", "size", "sales_type":
+		case "code", "name", "style", "style_id", "master_category", "sub_category", "color", "size", "sales_type":
 			query = query.Where(field+" = ?", value)
 		case "min_purchase_price":
 			query = query.Where("purchase_price >= ?", value)
@@ -68,3 +81,12 @@ func (r *productRepository) GetProductsWithFilters(filters map[string]interface{
 func (r *productRepository) BulkCreate(products []entities.Product) error {
 	return r.DB.Create(&products).Error
 }
+
+func (r *productRepository) GetByCodes(codes []string) ([]entities.Product, error) {
+	var products []entities.Product
+	if len(codes) == 0 {
+		return products, nil
+	}
+	err := r.DB.Where("code IN ? AND deleted_at IS NULL", codes).Find(&products).Error
+	return products, err
+}
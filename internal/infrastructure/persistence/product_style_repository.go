@@ -0,0 +1,468 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStyleCodeTaken        = errors.New("style code is already used by another style")
+	ErrVariantCodeTaken      = errors.New("variant code is already used by another product")
+	ErrVariantExists         = errors.New("style already has a variant in this colour and size")
+	ErrVariantOfAnotherStyle = errors.New("product is a variant of another style")
+	ErrBlankColorOrSize      = errors.New("colours and sizes can't be blank")
+)
+
+type ProductStyleRepository interface {
+	GetByID(id uuid.UUID) (*entities.ProductStyle, error)
+	GetStylesWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.ProductStyle, int64, error)
+	CreateWithVariants(style *entities.ProductStyle, colors, sizes []string) error
+	UpdateWithVariants(style *entities.ProductStyle) error
+	GenerateVariants(id uuid.UUID, colors, sizes []string) ([]entities.Product, error)
+	AttachVariants(id uuid.UUID, productIDs []uuid.UUID) error
+	GetStockMatrix(id uuid.UUID, shopID *uuid.UUID) (*entities.StockMatrix, error)
+	GetStyleStock(filters map[string]interface{}) ([]entities.StyleStock, error)
+}
+
+type productStyleRepository struct {
+	db *gorm.DB
+}
+
+func NewProductStyleRepository(db *gorm.DB) ProductStyleRepository {
+	return &productStyleRepository{
+		db: db,
+	}
+}
+
+func (r *productStyleRepository) GetByID(id uuid.UUID) (*entities.ProductStyle, error) {
+	var style entities.ProductStyle
+	err := r.db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Where("deleted_at IS NULL").Order("color").Order("size")
+	}).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&style).Error
+	if err != nil {
+		return nil, err
+	}
+	return &style, nil
+}
+
+func (r *productStyleRepository) GetStylesWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.ProductStyle, int64, error) {
+	var styles []entities.ProductStyle
+	var total int64
+
+	query := r.db.Model(&entities.ProductStyle{}).
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "code", "master_category", "sub_category", "sales_type", "shop_id":
+			query = query.Where(field+" = ?", value)
+		case "name":
+			query = query.Where("name ILIKE ?", "%"+value.(string)+"%")
+		}
+	}
+
+	// Count total before pagination
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err := query.Order("code").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&styles).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return styles, total, nil
+}
+
+// CreateWithVariants saves the style and a variant for every combination of
+// the given colours and sizes
+func (r *productStyleRepository) CreateWithVariants(style *entities.ProductStyle, colors, sizes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&entities.ProductStyle{}).Where("code = ?", style.Code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrStyleCodeTaken
+		}
+		if err := tx.Create(style).Error; err != nil {
+			return err
+		}
+		variants, err := generateVariants(tx, style, colors, sizes)
+		style.Variants = variants
+		return err
+	})
+}
+
+// UpdateWithVariants saves the style's shared attributes and copies them to
+// every variant. The style code, and each variant's own code, colour and
+// size, stay as they are.
+func (r *productStyleRepository) UpdateWithVariants(style *entities.ProductStyle) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockStyle(tx, style.ID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(existing).Updates(map[string]interface{}{
+			"name":            style.Name,
+			"master_category": style.MasterCategory,
+			"sub_category":    style.SubCategory,
+			"purchase_price":  style.PurchasePrice,
+			"sales_price":     style.SalesPrice,
+			"sales_type":      style.SalesType,
+			"remarks":         style.Remarks,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&entities.Product{}).
+			Where("style_id = ? AND deleted_at IS NULL", existing.ID).
+			Updates(map[string]interface{}{
+				"name":            existing.Name,
+				"style":           existing.Code,
+				"master_category": existing.MasterCategory,
+				"sub_category":    existing.SubCategory,
+				"purchase_price":  existing.PurchasePrice,
+				"sales_price":     existing.SalesPrice,
+				"sales_type":      existing.SalesType,
+				"shop_id":         existing.ShopID,
+			}).Error
+		if err != nil {
+			return err
+		}
+		*style = *existing
+		return nil
+	})
+}
+
+// GenerateVariants adds the variants of a size by colour grid that the style
+// doesn't have yet and returns them
+func (r *productStyleRepository) GenerateVariants(id uuid.UUID, colors, sizes []string) ([]entities.Product, error) {
+	var variants []entities.Product
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		style, err := lockStyle(tx, id)
+		if err != nil {
+			return err
+		}
+		variants, err = generateVariants(tx, style, colors, sizes)
+		return err
+	})
+	return variants, err
+}
+
+// AttachVariants makes existing products variants of the style. They keep
+// their code, colour, size and stock, and take on the style's shared
+// attributes.
+func (r *productStyleRepository) AttachVariants(id uuid.UUID, productIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		style, err := lockStyle(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var products []entities.Product
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND deleted_at IS NULL", productIDs).
+			Find(&products).Error
+		if err != nil {
+			return err
+		}
+		if len(products) != len(productIDs) {
+			return gorm.ErrRecordNotFound
+		}
+
+		for i := range products {
+			product := &products[i]
+			if product.StyleID != nil {
+				if *product.StyleID == style.ID {
+					continue
+				}
+				return fmt.Errorf("%w: %s", ErrVariantOfAnotherStyle, product.Code)
+			}
+			if err := checkVariantFree(tx, style.ID, product.Color, product.Size); err != nil {
+				return err
+			}
+
+			style.Inherit(product)
+			err := tx.Model(product).Updates(map[string]interface{}{
+				"style_id":        product.StyleID,
+				"name":            product.Name,
+				"style":           product.Style,
+				"master_category": product.MasterCategory,
+				"sub_category":    product.SubCategory,
+				"purchase_price":  product.PurchasePrice,
+				"sales_price":     product.SalesPrice,
+				"sales_type":      product.SalesType,
+				"shop_id":         product.ShopID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetStockMatrix lays out the stock of a style's variants by colour and
+// size, in one shop or, without one, across every shop
+func (r *productStyleRepository) GetStockMatrix(id uuid.UUID, shopID *uuid.UUID) (*entities.StockMatrix, error) {
+	style, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	type variantStock struct {
+		ProductID uuid.UUID
+		Quantity  int
+		Reserved  int
+		Available int
+	}
+	var stock []variantStock
+	if len(style.Variants) > 0 {
+		productIDs := make([]uuid.UUID, 0, len(style.Variants))
+		for _, variant := range style.Variants {
+			productIDs = append(productIDs, variant.ID)
+		}
+		query := r.db.Model(&entities.Inventory{}).
+			Select("product_id, SUM(quantity) AS quantity, SUM(reserved_quantity) AS reserved, "+
+				"SUM(GREATEST(quantity - reserved_quantity, 0)) AS available").
+			Where("product_id IN ? AND is_marked_to_delete = ?", productIDs, false).
+			Group("product_id")
+		if shopID != nil {
+			query = query.Where("shop_id = ?", *shopID)
+		}
+		if err := query.Scan(&stock).Error; err != nil {
+			return nil, err
+		}
+	}
+	stockByProduct := make(map[uuid.UUID]variantStock, len(stock))
+	for _, s := range stock {
+		stockByProduct[s.ProductID] = s
+	}
+
+	matrix := &entities.StockMatrix{Style: style, ShopID: shopID}
+	variants := make(map[[2]string]entities.Product, len(style.Variants))
+	for _, variant := range style.Variants {
+		key := [2]string{variant.Color, variant.Size}
+		if _, seen := variants[key]; seen {
+			continue
+		}
+		variants[key] = variant
+		if !slices.Contains(matrix.Colors, variant.Color) {
+			matrix.Colors = append(matrix.Colors, variant.Color)
+		}
+		if !slices.Contains(matrix.Sizes, variant.Size) {
+			matrix.Sizes = append(matrix.Sizes, variant.Size)
+		}
+	}
+	sort.Strings(matrix.Colors)
+	sortSizes(matrix.Sizes)
+
+	matrix.SizeTotals = make([]int, len(matrix.Sizes))
+	for _, color := range matrix.Colors {
+		row := entities.StockMatrixRow{Color: color}
+		for i, size := range matrix.Sizes {
+			cell := entities.StockMatrixCell{Size: size}
+			if variant, ok := variants[[2]string{color, size}]; ok {
+				productID := variant.ID
+				s := stockByProduct[productID]
+				cell.ProductID = &productID
+				cell.Quantity = s.Quantity
+				cell.Reserved = s.Reserved
+				cell.Available = s.Available
+			}
+			row.Cells = append(row.Cells, cell)
+			row.Total += cell.Quantity
+			matrix.SizeTotals[i] += cell.Quantity
+		}
+		matrix.Rows = append(matrix.Rows, row)
+		matrix.Total += row.Total
+	}
+
+	return matrix, nil
+}
+
+// GetStyleStock adds up the stock of every style's variants, in one shop
+// when a shop_id filter is given
+func (r *productStyleRepository) GetStyleStock(filters map[string]interface{}) ([]entities.StyleStock, error) {
+	inventoryJoin := "LEFT JOIN inventories ON inventories.product_id = products.id AND inventories.is_marked_to_delete = false"
+	var joinArgs []interface{}
+	if shopID, ok := filters["shop_id"]; ok {
+		inventoryJoin += " AND inventories.shop_id = ?"
+		joinArgs = append(joinArgs, shopID)
+	}
+
+	query := r.db.Table("product_styles").
+		Select(`product_styles.id AS style_id,
+			product_styles.code,
+			product_styles.name,
+			product_styles.master_category,
+			COUNT(DISTINCT products.id) AS variants,
+			COALESCE(SUM(inventories.quantity), 0) AS total_quantity,
+			COALESCE(SUM(inventories.reserved_quantity), 0) AS total_reserved,
+			COALESCE(SUM(GREATEST(inventories.quantity - inventories.reserved_quantity, 0)), 0) AS available,
+			COALESCE(SUM(inventories.quantity * inventories.average_cost), 0) AS stock_value`).
+		Joins("JOIN products ON products.style_id = product_styles.id AND products.deleted_at IS NULL").
+		Joins(inventoryJoin, joinArgs...).
+		Where("product_styles.is_marked_to_delete = ?", false).
+		Group("product_styles.id, product_styles.code, product_styles.name, product_styles.master_category").
+		Order("total_quantity DESC")
+
+	for field, value := range filters {
+		switch field {
+		case "master_category", "sub_category":
+			query = query.Where("product_styles."+field+" = ?", value)
+		}
+	}
+
+	var stock []entities.StyleStock
+	err := query.Scan(&stock).Error
+	return stock, err
+}
+
+// lockStyle loads and locks a style so variants aren't generated for it twice
+// at once
+func lockStyle(tx *gorm.DB, id uuid.UUID) (*entities.ProductStyle, error) {
+	var style entities.ProductStyle
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&style).Error
+	if err != nil {
+		return nil, err
+	}
+	return &style, nil
+}
+
+// generateVariants creates a product for every colour and size the style
+// lacks, coded as the style code followed by the colour and size
+func generateVariants(tx *gorm.DB, style *entities.ProductStyle, colors, sizes []string) ([]entities.Product, error) {
+	for _, value := range append(slices.Clone(colors), sizes...) {
+		if strings.TrimSpace(value) == "" {
+			return nil, ErrBlankColorOrSize
+		}
+	}
+
+	var existing []entities.Product
+	err := tx.Where("style_id = ? AND deleted_at IS NULL", style.ID).
+		Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[[2]string]bool, len(existing))
+	for _, variant := range existing {
+		have[[2]string{strings.ToUpper(variant.Color), strings.ToUpper(variant.Size)}] = true
+	}
+
+	var variants []entities.Product
+	for _, color := range colors {
+		color = strings.TrimSpace(color)
+		for _, size := range sizes {
+			size = strings.TrimSpace(size)
+			key := [2]string{strings.ToUpper(color), strings.ToUpper(size)}
+			if have[key] {
+				continue
+			}
+			have[key] = true
+
+			variant := entities.Product{
+				Code:  variantCode(style.Code, color, size),
+				Color: color,
+				Size:  size,
+			}
+			style.Inherit(&variant)
+
+			var taken int64
+			if err := tx.Model(&entities.Product{}).Where("code = ?", variant.Code).Count(&taken).Error; err != nil {
+				return nil, err
+			}
+			if taken > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrVariantCodeTaken, variant.Code)
+			}
+			if err := tx.Create(&variant).Error; err != nil {
+				return nil, err
+			}
+			variants = append(variants, variant)
+		}
+	}
+	return variants, nil
+}
+
+// checkVariantFree makes sure a style has no variant in a colour and size yet
+func checkVariantFree(tx *gorm.DB, styleID uuid.UUID, color, size string) error {
+	var count int64
+	err := tx.Model(&entities.Product{}).
+		Where("style_id = ? AND UPPER(color) = UPPER(?) AND UPPER(size) = UPPER(?) AND deleted_at IS NULL", styleID, color, size).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s %s", ErrVariantExists, color, size)
+	}
+	return nil
+}
+
+// variantCode builds a variant's product code, such as TS100-NAVYBLUE-XL
+func variantCode(styleCode, color, size string) string {
+	part := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, s)
+	}
+	return styleCode + "-" + part(color) + "-" + part(size)
+}
+
+// garmentSizes is the order letter sizes are shown in
+var garmentSizes = []string{"XXS", "XS", "S", "M", "L", "XL", "XXL", "2XL", "XXXL", "3XL", "4XL", "5XL"}
+
+// sortSizes orders sizes the way they are shelved: letter sizes from small
+// to large, then numeric sizes by value, then anything else alphabetically
+func sortSizes(sizes []string) {
+	rank := func(size string) (int, float64) {
+		upper := strings.ToUpper(strings.TrimSpace(size))
+		for i, garmentSize := range garmentSizes {
+			if upper == garmentSize {
+				return 0, float64(i)
+			}
+		}
+		if value, err := strconv.ParseFloat(upper, 64); err == nil {
+			return 1, value
+		}
+		return 2, 0
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		groupI, valueI := rank(sizes[i])
+		groupJ, valueJ := rank(sizes[j])
+		if groupI != groupJ {
+			return groupI < groupJ
+		}
+		if valueI != valueJ {
+			return valueI < valueJ
+		}
+		return sizes[i] < sizes[j]
+	})
+}
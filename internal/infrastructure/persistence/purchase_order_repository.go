@@ -0,0 +1,212 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReceiptExceedsOrder = errors.New("received quantity exceeds the outstanding ordered quantity")
+	ErrUnknownOrderLine    = errors.New("receipt line does not belong to the purchase order")
+	ErrOrderNotReceiving   = errors.New("purchase order is not open for receiving")
+)
+
+type PurchaseOrderRepository interface {
+	BaseRepository[entities.PurchaseOrder]
+	GetPurchaseOrdersWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.PurchaseOrder, int64, error)
+	GetWithLines(id uuid.UUID) (*entities.PurchaseOrder, error)
+	CreateReceipt(receipt *entities.GoodsReceipt) error
+	GetReceipts(purchaseOrderID uuid.UUID) ([]entities.GoodsReceipt, error)
+	GetInvoices(purchaseOrderID uuid.UUID) ([]entities.PurchaseInvoice, error)
+}
+
+type purchaseOrderRepository struct {
+	BaseRepositoryImpl[entities.PurchaseOrder]
+}
+
+func NewPurchaseOrderRepository(db *gorm.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.PurchaseOrder]{DB: db},
+	}
+}
+
+func (r *purchaseOrderRepository) GetPurchaseOrdersWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.PurchaseOrder, int64, error) {
+	var orders []entities.PurchaseOrder
+	var total int64
+
+	query := r.DB.Model(&entities.PurchaseOrder{}).
+		Preload("Supplier").
+		Preload("Shop").
+		Preload("Lines").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "supplier_id", "shop_id", "status":
+			query = query.Where(field+" = ?", value)
+		case "date_from":
+			query = query.Where("order_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("order_date_time <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+func (r *purchaseOrderRepository) GetWithLines(id uuid.UUID) (*entities.PurchaseOrder, error) {
+	var order entities.PurchaseOrder
+	err := r.DB.Preload("Supplier").
+		Preload("Shop").
+		Preload("Lines").
+		Preload("Lines.Product").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CreateReceipt saves a goods receipt, adds the received quantities to the
+// order lines and the shop inventory, and moves the order to partially
+// received or closed. The order row is locked so concurrent receipts cannot
+// over-receive a line, and its status is checked under the lock so an order
+// closed meanwhile receives nothing.
+func (r *purchaseOrderRepository) CreateReceipt(receipt *entities.GoodsReceipt) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var order entities.PurchaseOrder
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_marked_to_delete = ?", receipt.PurchaseOrderID, false).
+			First(&order).Error
+		if err != nil {
+			return err
+		}
+		if order.Status != entities.PurchaseOrderStatusSent && order.Status != entities.PurchaseOrderStatusPartiallyReceived {
+			return ErrOrderNotReceiving
+		}
+
+		var lines []entities.PurchaseOrderLine
+		if err := tx.Where("purchase_order_id = ?", order.ID).Find(&lines).Error; err != nil {
+			return err
+		}
+		linesByID := make(map[uuid.UUID]*entities.PurchaseOrderLine, len(lines))
+		for i := range lines {
+			linesByID[lines[i].ID] = &lines[i]
+		}
+
+		receipt.ShopID = order.ShopID
+		for i := range receipt.Lines {
+			receiptLine := &receipt.Lines[i]
+			line, ok := linesByID[receiptLine.PurchaseOrderLineID]
+			if !ok {
+				return ErrUnknownOrderLine
+			}
+			if line.ReceivedQuantity+receiptLine.Quantity > line.OrderedQuantity {
+				return ErrReceiptExceedsOrder
+			}
+			line.ReceivedQuantity += receiptLine.Quantity
+			receiptLine.ProductID = line.ProductID
+		}
+
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+
+		for i := range receipt.Lines {
+			receiptLine := &receipt.Lines[i]
+			line := linesByID[receiptLine.PurchaseOrderLineID]
+			if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+				return err
+			}
+			if err := updateCatalogPrice(tx, order.SupplierID, line.ProductID, line.UnitPrice); err != nil {
+				return err
+			}
+			if err := checkSerials(tx, line.ProductID, receiptLine.Quantity, receiptLine.SerialNumbers); err != nil {
+				return err
+			}
+			movement := entities.StockMovementRef{
+				Type:          entities.MovementGoodsReceipt,
+				DocumentID:    receipt.ID,
+				UserID:        &receipt.ReceivedByID,
+				SerialNumbers: receiptLine.SerialNumbers,
+			}
+			lot, err := receiptLot(tx, line.ProductID, receiptLine.LotNumber, &order.SupplierID, movement)
+			if err != nil {
+				return err
+			}
+			if lot != nil {
+				receiptLine.LotID = &lot.ID
+				receiptLine.LotNumber = lot.LotNumber
+				movement.LotID = &lot.ID
+				err := tx.Model(receiptLine).Updates(map[string]interface{}{"lot_id": lot.ID, "lot_number": lot.LotNumber}).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			err = receiveStock(tx, line.ProductID, order.ShopID, receiptLine.Quantity, line.UnitPrice, entities.CostSourceGoodsReceipt, receiptLine.ID, movement)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Close the order once every line is fully received
+		status := entities.PurchaseOrderStatusClosed
+		for _, line := range lines {
+			if line.ReceivedQuantity < line.OrderedQuantity {
+				status = entities.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		updates := map[string]interface{}{"status": status}
+		if status == entities.PurchaseOrderStatusClosed {
+			updates["closed_at"] = time.Now()
+		}
+		return tx.Model(&order).Updates(updates).Error
+	})
+}
+
+func (r *purchaseOrderRepository) GetReceipts(purchaseOrderID uuid.UUID) ([]entities.GoodsReceipt, error) {
+	var receipts []entities.GoodsReceipt
+	err := r.DB.Preload("Lines").
+		Preload("Lines.Product").
+		Preload("ReceivedBy").
+		Where("purchase_order_id = ? AND is_marked_to_delete = ?", purchaseOrderID, false).
+		Order("received_date_time").
+		Find(&receipts).Error
+	return receipts, err
+}
+
+func (r *purchaseOrderRepository) GetInvoices(purchaseOrderID uuid.UUID) ([]entities.PurchaseInvoice, error) {
+	var invoices []entities.PurchaseInvoice
+	err := r.DB.Preload("PurchaseDetails", "is_marked_to_delete = ?", false).
+		Where("purchase_order_id = ? AND is_marked_to_delete = ?", purchaseOrderID, false).
+		Find(&invoices).Error
+	return invoices, err
+}
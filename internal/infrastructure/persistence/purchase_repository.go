@@ -1,14 +1,34 @@
-
 package persistence
 
 import (
+	"errors"
+	"time"
+
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrPurchaseHasReturns  = errors.New("purchase has supplier returns and cannot be changed")
+	ErrPurchaseHasPayments = errors.New("purchase has payments allocated to it and cannot be deleted")
+	ErrPurchaseLocked      = errors.New("purchase is locked and cannot be edited")
+	ErrPurchaseSettled     = errors.New("purchase is fully paid and cannot be edited")
+	ErrPurchaseBelowPaid   = errors.New("purchase total cannot be less than the amount already paid")
+	ErrUnknownPurchaseEdit = errors.New("edited line does not belong to the purchase")
+)
+
 type PurchaseRepository interface {
 	BaseRepository[entities.PurchaseInvoice]
 	GetPurchasesWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.PurchaseInvoice, int64, error)
+	GetWithDetails(id uuid.UUID) (*entities.PurchaseInvoice, error)
+	CreateWithStock(purchase *entities.PurchaseInvoice) error
+	DeleteWithStock(id uuid.UUID) error
+	GetPriceHistory(productIDs []uuid.UUID, since time.Time) ([]entities.PriceHistoryEntry, error)
+	UpdateWithStock(purchase *entities.PurchaseInvoice, editedByID uuid.UUID, reason string) error
+	Lock(id uuid.UUID) error
+	GetRevisions(id uuid.UUID) ([]entities.PurchaseRevision, error)
 }
 
 type purchaseRepository struct {
@@ -27,15 +47,17 @@ func (r *purchaseRepository) GetPurchasesWithFilters(filters map[string]interfac
 
 	query := r.DB.Model(&entities.PurchaseInvoice{}).
 		Preload("Supplier").
+		Preload("Shop").
 		Preload("EntryBy").
-		Preload("PurchaseDetails").
+		Preload("PurchaseDetails", "is_marked_to_delete = ?", false).
 		Preload("PurchaseDetails.Product").
+		Preload("SupplierReturns", "is_marked_to_delete = ?", false).
 		Where("is_marked_to_delete = ?", false)
 
 	// Apply filters
 	for field, value := range filters {
 		switch field {
-		case "supplier_id", "entry_by_id":
+		case "supplier_id", "shop_id", "entry_by_id":
 			query = query.Where(field+" = ?", value)
 		case "payment_type":
 			query = query.Where("payment_type = ?", value)
@@ -70,3 +92,412 @@ func (r *purchaseRepository) GetPurchasesWithFilters(filters map[string]interfac
 
 	return purchases, total, nil
 }
+
+func (r *purchaseRepository) GetWithDetails(id uuid.UUID) (*entities.PurchaseInvoice, error) {
+	var purchase entities.PurchaseInvoice
+	err := r.DB.Preload("Supplier").
+		Preload("Shop").
+		Preload("EntryBy").
+		Preload("PurchaseDetails", "is_marked_to_delete = ?", false).
+		Preload("PurchaseDetails.Product").
+		Preload("SupplierReturns", "is_marked_to_delete = ?", false).
+		Preload("LandedCosts", "is_marked_to_delete = ?", false).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&purchase).Error
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// priceHistorySQL lists every price paid for the given products. Invoices
+// billed against a purchase order are skipped because their goods, and
+// prices, already show up through the order's goods receipts.
+const priceHistorySQL = `
+	SELECT purchase_details.product_id, purchase_invoices.supplier_id, suppliers.name AS supplier_name,
+		'PURCHASE' AS source_type, purchase_invoices.id AS source_id,
+		purchase_invoices.purchase_date_time AS purchased_at,
+		purchase_details.quantity, purchase_details.purchase_price AS unit_price
+	FROM purchase_details
+	JOIN purchase_invoices ON purchase_invoices.id = purchase_details.purchase_invoice_id
+	JOIN suppliers ON suppliers.id = purchase_invoices.supplier_id
+	WHERE purchase_details.product_id IN (@products)
+		AND purchase_details.deleted_at IS NULL
+		AND purchase_details.is_marked_to_delete = false
+		AND purchase_invoices.is_marked_to_delete = false
+		AND purchase_invoices.purchase_order_id IS NULL
+		AND purchase_invoices.purchase_date_time >= @since
+	UNION ALL
+	SELECT goods_receipt_lines.product_id, purchase_orders.supplier_id, suppliers.name,
+		'GOODS_RECEIPT', goods_receipts.id,
+		goods_receipts.received_date_time,
+		goods_receipt_lines.quantity, purchase_order_lines.unit_price
+	FROM goods_receipt_lines
+	JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id
+	JOIN purchase_order_lines ON purchase_order_lines.id = goods_receipt_lines.purchase_order_line_id
+	JOIN purchase_orders ON purchase_orders.id = goods_receipts.purchase_order_id
+	JOIN suppliers ON suppliers.id = purchase_orders.supplier_id
+	WHERE goods_receipt_lines.product_id IN (@products)
+		AND goods_receipts.is_marked_to_delete = false
+		AND goods_receipts.received_date_time >= @since
+	ORDER BY purchased_at`
+
+// CreateWithStock saves the purchase with its details and receives every line
+// into the purchase's shop inventory in a single transaction. Invoices raised
+// against a purchase order leave stock alone.
+func (r *purchaseRepository) CreateWithStock(purchase *entities.PurchaseInvoice) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(purchase).Error; err != nil {
+			return err
+		}
+
+		for _, detail := range purchase.PurchaseDetails {
+			if err := updateCatalogPrice(tx, purchase.SupplierID, detail.ProductID, detail.PurchasePrice); err != nil {
+				return err
+			}
+		}
+
+		// Goods billed against a purchase order were already received
+		// through its goods receipts
+		if purchase.PurchaseOrderID != nil {
+			return nil
+		}
+
+		for i := range purchase.PurchaseDetails {
+			detail := &purchase.PurchaseDetails[i]
+			if err := checkSerials(tx, detail.ProductID, detail.Quantity, detail.SerialNumbers); err != nil {
+				return err
+			}
+			receipt := entities.StockMovementRef{
+				Type:          entities.MovementPurchase,
+				DocumentID:    purchase.ID,
+				UserID:        &purchase.EntryByID,
+				SerialNumbers: detail.SerialNumbers,
+			}
+			if err := receivePurchaseLot(tx, detail, purchase.SupplierID, &receipt); err != nil {
+				return err
+			}
+			err := receiveStock(tx, detail.ProductID, purchase.ShopID, detail.Quantity, receivedUnitCost(*detail), entities.CostSourcePurchase, detail.ID, receipt)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteWithStock soft deletes the purchase and takes its quantities back out
+// of the shop inventory. It fails with ErrInsufficientStock if any of the
+// received goods have already been sold or moved away.
+func (r *purchaseRepository) DeleteWithStock(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var purchase entities.PurchaseInvoice
+		err := tx.Preload("PurchaseDetails", "is_marked_to_delete = ?", false).
+			Where("id = ? AND is_marked_to_delete = ?", id, false).
+			First(&purchase).Error
+		if err != nil {
+			return err
+		}
+		if purchase.LockedAt != nil {
+			return ErrPurchaseLocked
+		}
+
+		var returns int64
+		err = tx.Model(&entities.SupplierReturn{}).
+			Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", id, false).
+			Count(&returns).Error
+		if err != nil {
+			return err
+		}
+		if returns > 0 {
+			return ErrPurchaseHasReturns
+		}
+
+		var allocations int64
+		err = tx.Model(&entities.PaymentAllocation{}).
+			Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", id, false).
+			Count(&allocations).Error
+		if err != nil {
+			return err
+		}
+		if allocations > 0 {
+			return ErrPurchaseHasPayments
+		}
+
+		if purchase.PurchaseOrderID == nil {
+			for _, detail := range purchase.PurchaseDetails {
+				err := reverseReceipt(tx, detail.ProductID, purchase.ShopID, detail.Quantity, receivedUnitCost(detail), detail.ID, entities.StockMovementRef{
+					Type:       entities.MovementPurchaseReversal,
+					DocumentID: purchase.ID,
+					LotID:      detail.LotID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Model(&entities.PurchaseInvoice{}).
+			Where("id = ?", id).
+			Update("is_marked_to_delete", true).Error
+	})
+}
+
+// GetPriceHistory returns the prices paid for the products since the given
+// time, oldest first
+func (r *purchaseRepository) GetPriceHistory(productIDs []uuid.UUID, since time.Time) ([]entities.PriceHistoryEntry, error) {
+	var history []entities.PriceHistoryEntry
+	if len(productIDs) == 0 {
+		return history, nil
+	}
+
+	err := r.DB.Raw(priceHistorySQL, map[string]interface{}{
+		"products": productIDs,
+		"since":    since,
+	}).Scan(&history).Error
+	return history, err
+}
+
+// UpdateWithStock replaces a purchase's header and lines with the given
+// version. The previous version is kept as a revision, and only the change
+// in each line's quantity and cost is applied to the shop's stock, so lines
+// whose goods were partly sold can still be corrected. Purchases that are
+// locked, have returns or are fully paid are refused.
+func (r *purchaseRepository) UpdateWithStock(purchase *entities.PurchaseInvoice, editedByID uuid.UUID, reason string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := lockPurchase(tx, purchase.ID)
+		if err != nil {
+			return err
+		}
+		if current.LockedAt != nil {
+			return ErrPurchaseLocked
+		}
+
+		var returns int64
+		err = tx.Model(&entities.SupplierReturn{}).
+			Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).
+			Count(&returns).Error
+		if err != nil {
+			return err
+		}
+		if returns > 0 {
+			return ErrPurchaseHasReturns
+		}
+
+		var paid float64
+		err = tx.Model(&entities.PaymentAllocation{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).
+			Scan(&paid).Error
+		if err != nil {
+			return err
+		}
+		if paid > 0 && paid >= current.Total {
+			return ErrPurchaseSettled
+		}
+		if paid > purchase.Total {
+			return ErrPurchaseBelowPaid
+		}
+
+		var details []entities.PurchaseDetail
+		if err := tx.Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).Find(&details).Error; err != nil {
+			return err
+		}
+
+		// Keep the version being replaced
+		var revisions int64
+		err = tx.Model(&entities.PurchaseRevision{}).
+			Where("purchase_invoice_id = ?", purchase.ID).
+			Count(&revisions).Error
+		if err != nil {
+			return err
+		}
+		revision := entities.PurchaseRevision{
+			PurchaseInvoiceID: current.ID,
+			Revision:          int(revisions) + 1,
+			SupplierID:        current.SupplierID,
+			PurchaseDateTime:  current.PurchaseDateTime,
+			Total:             current.Total,
+			PaymentType:       current.PaymentType,
+			Remarks:           current.Remarks,
+			EditedByID:        editedByID,
+			Reason:            reason,
+		}
+		for _, detail := range details {
+			revision.Lines = append(revision.Lines, entities.PurchaseRevisionLine{
+				PurchaseDetailID: detail.ID,
+				ProductID:        detail.ProductID,
+				Quantity:         detail.Quantity,
+				PurchasePrice:    detail.PurchasePrice,
+				Weight:           detail.Weight,
+			})
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(current).Updates(map[string]interface{}{
+			"supplier_id":      purchase.SupplierID,
+			"PurchaseDateTime": purchase.PurchaseDateTime,
+			"total":            purchase.Total,
+			"currency_total":   purchase.CurrencyTotal,
+			"payment_type":     purchase.PaymentType,
+			"remarks":          purchase.Remarks,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Goods billed against a purchase order came in through its goods
+		// receipts, so their stock is left alone
+		withStock := current.PurchaseOrderID == nil
+
+		existing := make(map[uuid.UUID]entities.PurchaseDetail, len(details))
+		for _, detail := range details {
+			existing[detail.ID] = detail
+		}
+
+		// Stock moved by the edit is put on the ledger against the editor
+		receipt := entities.StockMovementRef{Type: entities.MovementPurchase, DocumentID: current.ID, UserID: &editedByID}
+		reversal := entities.StockMovementRef{Type: entities.MovementPurchaseReversal, DocumentID: current.ID, UserID: &editedByID}
+
+		kept := make(map[uuid.UUID]bool, len(purchase.PurchaseDetails))
+		for i := range purchase.PurchaseDetails {
+			detail := &purchase.PurchaseDetails[i]
+			detail.PurchaseInvoiceID = current.ID
+
+			old, ok := existing[detail.ID]
+			if detail.ID != uuid.Nil && !ok {
+				return ErrUnknownPurchaseEdit
+			}
+
+			// A line moved to another product is treated as removed and re-added
+			if ok && old.ProductID != detail.ProductID {
+				if withStock {
+					if err := reverseReceipt(tx, old.ProductID, current.ShopID, old.Quantity, receivedUnitCost(old), old.ID, lineRef(reversal, old.LotID)); err != nil {
+						return err
+					}
+				}
+				if err := tx.Model(&old).Update("is_marked_to_delete", true).Error; err != nil {
+					return err
+				}
+				detail.ID = uuid.Nil
+				ok = false
+			}
+
+			if !ok {
+				detail.LandedUnitCost = detail.PurchasePrice
+				if err := tx.Create(detail).Error; err != nil {
+					return err
+				}
+				kept[detail.ID] = true
+				if withStock {
+					if err := checkSerials(tx, detail.ProductID, detail.Quantity, detail.SerialNumbers); err != nil {
+						return err
+					}
+					lineReceipt := serialRef(receipt, detail.SerialNumbers)
+					if err := receivePurchaseLot(tx, detail, purchase.SupplierID, &lineReceipt); err != nil {
+						return err
+					}
+					err := receiveStock(tx, detail.ProductID, current.ShopID, detail.Quantity, detail.PurchasePrice, entities.CostSourcePurchase, detail.ID, lineReceipt)
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			kept[detail.ID] = true
+			err := tx.Model(&old).Updates(map[string]interface{}{
+				"quantity":       detail.Quantity,
+				"purchase_price": detail.PurchasePrice,
+				"currency_price": detail.CurrencyPrice,
+				"weight":         detail.Weight,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			if !withStock {
+				continue
+			}
+
+			// Revalue what is left of the line at the new price, then move
+			// only the difference in quantity. Landed costs are spread again
+			// below.
+			if _, err := revalueReceipt(tx, old.ProductID, current.ShopID, old.ID, detail.PurchasePrice); err != nil {
+				return err
+			}
+			switch delta := detail.Quantity - old.Quantity; {
+			case delta > 0:
+				if err := checkSerials(tx, detail.ProductID, delta, detail.SerialNumbers); err != nil {
+					return err
+				}
+				err = receiveStock(tx, detail.ProductID, current.ShopID, delta, detail.PurchasePrice, entities.CostSourcePurchase, old.ID, serialRef(lineRef(receipt, old.LotID), detail.SerialNumbers))
+			case delta < 0:
+				err = reverseReceipt(tx, detail.ProductID, current.ShopID, -delta, detail.PurchasePrice, old.ID, serialRef(lineRef(reversal, old.LotID), detail.SerialNumbers))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, old := range details {
+			if kept[old.ID] {
+				continue
+			}
+			if withStock {
+				if err := reverseReceipt(tx, old.ProductID, current.ShopID, old.Quantity, receivedUnitCost(old), old.ID, lineRef(reversal, old.LotID)); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&old).Update("is_marked_to_delete", true).Error; err != nil {
+				return err
+			}
+		}
+
+		return reallocateLandedCosts(tx, current)
+	})
+}
+
+// Lock stops any further edits to the purchase. Locking it again keeps the
+// original lock time.
+func (r *purchaseRepository) Lock(id uuid.UUID) error {
+	result := r.DB.Model(&entities.PurchaseInvoice{}).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		Update("locked_at", gorm.Expr("COALESCE(locked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *purchaseRepository) GetRevisions(id uuid.UUID) ([]entities.PurchaseRevision, error) {
+	var revisions []entities.PurchaseRevision
+	err := r.DB.Preload("Lines").
+		Preload("EditedBy").
+		Where("purchase_invoice_id = ?", id).
+		Order("revision DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// receivePurchaseLot opens the lot a purchase line is received into and
+// points the line and its stock movement at it
+func receivePurchaseLot(tx *gorm.DB, detail *entities.PurchaseDetail, supplierID uuid.UUID, ref *entities.StockMovementRef) error {
+	lot, err := receiptLot(tx, detail.ProductID, detail.LotNumber, &supplierID, *ref)
+	if err != nil || lot == nil {
+		return err
+	}
+
+	detail.LotID = &lot.ID
+	detail.LotNumber = lot.LotNumber
+	ref.LotID = &lot.ID
+	return tx.Model(&entities.PurchaseDetail{}).
+		Where("id = ?", detail.ID).
+		Updates(map[string]interface{}{"lot_id": lot.ID, "lot_number": lot.LotNumber}).Error
+}
@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReplenishmentRepository interface {
+	GetRules(shopID uuid.UUID) ([]entities.ReorderRule, error)
+	UpsertRule(rule *entities.ReorderRule) error
+	UpsertRules(rules []entities.ReorderRule) error
+	GetCategoryDefaults(shopID uuid.UUID) ([]entities.CategoryReorderDefault, error)
+	UpsertCategoryDefault(categoryDefault *entities.CategoryReorderDefault) error
+	GetStockPositions(shopID uuid.UUID, soldSince time.Time) ([]StockPosition, error)
+	CreateDraftOrders(orders []entities.PurchaseOrder) error
+}
+
+// StockPosition is a product's stock, open orders, recent sales and reorder
+// settings in one shop
+type StockPosition struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductCode  string    `json:"product_code"`
+	ProductName  string    `json:"product_name"`
+	OnHand       int       `json:"on_hand"`
+	OnOrder      int       `json:"on_order"`
+	SoldQuantity int       `json:"sold_quantity"`
+	MinQuantity  int       `json:"min_quantity"`
+	MaxQuantity  int       `json:"max_quantity"`
+	ReorderPoint int       `json:"reorder_point"`
+}
+
+// stockPositionsSQL covers every product that has a reorder rule in the shop,
+// is stocked there under a category with a default, or has sold there
+// recently. A product's own rule wins over its category's default. Draft
+// orders count as on order so a second run does not suggest the same goods
+// again.
+const stockPositionsSQL = `
+	WITH stock AS (
+		SELECT product_id, SUM(quantity) AS quantity
+		FROM inventories
+		WHERE shop_id = @shop AND is_marked_to_delete = false
+		GROUP BY product_id
+	), on_order AS (
+		SELECT purchase_order_lines.product_id,
+			SUM(purchase_order_lines.ordered_quantity - purchase_order_lines.received_quantity) AS quantity
+		FROM purchase_order_lines
+		JOIN purchase_orders ON purchase_orders.id = purchase_order_lines.purchase_order_id
+		WHERE purchase_orders.shop_id = @shop
+			AND purchase_orders.is_marked_to_delete = false
+			AND purchase_orders.status IN ('DRAFT', 'SENT', 'PARTIALLY_RECEIVED')
+		GROUP BY purchase_order_lines.product_id
+	), sold AS (
+		SELECT sales_details.product_id, SUM(sales_details.quantity) AS quantity
+		FROM sales_details
+		JOIN sales_invoices ON sales_invoices.id = sales_details.invoice_id
+		WHERE sales_invoices.shop_id = @shop
+			AND sales_invoices.is_marked_to_delete = false
+			AND sales_invoices.sale_date_time >= @since
+		GROUP BY sales_details.product_id
+	)
+	SELECT products.id AS product_id, products.code AS product_code, products.name AS product_name,
+		COALESCE(stock.quantity, 0) AS on_hand,
+		COALESCE(on_order.quantity, 0) AS on_order,
+		COALESCE(sold.quantity, 0) AS sold_quantity,
+		CASE WHEN reorder_rules.id IS NOT NULL THEN reorder_rules.min_quantity ELSE COALESCE(category_reorder_defaults.min_quantity, 0) END AS min_quantity,
+		CASE WHEN reorder_rules.id IS NOT NULL THEN reorder_rules.max_quantity ELSE COALESCE(category_reorder_defaults.max_quantity, 0) END AS max_quantity,
+		CASE WHEN reorder_rules.id IS NOT NULL THEN reorder_rules.reorder_point ELSE COALESCE(category_reorder_defaults.reorder_point, 0) END AS reorder_point
+	FROM products
+	LEFT JOIN reorder_rules ON reorder_rules.product_id = products.id
+		AND reorder_rules.shop_id = @shop AND reorder_rules.is_marked_to_delete = false
+	LEFT JOIN category_reorder_defaults ON category_reorder_defaults.master_category = products.master_category
+		AND category_reorder_defaults.shop_id = @shop AND category_reorder_defaults.is_marked_to_delete = false
+	LEFT JOIN stock ON stock.product_id = products.id
+	LEFT JOIN on_order ON on_order.product_id = products.id
+	LEFT JOIN sold ON sold.product_id = products.id
+	WHERE products.deleted_at IS NULL
+		AND (reorder_rules.id IS NOT NULL OR sold.quantity > 0
+			OR (category_reorder_defaults.id IS NOT NULL AND stock.product_id IS NOT NULL))
+	ORDER BY products.code`
+
+// reorderRuleConflict replaces the limits of a product's existing rule in
+// the shop
+var reorderRuleConflict = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "product_id"}, {Name: "shop_id"}},
+	DoUpdates: clause.AssignmentColumns([]string{"min_quantity", "max_quantity", "reorder_point", "is_marked_to_delete", "updated_at"}),
+}
+
+type replenishmentRepository struct {
+	db *gorm.DB
+}
+
+func NewReplenishmentRepository(db *gorm.DB) ReplenishmentRepository {
+	return &replenishmentRepository{
+		db: db,
+	}
+}
+
+func (r *replenishmentRepository) GetRules(shopID uuid.UUID) ([]entities.ReorderRule, error) {
+	var rules []entities.ReorderRule
+	err := r.db.Preload("Product").
+		Where("shop_id = ? AND is_marked_to_delete = ?", shopID, false).
+		Find(&rules).Error
+	return rules, err
+}
+
+// UpsertRule creates the product's rule in the shop or replaces its limits
+func (r *replenishmentRepository) UpsertRule(rule *entities.ReorderRule) error {
+	return r.db.Clauses(reorderRuleConflict).Create(rule).Error
+}
+
+// UpsertRules saves the rules of a bulk import together so a failed import
+// changes nothing
+func (r *replenishmentRepository) UpsertRules(rules []entities.ReorderRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(reorderRuleConflict).CreateInBatches(rules, 500).Error
+	})
+}
+
+func (r *replenishmentRepository) GetCategoryDefaults(shopID uuid.UUID) ([]entities.CategoryReorderDefault, error) {
+	var defaults []entities.CategoryReorderDefault
+	err := r.db.Where("shop_id = ? AND is_marked_to_delete = ?", shopID, false).
+		Order("master_category").
+		Find(&defaults).Error
+	return defaults, err
+}
+
+// UpsertCategoryDefault creates the category's default in the shop or
+// replaces its limits
+func (r *replenishmentRepository) UpsertCategoryDefault(categoryDefault *entities.CategoryReorderDefault) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shop_id"}, {Name: "master_category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_quantity", "max_quantity", "reorder_point", "is_marked_to_delete", "updated_at"}),
+	}).Create(categoryDefault).Error
+}
+
+func (r *replenishmentRepository) GetStockPositions(shopID uuid.UUID, soldSince time.Time) ([]StockPosition, error) {
+	var positions []StockPosition
+	err := r.db.Raw(stockPositionsSQL, map[string]interface{}{
+		"shop":  shopID,
+		"since": soldSince,
+	}).Scan(&positions).Error
+	return positions, err
+}
+
+// CreateDraftOrders saves the purchase orders of one replenishment run
+// together so a failed run leaves no partial set of drafts behind
+func (r *replenishmentRepository) CreateDraftOrders(orders []entities.PurchaseOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range orders {
+			if err := tx.Create(&orders[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
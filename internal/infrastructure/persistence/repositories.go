@@ -4,31 +4,67 @@ import "gorm.io/gorm"
 
 // Repositories groups all repository instances
 type Repositories struct {
-	User      UserRepository
-	Product   ProductRepository
-	Sales     SalesRepository
-	Purchase  PurchaseRepository
-	Supplier  SupplierRepository
-	Company   CompanyRepository
-	Shop      ShopRepository
-	Auth      AuthRepository
-	Analytics AnalyticsRepository
-	Customer  CustomerRepository
-	Inventory InventoryRepository
+	User              UserRepository
+	Product           ProductRepository
+	Sales             SalesRepository
+	Purchase          PurchaseRepository
+	PurchaseOrder     PurchaseOrderRepository
+	LandedCost        LandedCostRepository
+	Supplier          SupplierRepository
+	SupplierProduct   SupplierProductRepository
+	SupplierReturn    SupplierReturnRepository
+	Payment           PaymentRepository
+	Currency          CurrencyRepository
+	SupplierScorecard SupplierScorecardRepository
+	Replenishment     ReplenishmentRepository
+	Company           CompanyRepository
+	Shop              ShopRepository
+	Auth              AuthRepository
+	Analytics         AnalyticsRepository
+	Customer          CustomerRepository
+	Inventory         InventoryRepository
+	StockMovement     StockMovementRepository
+	StockCount        StockCountRepository
+	StockAdjustment   StockAdjustmentRepository
+	StockReservation  StockReservationRepository
+	StockLot          StockLotRepository
+	SerialNumber      SerialNumberRepository
+	ProductStyle      ProductStyleRepository
+	Notification      NotificationRepository
+	StockTransfer     StockTransferRepository
+	Warehouse         WarehouseRepository
 }
 
 func NewRepositories(db *gorm.DB) *Repositories {
 	return &Repositories{
-		User:      NewUserRepository(db),
-		Product:   NewProductRepository(db),
-		Sales:     NewSalesRepository(db),
-		Purchase:  NewPurchaseRepository(db),
-		Supplier:  NewSupplierRepository(db),
-		Company:   NewCompanyRepository(db),
-		Shop:      NewShopRepository(db),
-		Auth:      NewAuthRepository(db),
-		Analytics: NewAnalyticsRepository(db),
-		Customer:  NewCustomerRepository(db),
-		Inventory: NewInventoryRepository(db),
+		User:              NewUserRepository(db),
+		Product:           NewProductRepository(db),
+		Sales:             NewSalesRepository(db),
+		Purchase:          NewPurchaseRepository(db),
+		PurchaseOrder:     NewPurchaseOrderRepository(db),
+		LandedCost:        NewLandedCostRepository(db),
+		Supplier:          NewSupplierRepository(db),
+		SupplierProduct:   NewSupplierProductRepository(db),
+		SupplierReturn:    NewSupplierReturnRepository(db),
+		Payment:           NewPaymentRepository(db),
+		Currency:          NewCurrencyRepository(db),
+		SupplierScorecard: NewSupplierScorecardRepository(db),
+		Replenishment:     NewReplenishmentRepository(db),
+		Company:           NewCompanyRepository(db),
+		Shop:              NewShopRepository(db),
+		Auth:              NewAuthRepository(db),
+		Analytics:         NewAnalyticsRepository(db),
+		Customer:          NewCustomerRepository(db),
+		Inventory:         NewInventoryRepository(db),
+		StockMovement:     NewStockMovementRepository(db),
+		StockCount:        NewStockCountRepository(db),
+		StockAdjustment:   NewStockAdjustmentRepository(db),
+		StockReservation:  NewStockReservationRepository(db),
+		StockLot:          NewStockLotRepository(db),
+		SerialNumber:      NewSerialNumberRepository(db),
+		ProductStyle:      NewProductStyleRepository(db),
+		Notification:      NewNotificationRepository(db),
+		StockTransfer:     NewStockTransferRepository(db),
+		Warehouse:         NewWarehouseRepository(db),
 	}
 }
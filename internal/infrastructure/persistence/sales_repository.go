@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"errors"
+	"math"
 	"time"
 
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
@@ -9,11 +11,16 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrUnknownMarginGrouping = errors.New("margins can be grouped by invoice, product, shop or style")
+
 type SalesRepository interface {
 	BaseRepository[entities.SalesInvoice]
 	GetSalesWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.SalesInvoice, int64, error)
 	GetSalesAnalytics(shopID *uuid.UUID, startDate, endDate time.Time) (*SalesAnalytics, error)
 	GetLast7DaysSales(shopID *uuid.UUID) ([]DailySales, error)
+	CreateWithStock(sale *entities.SalesInvoice) error
+	DeleteWithStock(id uuid.UUID) error
+	GetGrossMargins(groupBy string, filters map[string]interface{}) ([]entities.GrossMargin, error)
 }
 
 type SalesAnalytics struct {
@@ -166,3 +173,152 @@ func (r *salesRepository) GetLast7DaysSales(shopID *uuid.UUID) ([]DailySales, er
 	err := r.DB.Raw(query, startDate, today).Scan(&sales).Error
 	return sales, err
 }
+
+// CreateWithStock saves the sale and issues every line from the shop's
+// stock, recording each line's cost of goods sold under the company's
+// costing method. Lines may only sell stock that isn't reserved, apart from
+// the reservation they are sold from, which is fulfilled. Products that track
+// lots sell from the line's lot, or the oldest stock when it names none.
+// Products that track serial numbers name every unit sold, which starts its
+// warranty. Warehouses don't sell.
+func (r *salesRepository) CreateWithStock(sale *entities.SalesInvoice) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSellingLocation(tx, sale.ShopID); err != nil {
+			return err
+		}
+		if sale.ID == uuid.Nil {
+			sale.ID = uuid.New()
+		}
+		movement := entities.StockMovementRef{Type: entities.MovementSale, DocumentID: sale.ID, UserID: &sale.SalesByID}
+
+		sale.CostOfGoodsSold = 0
+		for i := range sale.SalesDetails {
+			detail := &sale.SalesDetails[i]
+			if detail.ReservationID != nil {
+				if err := fulfilReservation(tx, *detail.ReservationID, detail.ProductID, sale.ShopID); err != nil {
+					return err
+				}
+			}
+			if _, err := lockAvailableStock(tx, detail.ProductID, sale.ShopID, detail.Quantity); err != nil {
+				return err
+			}
+			if detail.LotID != nil {
+				if err := checkLot(tx, *detail.LotID, detail.ProductID); err != nil {
+					return err
+				}
+			}
+
+			if err := checkSerials(tx, detail.ProductID, detail.Quantity, detail.SerialNumbers); err != nil {
+				return err
+			}
+
+			cost, err := issueStock(tx, detail.ProductID, sale.ShopID, detail.Quantity, serialRef(lineRef(movement, detail.LotID), detail.SerialNumbers))
+			if err != nil {
+				return err
+			}
+			detail.CostOfGoodsSold = math.Round(cost*100) / 100
+			if detail.Quantity > 0 {
+				detail.UnitCost = cost / float64(detail.Quantity)
+			}
+			sale.CostOfGoodsSold += detail.CostOfGoodsSold
+		}
+
+		return tx.Create(sale).Error
+	})
+}
+
+// DeleteWithStock soft deletes the sale and returns its goods to stock at
+// the cost they were issued at
+func (r *salesRepository) DeleteWithStock(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var sale entities.SalesInvoice
+		err := tx.Preload("SalesDetails").
+			Where("id = ? AND is_marked_to_delete = ?", id, false).
+			First(&sale).Error
+		if err != nil {
+			return err
+		}
+
+		for _, detail := range sale.SalesDetails {
+			err := receiveStock(tx, detail.ProductID, sale.ShopID, detail.Quantity, detail.UnitCost, entities.CostSourceSaleReversal, detail.ID, entities.StockMovementRef{
+				Type:       entities.MovementSaleReturn,
+				DocumentID: sale.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&entities.SalesInvoice{}).
+			Where("id = ?", id).
+			Update("is_marked_to_delete", true).Error
+	})
+}
+
+// GetGrossMargins totals revenue and cost of goods sold per invoice, product
+// or shop. Invoice discounts are spread over the lines in proportion to
+// their subtotals.
+func (r *salesRepository) GetGrossMargins(groupBy string, filters map[string]interface{}) ([]entities.GrossMargin, error) {
+	var key, name string
+	switch groupBy {
+	case "invoice":
+		key = "sales_invoices.id::text"
+		name = "to_char(sales_invoices.sale_date_time, 'YYYY-MM-DD HH24:MI')"
+	case "product":
+		key = "products.id::text"
+		name = "products.name"
+	case "shop":
+		key = "shops.shop_id::text"
+		name = "shops.name"
+	case "style":
+		// Products that aren't variants of a style group by their style text
+		key = "COALESCE(product_styles.id::text, products.style)"
+		name = "COALESCE(product_styles.name, products.style)"
+	default:
+		return nil, ErrUnknownMarginGrouping
+	}
+
+	query := r.DB.Table("sales_details").
+		Select(key+" AS key, "+name+" AS name, "+
+			"SUM(sales_details.quantity) AS quantity, "+
+			"SUM(sales_details.subtotal * sales_invoices.total / NULLIF(sales_invoices.total + sales_invoices.discount, 0)) AS revenue, "+
+			"SUM(sales_details.cost_of_goods_sold) AS cost_of_goods_sold").
+		Joins("JOIN sales_invoices ON sales_invoices.id = sales_details.invoice_id").
+		Joins("JOIN products ON products.id = sales_details.product_id").
+		Joins("JOIN shops ON shops.shop_id = sales_invoices.shop_id").
+		Joins("LEFT JOIN product_styles ON product_styles.id = products.style_id").
+		Where("sales_invoices.is_marked_to_delete = ?", false).
+		Group(key + ", " + name).
+		Order("revenue DESC")
+
+	for field, value := range filters {
+		switch field {
+		case "shop_id":
+			query = query.Where("sales_invoices.shop_id = ?", value)
+		case "product_id":
+			query = query.Where("sales_details.product_id = ?", value)
+		case "style_id":
+			query = query.Where("products.style_id = ?", value)
+		case "date_from":
+			query = query.Where("sales_invoices.sale_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("sales_invoices.sale_date_time <= ?", value)
+		}
+	}
+
+	var margins []entities.GrossMargin
+	if err := query.Scan(&margins).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range margins {
+		margin := &margins[i]
+		margin.Revenue = math.Round(margin.Revenue*100) / 100
+		margin.GrossMargin = margin.Revenue - margin.CostOfGoodsSold
+		if margin.Revenue != 0 {
+			margin.MarginPercent = math.Round(margin.GrossMargin/margin.Revenue*10000) / 100
+		}
+	}
+
+	return margins, nil
+}
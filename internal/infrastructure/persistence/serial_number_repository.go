@@ -0,0 +1,379 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSerialsRequired    = errors.New("a serial number is needed for each unit of the product")
+	ErrSerialNotTracked   = errors.New("product does not track serial numbers")
+	ErrDuplicateSerial    = errors.New("serial number is given more than once")
+	ErrSerialNotInStock   = errors.New("serial number is not in stock at the shop")
+	ErrSerialInStock      = errors.New("serial number is already in stock")
+	ErrSerialsExceedStock = errors.New("more serial numbers than units on hand without one")
+)
+
+type SerialNumberRepository interface {
+	GetByID(id uuid.UUID) (*entities.SerialNumber, error)
+	GetSerialsWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.SerialNumber, int64, error)
+	Lookup(serialNumber string) ([]entities.SerialNumber, error)
+	Register(productID, shopID uuid.UUID, serialNumbers []string, userID uuid.UUID) ([]entities.SerialNumber, error)
+	SetSerialTracking(productID uuid.UUID, enabled bool, warrantyMonths *int) error
+}
+
+type serialNumberRepository struct {
+	db *gorm.DB
+}
+
+func NewSerialNumberRepository(db *gorm.DB) SerialNumberRepository {
+	return &serialNumberRepository{
+		db: db,
+	}
+}
+
+func (r *serialNumberRepository) GetByID(id uuid.UUID) (*entities.SerialNumber, error) {
+	var serial entities.SerialNumber
+	err := r.history(r.db).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&serial).Error
+	if err != nil {
+		return nil, err
+	}
+	return &serial, nil
+}
+
+func (r *serialNumberRepository) GetSerialsWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.SerialNumber, int64, error) {
+	var serials []entities.SerialNumber
+	var total int64
+
+	query := r.db.Model(&entities.SerialNumber{}).
+		Preload("Product").
+		Preload("Shop").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "product_id", "shop_id", "status", "sales_invoice_id":
+			query = query.Where(field+" = ?", value)
+		case "serial_number":
+			query = query.Where("serial_number ILIKE ?", "%"+value.(string)+"%")
+		case "warranty_expires_before":
+			query = query.Where("warranty_expires_at < ?", value)
+		}
+	}
+
+	// Count total before pagination
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err := query.Order("serial_number").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&serials).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return serials, total, nil
+}
+
+// Lookup finds a serial number across products, with the history of each
+// unit that carries it
+func (r *serialNumberRepository) Lookup(serialNumber string) ([]entities.SerialNumber, error) {
+	var serials []entities.SerialNumber
+	err := r.history(r.db).
+		Where("serial_number = ? AND is_marked_to_delete = ?", normalizeSerial(serialNumber), false).
+		Find(&serials).Error
+	return serials, err
+}
+
+// Register records the serial numbers of units a shop already held when its
+// product started tracking serial numbers, so they can be sold
+func (r *serialNumberRepository) Register(productID, shopID uuid.UUID, serialNumbers []string, userID uuid.UUID) ([]entities.SerialNumber, error) {
+	for i := range serialNumbers {
+		serialNumbers[i] = normalizeSerial(serialNumbers[i])
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSerials(tx, productID, len(serialNumbers), serialNumbers); err != nil {
+			return err
+		}
+
+		inventory, err := lockInventory(tx, productID, shopID)
+		if err != nil {
+			return err
+		}
+		var serialised int64
+		err = tx.Model(&entities.SerialNumber{}).
+			Where("product_id = ? AND shop_id = ? AND status = ? AND is_marked_to_delete = ?", productID, shopID, entities.SerialStatusInStock, false).
+			Count(&serialised).Error
+		if err != nil {
+			return err
+		}
+		if inventory == nil || int(serialised)+len(serialNumbers) > inventory.Quantity {
+			return ErrSerialsExceedStock
+		}
+
+		return moveSerials(tx, productID, shopID, len(serialNumbers), entities.StockMovementRef{
+			Type:          entities.MovementOpeningBalance,
+			UserID:        &userID,
+			SerialNumbers: serialNumbers,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var serials []entities.SerialNumber
+	err = r.db.Where("product_id = ? AND serial_number IN ?", productID, serialNumbers).
+		Order("serial_number").
+		Find(&serials).Error
+	return serials, err
+}
+
+// SetSerialTracking turns serial number tracking on or off for a product and
+// sets the warranty its units are sold with. Units on hand when tracking
+// starts need registering before they can be sold.
+func (r *serialNumberRepository) SetSerialTracking(productID uuid.UUID, enabled bool, warrantyMonths *int) error {
+	updates := map[string]interface{}{"track_serials": enabled}
+	if warrantyMonths != nil {
+		updates["warranty_months"] = *warrantyMonths
+	}
+	result := r.db.Model(&entities.Product{}).
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// history preloads what a serial lookup shows: where the unit is, who bought
+// it and every movement, oldest first
+func (r *serialNumberRepository) history(db *gorm.DB) *gorm.DB {
+	return db.Preload("Product").
+		Preload("Shop").
+		Preload("SalesInvoice").
+		Preload("SalesInvoice.Customer").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("moved_at").Order("created_at")
+		}).
+		Preload("Events.Shop").
+		Preload("Events.User")
+}
+
+// productTracksSerials reports whether a product follows its units by serial
+// number, and the months of warranty they are sold with
+func productTracksSerials(tx *gorm.DB, productID uuid.UUID) (bool, int, error) {
+	var product struct {
+		TrackSerials   bool
+		WarrantyMonths int
+	}
+	err := tx.Model(&entities.Product{}).
+		Select("track_serials", "warranty_months").
+		Where("id = ?", productID).
+		Scan(&product).Error
+	return product.TrackSerials, product.WarrantyMonths, err
+}
+
+// normalizeSerial is the form serial numbers are saved and looked up in:
+// trimmed and upper case, so SN1 and sn1 are the same unit
+func normalizeSerial(serialNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(serialNumber))
+}
+
+// checkSerials makes sure a document line names one serial number per unit
+// of a product that tracks them, and none for other products
+func checkSerials(tx *gorm.DB, productID uuid.UUID, quantity int, serialNumbers []string) error {
+	tracked, _, err := productTracksSerials(tx, productID)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		if len(serialNumbers) > 0 {
+			return ErrSerialNotTracked
+		}
+		return nil
+	}
+	if len(serialNumbers) != quantity {
+		return ErrSerialsRequired
+	}
+
+	seen := make(map[string]bool, len(serialNumbers))
+	for _, serial := range serialNumbers {
+		key := normalizeSerial(serial)
+		if seen[key] {
+			return fmt.Errorf("%w: %s", ErrDuplicateSerial, serial)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// serialRef is a document's movement reference narrowed to one line's units
+func serialRef(ref entities.StockMovementRef, serialNumbers []string) entities.StockMovementRef {
+	ref.SerialNumbers = serialNumbers
+	return ref
+}
+
+// moveSerials moves the units of a product that tracks serial numbers along
+// with a change of its stock in a shop.
+//
+// ref.SerialNumbers, when given, are exactly the units moved. Units received
+// for the first time are added to the register. Otherwise stock coming in
+// brings back the units the document took out, as when a sale is deleted or
+// a transfer arrives, and stock going out takes the units the document
+// brought into the shop. Other stock, such as adjustments, moves no units.
+func moveSerials(tx *gorm.DB, productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) error {
+	tracked, warrantyMonths, err := productTracksSerials(tx, productID)
+	if err != nil || !tracked || quantity == 0 {
+		return err
+	}
+
+	units := quantity
+	if units < 0 {
+		units = -units
+	}
+
+	var serials []entities.SerialNumber
+	switch {
+	case len(ref.SerialNumbers) > 0:
+		if len(ref.SerialNumbers) != units {
+			return ErrSerialsRequired
+		}
+		for _, number := range ref.SerialNumbers {
+			serial, err := lockSerial(tx, productID, shopID, normalizeSerial(number), quantity > 0)
+			if err != nil {
+				return err
+			}
+			serials = append(serials, *serial)
+		}
+	case ref.DocumentID == uuid.Nil:
+		return nil
+	case quantity > 0:
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND last_document_id = ? AND status <> ?", productID, ref.DocumentID, entities.SerialStatusInStock).
+			Order("serial_number").
+			Limit(units).
+			Find(&serials).Error
+	default:
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND last_document_id = ? AND status = ? AND shop_id = ?", productID, ref.DocumentID, entities.SerialStatusInStock, shopID).
+			Order("serial_number").
+			Limit(units).
+			Find(&serials).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range serials {
+		serial := &serials[i]
+		serial.ShopID = &shopID
+		if ref.DocumentID != uuid.Nil {
+			documentID := ref.DocumentID
+			serial.LastDocumentID = &documentID
+		} else {
+			serial.LastDocumentID = nil
+		}
+
+		switch {
+		case quantity > 0:
+			serial.Status = entities.SerialStatusInStock
+			serial.SalesInvoiceID = nil
+			serial.SoldAt = nil
+			serial.WarrantyExpiresAt = nil
+		case ref.Type == entities.MovementSale:
+			serial.Status = entities.SerialStatusSold
+			serial.SalesInvoiceID = serial.LastDocumentID
+			serial.SoldAt = &now
+			if warrantyMonths > 0 {
+				expiresAt := now.AddDate(0, warrantyMonths, 0)
+				serial.WarrantyExpiresAt = &expiresAt
+			}
+		case ref.Type == entities.MovementTransferOut:
+			serial.Status = entities.SerialStatusInTransit
+		case ref.Type == entities.MovementSupplierReturn:
+			serial.Status = entities.SerialStatusReturned
+		default:
+			serial.Status = entities.SerialStatusRemoved
+		}
+
+		err := tx.Model(serial).Select("shop_id", "last_document_id", "status", "sales_invoice_id", "sold_at", "warranty_expires_at").
+			Updates(serial).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&entities.SerialNumberEvent{
+			SerialNumberID: serial.ID,
+			ShopID:         shopID,
+			MovedAt:        now,
+			MovementType:   ref.Type,
+			DocumentID:     serial.LastDocumentID,
+			UserID:         ref.UserID,
+			Status:         serial.Status,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockSerial loads and locks a unit named on a document. A unit coming in
+// must not be in stock anywhere and is added to the register the first time
+// it is received; a unit going out must be in stock at the shop. number is
+// already normalized.
+func lockSerial(tx *gorm.DB, productID, shopID uuid.UUID, number string, incoming bool) (*entities.SerialNumber, error) {
+	if incoming {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "serial_number"}},
+			DoNothing: true,
+		}).Create(&entities.SerialNumber{
+			ProductID:    productID,
+			SerialNumber: number,
+			Status:       entities.SerialStatusRemoved,
+			ReceivedAt:   time.Now(),
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var serial entities.SerialNumber
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND serial_number = ? AND is_marked_to_delete = ?", productID, number, false).
+		First(&serial).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrSerialNotInStock, number)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case incoming && serial.Status == entities.SerialStatusInStock:
+		return nil, fmt.Errorf("%w: %s", ErrSerialInStock, number)
+	case !incoming && (serial.Status != entities.SerialStatusInStock || serial.ShopID == nil || *serial.ShopID != shopID):
+		return nil, fmt.Errorf("%w: %s", ErrSerialNotInStock, number)
+	}
+	return &serial, nil
+}
@@ -33,8 +33,8 @@ func (r *shopRepository) GetShopsWithFilters(filters map[string]interface{}, sor
 		switch field {
 		case "name", "phone", "email", "manager_name", "manager_phone":
 			query = query.Where(field+" LIKE ?", "%"+value.(string)+"%")
-		case "company_id":
-			query = query.Where("company_id = ?", value)
+		case "company_id", "type":
+			query = query.Where(field+" = ?", value)
 		}
 	}
 
@@ -0,0 +1,313 @@
+package persistence
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAdjustmentNotPending = errors.New("stock adjustment is not waiting for approval")
+
+type StockAdjustmentRepository interface {
+	BaseRepository[entities.StockAdjustment]
+	GetStockAdjustmentsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockAdjustment, int64, error)
+	EstimateValue(shopID uuid.UUID, lines []entities.StockAdjustmentLine) (float64, error)
+	CreateWithStock(adjustment *entities.StockAdjustment) error
+	Approve(id, approverID uuid.UUID) (*entities.StockAdjustment, error)
+	Reject(id, approverID uuid.UUID, reason string) (*entities.StockAdjustment, error)
+	GetShrinkage(filters map[string]interface{}) ([]entities.ShrinkageSummary, error)
+}
+
+type stockAdjustmentRepository struct {
+	BaseRepositoryImpl[entities.StockAdjustment]
+}
+
+func NewStockAdjustmentRepository(db *gorm.DB) StockAdjustmentRepository {
+	return &stockAdjustmentRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.StockAdjustment]{DB: db},
+	}
+}
+
+func (r *stockAdjustmentRepository) GetByID(id uuid.UUID) (*entities.StockAdjustment, error) {
+	var adjustment entities.StockAdjustment
+	err := r.DB.Preload("Shop").
+		Preload("CreatedBy").
+		Preload("ApprovedBy").
+		Preload("Lines").
+		Preload("Lines.Product").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&adjustment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+func (r *stockAdjustmentRepository) GetStockAdjustmentsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockAdjustment, int64, error) {
+	var adjustments []entities.StockAdjustment
+	var total int64
+
+	query := r.DB.Model(&entities.StockAdjustment{}).
+		Preload("Shop").
+		Preload("Lines").
+		Preload("Lines.Product").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "shop_id", "reason", "status", "created_by_id":
+			query = query.Where(field+" = ?", value)
+		case "date_from":
+			query = query.Where("adjustment_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("adjustment_date_time <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+	if len(sorts) == 0 {
+		query = query.Order("adjustment_date_time DESC")
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&adjustments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return adjustments, total, nil
+}
+
+// EstimateValue prices the lines at the shop's current average cost and
+// returns the total value of stock they move in either direction
+func (r *stockAdjustmentRepository) EstimateValue(shopID uuid.UUID, lines []entities.StockAdjustmentLine) (float64, error) {
+	var value float64
+	for _, line := range lines {
+		unitCost, err := adjustmentUnitCost(r.DB, line.ProductID, shopID)
+		if err != nil {
+			return 0, err
+		}
+		value += math.Abs(float64(line.Quantity)) * unitCost
+	}
+	return value, nil
+}
+
+// CreateWithStock saves the adjustment and, if it is already approved, moves
+// the stock in the same transaction
+func (r *stockAdjustmentRepository) CreateWithStock(adjustment *entities.StockAdjustment) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(adjustment).Error; err != nil {
+			return err
+		}
+		if adjustment.Status != entities.StockAdjustmentStatusApproved {
+			return nil
+		}
+		return applyAdjustment(tx, adjustment)
+	})
+}
+
+// Approve moves the stock of a pending adjustment. Lines are costed when they
+// are applied, not when the adjustment was raised.
+func (r *stockAdjustmentRepository) Approve(id, approverID uuid.UUID) (*entities.StockAdjustment, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		adjustment, err := lockPendingAdjustment(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("stock_adjustment_id = ?", adjustment.ID).Find(&adjustment.Lines).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		adjustment.Status = entities.StockAdjustmentStatusApproved
+		adjustment.ApprovedByID = &approverID
+		adjustment.ApprovedAt = &now
+		if err := applyAdjustment(tx, adjustment); err != nil {
+			return err
+		}
+
+		return tx.Model(adjustment).Updates(map[string]interface{}{
+			"status":         adjustment.Status,
+			"approved_by_id": approverID,
+			"approved_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Reject closes a pending adjustment without touching stock
+func (r *stockAdjustmentRepository) Reject(id, approverID uuid.UUID, reason string) (*entities.StockAdjustment, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		adjustment, err := lockPendingAdjustment(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(adjustment).Updates(map[string]interface{}{
+			"status":           entities.StockAdjustmentStatusRejected,
+			"approved_by_id":   approverID,
+			"approved_at":      time.Now(),
+			"rejection_reason": reason,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// GetShrinkage totals approved adjustments by shop and reason, keeping stock
+// lost apart from stock found
+func (r *stockAdjustmentRepository) GetShrinkage(filters map[string]interface{}) ([]entities.ShrinkageSummary, error) {
+	var rows []entities.ShrinkageSummary
+
+	query := r.DB.Table("stock_adjustments sa").
+		Select(`sa.shop_id, s.name AS shop_name, sa.reason,
+			COUNT(DISTINCT sa.id) AS adjustments,
+			COALESCE(SUM(CASE WHEN l.quantity < 0 THEN -l.quantity ELSE 0 END), 0) AS quantity_lost,
+			COALESCE(SUM(CASE WHEN l.quantity > 0 THEN l.quantity ELSE 0 END), 0) AS quantity_found,
+			COALESCE(SUM(CASE WHEN l.value < 0 THEN -l.value ELSE 0 END), 0) AS value_lost,
+			COALESCE(SUM(CASE WHEN l.value > 0 THEN l.value ELSE 0 END), 0) AS value_found,
+			COALESCE(SUM(l.value), 0) AS net_value`).
+		Joins("JOIN stock_adjustment_lines l ON l.stock_adjustment_id = sa.id").
+		Joins("JOIN shops s ON s.shop_id = sa.shop_id").
+		Where("sa.status = ? AND sa.is_marked_to_delete = ?", entities.StockAdjustmentStatusApproved, false)
+
+	for field, value := range filters {
+		switch field {
+		case "shop_id":
+			query = query.Where("sa.shop_id = ?", value)
+		case "reason":
+			query = query.Where("sa.reason = ?", value)
+		case "date_from":
+			query = query.Where("sa.adjustment_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("sa.adjustment_date_time < ?", value)
+		}
+	}
+
+	err := query.Group("sa.shop_id, s.name, sa.reason").
+		Order("value_lost DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// lockPendingAdjustment loads and locks an adjustment that is still waiting
+// for approval
+func lockPendingAdjustment(tx *gorm.DB, id uuid.UUID) (*entities.StockAdjustment, error) {
+	var adjustment entities.StockAdjustment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&adjustment).Error
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status != entities.StockAdjustmentStatusPending {
+		return nil, ErrAdjustmentNotPending
+	}
+	return &adjustment, nil
+}
+
+// applyAdjustment moves the stock of each line and records what it cost.
+// Stock taken out is costed under the company's costing method; stock found
+// comes in at the shop's average cost so it does not shift the average.
+// The ledger names the approver, or the creator of an adjustment approved
+// automatically.
+func applyAdjustment(tx *gorm.DB, adjustment *entities.StockAdjustment) error {
+	userID := adjustment.ApprovedByID
+	if userID == nil {
+		userID = &adjustment.CreatedByID
+	}
+	movement := entities.StockMovementRef{
+		Type:       entities.MovementAdjustment,
+		DocumentID: adjustment.ID,
+		UserID:     userID,
+	}
+
+	adjustment.Value = 0
+	for i := range adjustment.Lines {
+		line := &adjustment.Lines[i]
+
+		if line.Quantity < 0 {
+			cost, err := issueAdjustedStock(tx, adjustment, line.ProductID, -line.Quantity, movement)
+			if err != nil {
+				return err
+			}
+			line.UnitCost = cost / float64(-line.Quantity)
+			line.Value = -cost
+		} else {
+			unitCost, err := adjustmentUnitCost(tx, line.ProductID, adjustment.ShopID)
+			if err != nil {
+				return err
+			}
+			err = receiveStock(tx, line.ProductID, adjustment.ShopID, line.Quantity, unitCost, entities.CostSourceAdjustment, line.ID, movement)
+			if err != nil {
+				return err
+			}
+			line.UnitCost = unitCost
+			line.Value = unitCost * float64(line.Quantity)
+		}
+		line.Value = math.Round(line.Value*100) / 100
+		adjustment.Value += line.Value
+
+		err := tx.Model(line).Updates(map[string]interface{}{
+			"unit_cost": line.UnitCost,
+			"value":     line.Value,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(adjustment).Update("value", adjustment.Value).Error
+}
+
+// adjustmentUnitCost is the shop's average cost for a product, or its
+// purchase price if the shop has never costed it
+func adjustmentUnitCost(tx *gorm.DB, productID, shopID uuid.UUID) (float64, error) {
+	var inventory entities.Inventory
+	err := tx.Where("product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", productID, shopID, false).
+		First(&inventory).Error
+	if err == nil && inventory.AverageCost > 0 {
+		return inventory.AverageCost, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	var product entities.Product
+	if err := tx.Select("purchase_price").Where("id = ?", productID).First(&product).Error; err != nil {
+		return 0, err
+	}
+	return product.PurchasePrice, nil
+}
+
+// issueAdjustedStock writes stock off. Count corrections record what is
+// physically there, so they may take reserved stock; other adjustments leave
+// it alone.
+func issueAdjustedStock(tx *gorm.DB, adjustment *entities.StockAdjustment, productID uuid.UUID, quantity int, ref entities.StockMovementRef) (float64, error) {
+	if adjustment.Reason == entities.AdjustmentReasonCountCorrection {
+		return issueStock(tx, productID, adjustment.ShopID, quantity, ref)
+	}
+	return issueAvailableStock(tx, productID, adjustment.ShopID, quantity, ref)
+}
@@ -0,0 +1,456 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStockCountNotOpen   = errors.New("stock count is no longer open")
+	ErrProductOutsideCount = errors.New("product is not in the category being counted")
+	ErrNegativeCount       = errors.New("counted quantity cannot go below zero")
+)
+
+// StockCountEntryInput is a tally of one product by a counter
+type StockCountEntryInput struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
+type StockCountRepository interface {
+	BaseRepository[entities.StockCount]
+	GetStockCountsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockCount, int64, error)
+	Start(count *entities.StockCount) error
+	AddEntries(countID, countedByID uuid.UUID, device string, entries []StockCountEntryInput) error
+	GetVariance(id uuid.UUID) (*entities.StockCountVariance, error)
+	Approve(id, approverID uuid.UUID, zeroUncounted bool) (*entities.StockCount, error)
+	Cancel(id uuid.UUID) error
+}
+
+type stockCountRepository struct {
+	BaseRepositoryImpl[entities.StockCount]
+}
+
+func NewStockCountRepository(db *gorm.DB) StockCountRepository {
+	return &stockCountRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.StockCount]{DB: db},
+	}
+}
+
+func (r *stockCountRepository) GetByID(id uuid.UUID) (*entities.StockCount, error) {
+	var count entities.StockCount
+	err := r.DB.Preload("Shop").
+		Preload("StartedBy").
+		Preload("ApprovedBy").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("(SELECT code FROM products WHERE products.id = stock_count_lines.product_id)")
+		}).
+		Preload("Lines.Product").
+		Preload("Lines.Entries").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+func (r *stockCountRepository) GetStockCountsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockCount, int64, error) {
+	var counts []entities.StockCount
+	var total int64
+
+	query := r.DB.Model(&entities.StockCount{}).
+		Preload("Shop").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "shop_id", "status", "scope":
+			query = query.Where(field+" = ?", value)
+		case "date_from":
+			query = query.Where("started_at >= ?", value)
+		case "date_to":
+			query = query.Where("started_at <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+	if len(sorts) == 0 {
+		query = query.Order("started_at DESC")
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&counts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return counts, total, nil
+}
+
+// Start opens the count and snapshots the expected quantity, cost and retail
+// price of every product in scope that the shop holds
+func (r *stockCountRepository) Start(count *entities.StockCount) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		count.Status = entities.StockCountStatusOpen
+		count.StartedAt = time.Now()
+		if err := tx.Create(count).Error; err != nil {
+			return err
+		}
+
+		type snapshotRow struct {
+			ProductID   uuid.UUID
+			Quantity    int
+			AverageCost float64
+			Purchase    float64
+			SalesPrice  float64
+		}
+		var rows []snapshotRow
+		query := tx.Table("inventories").
+			Select("inventories.product_id, inventories.quantity, inventories.average_cost, products.purchase_price AS purchase, products.sales_price").
+			Joins("JOIN products ON products.id = inventories.product_id AND products.deleted_at IS NULL").
+			Where("inventories.shop_id = ? AND inventories.is_marked_to_delete = ?", count.ShopID, false)
+		query = countScope(query, count)
+		if err := query.Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		lines := make([]entities.StockCountLine, 0, len(rows))
+		for _, row := range rows {
+			unitCost := row.AverageCost
+			if unitCost <= 0 {
+				unitCost = row.Purchase
+			}
+			lines = append(lines, entities.StockCountLine{
+				StockCountID:     count.ID,
+				ProductID:        row.ProductID,
+				ExpectedQuantity: row.Quantity,
+				UnitCost:         unitCost,
+				RetailPrice:      row.SalesPrice,
+			})
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&lines, 200).Error; err != nil {
+			return err
+		}
+		count.Lines = lines
+		return nil
+	})
+}
+
+// AddEntries records a counter's tallies. Products found on the shelf that
+// were not in the snapshot join the count with the quantity the ledger held
+// for them when the count started.
+func (r *stockCountRepository) AddEntries(countID, countedByID uuid.UUID, device string, entries []StockCountEntryInput) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := lockOpenCount(tx, countID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, input := range entries {
+			var line entities.StockCountLine
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("stock_count_id = ? AND product_id = ?", count.ID, input.ProductID).
+				First(&line).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				newLine, err := addCountLine(tx, count, input.ProductID)
+				if err != nil {
+					return err
+				}
+				line = *newLine
+			} else if err != nil {
+				return err
+			}
+
+			counted := input.Quantity
+			if line.CountedQuantity != nil {
+				counted += *line.CountedQuantity
+			}
+			if counted < 0 {
+				return ErrNegativeCount
+			}
+
+			err = tx.Create(&entities.StockCountEntry{
+				StockCountLineID: line.ID,
+				CountedByID:      countedByID,
+				Device:           device,
+				Quantity:         input.Quantity,
+				CountedAt:        now,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&line).Updates(map[string]interface{}{
+				"counted_quantity": counted,
+				"last_counted_at":  now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetVariance compares every line with what the shop should have held when
+// it was counted. Lines of an open count use the movements so far.
+func (r *stockCountRepository) GetVariance(id uuid.UUID) (*entities.StockCountVariance, error) {
+	count, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	variance := &entities.StockCountVariance{
+		StockCountID: count.ID,
+		Status:       count.Status,
+		Lines:        []entities.StockCountVarianceLine{},
+	}
+	for _, line := range count.Lines {
+		moved := line.MovedDuringCount
+		if count.Status == entities.StockCountStatusOpen && line.LastCountedAt != nil {
+			moved, err = movedDuringCount(r.DB, count, line.ProductID, *line.LastCountedAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		addVarianceLine(variance, line, moved)
+	}
+	return variance, nil
+}
+
+// Approve posts the differences between counted and expected quantities as a
+// count correction adjustment. Each product is compared with what the shop
+// should have held when it was last counted, so sales made during the count
+// are not mistaken for shrinkage. Uncounted products are left alone unless
+// zeroUncounted is set.
+func (r *stockCountRepository) Approve(id, approverID uuid.UUID, zeroUncounted bool) (*entities.StockCount, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := lockOpenCount(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var lines []entities.StockCountLine
+		if err := tx.Where("stock_count_id = ?", count.ID).Find(&lines).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		adjustment := &entities.StockAdjustment{
+			ShopID:             count.ShopID,
+			AdjustmentDateTime: now,
+			Reason:             entities.AdjustmentReasonCountCorrection,
+			Notes:              fmt.Sprintf("Stock count started %s", count.StartedAt.Format("2006-01-02 15:04")),
+			Status:             entities.StockAdjustmentStatusApproved,
+			CreatedByID:        approverID,
+			ApprovedByID:       &approverID,
+			ApprovedAt:         &now,
+		}
+		for _, line := range lines {
+			countedAt := now
+			if line.LastCountedAt != nil {
+				countedAt = *line.LastCountedAt
+			}
+			counted := 0
+			if line.CountedQuantity != nil {
+				counted = *line.CountedQuantity
+			} else if !zeroUncounted {
+				continue
+			}
+
+			moved, err := movedDuringCount(tx, count, line.ProductID, countedAt)
+			if err != nil {
+				return err
+			}
+			updates := map[string]interface{}{"moved_during_count": moved}
+			if line.CountedQuantity == nil {
+				updates["counted_quantity"] = 0
+				updates["last_counted_at"] = now
+			}
+			if err := tx.Model(&line).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			if difference := counted - (line.ExpectedQuantity + moved); difference != 0 {
+				adjustment.Lines = append(adjustment.Lines, entities.StockAdjustmentLine{
+					ProductID: line.ProductID,
+					Quantity:  difference,
+				})
+			}
+		}
+
+		updates := map[string]interface{}{
+			"status":         entities.StockCountStatusApproved,
+			"approved_by_id": approverID,
+			"approved_at":    now,
+		}
+		if len(adjustment.Lines) > 0 {
+			if err := tx.Create(adjustment).Error; err != nil {
+				return err
+			}
+			if err := applyAdjustment(tx, adjustment); err != nil {
+				return err
+			}
+			updates["stock_adjustment_id"] = adjustment.ID
+		}
+
+		return tx.Model(count).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *stockCountRepository) Cancel(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := lockOpenCount(tx, id)
+		if err != nil {
+			return err
+		}
+		return tx.Model(count).Update("status", entities.StockCountStatusCancelled).Error
+	})
+}
+
+// lockOpenCount loads and locks a count that is still open for counting
+func lockOpenCount(tx *gorm.DB, id uuid.UUID) (*entities.StockCount, error) {
+	var count entities.StockCount
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != entities.StockCountStatusOpen {
+		return nil, ErrStockCountNotOpen
+	}
+	return &count, nil
+}
+
+// countScope limits a products query to the count's category
+func countScope(query *gorm.DB, count *entities.StockCount) *gorm.DB {
+	if count.Scope != entities.StockCountScopeCategory {
+		return query
+	}
+	query = query.Where("products.master_category = ?", count.Category)
+	if count.SubCategory != "" {
+		query = query.Where("products.sub_category = ?", count.SubCategory)
+	}
+	return query
+}
+
+// addCountLine adds a product that was not in the count's snapshot
+func addCountLine(tx *gorm.DB, count *entities.StockCount, productID uuid.UUID) (*entities.StockCountLine, error) {
+	var product entities.Product
+	query := tx.Where("products.id = ? AND products.deleted_at IS NULL", productID)
+	err := countScope(query, count).First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var exists int64
+		if err := tx.Model(&entities.Product{}).Where("id = ? AND deleted_at IS NULL", productID).Count(&exists).Error; err != nil {
+			return nil, err
+		}
+		if exists > 0 {
+			return nil, ErrProductOutsideCount
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var expected int
+	err = tx.Model(&entities.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND shop_id = ? AND moved_at < ?", productID, count.ShopID, count.StartedAt).
+		Scan(&expected).Error
+	if err != nil {
+		return nil, err
+	}
+
+	unitCost, err := adjustmentUnitCost(tx, productID, count.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &entities.StockCountLine{
+		StockCountID:     count.ID,
+		ProductID:        productID,
+		ExpectedQuantity: expected,
+		UnitCost:         unitCost,
+		RetailPrice:      product.SalesPrice,
+	}
+	if err := tx.Create(line).Error; err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// movedDuringCount is the net stock movement of a product in the shop from
+// the start of the count until it was counted
+func movedDuringCount(tx *gorm.DB, count *entities.StockCount, productID uuid.UUID, until time.Time) (int, error) {
+	var moved int
+	err := tx.Model(&entities.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND shop_id = ? AND moved_at >= ? AND moved_at <= ?", productID, count.ShopID, count.StartedAt, until).
+		Scan(&moved).Error
+	return moved, err
+}
+
+func addVarianceLine(variance *entities.StockCountVariance, line entities.StockCountLine, moved int) {
+	varianceLine := entities.StockCountVarianceLine{
+		ProductID:        line.ProductID,
+		ExpectedQuantity: line.ExpectedQuantity,
+		MovedDuringCount: moved,
+		CountedQuantity:  line.CountedQuantity,
+	}
+	if line.Product != nil {
+		varianceLine.Code = line.Product.Code
+		varianceLine.Name = line.Product.Name
+	}
+
+	if line.CountedQuantity == nil {
+		variance.LinesUncounted++
+		variance.Lines = append(variance.Lines, varianceLine)
+		return
+	}
+	variance.LinesCounted++
+
+	varianceLine.Variance = *line.CountedQuantity - (line.ExpectedQuantity + moved)
+	varianceLine.VarianceAtCost = math.Round(float64(varianceLine.Variance)*line.UnitCost*100) / 100
+	varianceLine.VarianceAtRetail = math.Round(float64(varianceLine.Variance)*line.RetailPrice*100) / 100
+	variance.Lines = append(variance.Lines, varianceLine)
+
+	variance.TotalAtCost += varianceLine.VarianceAtCost
+	variance.TotalAtRetail += varianceLine.VarianceAtRetail
+	if varianceLine.Variance < 0 {
+		variance.ShortageAtCost -= varianceLine.VarianceAtCost
+		variance.ShortageAtRetail -= varianceLine.VarianceAtRetail
+	} else {
+		variance.SurplusAtCost += varianceLine.VarianceAtCost
+		variance.SurplusAtRetail += varianceLine.VarianceAtRetail
+	}
+}
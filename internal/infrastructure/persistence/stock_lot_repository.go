@@ -0,0 +1,436 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientLotStock matches ErrInsufficientStock as well, so callers
+// that only care about running out of stock needn't tell the two apart
+var (
+	ErrInsufficientLotStock = fmt.Errorf("%w in the lot", ErrInsufficientStock)
+	ErrLotNotTracked        = errors.New("product does not track lots")
+	ErrLotProductMismatch   = errors.New("lot belongs to another product")
+)
+
+type StockLotRepository interface {
+	GetByID(id uuid.UUID) (*entities.StockLot, error)
+	GetLotsWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.StockLot, int64, error)
+	GetLotTrace(id uuid.UUID) (*entities.LotTrace, error)
+	SetLotTracking(productID uuid.UUID, enabled bool) error
+}
+
+type stockLotRepository struct {
+	db *gorm.DB
+}
+
+func NewStockLotRepository(db *gorm.DB) StockLotRepository {
+	return &stockLotRepository{
+		db: db,
+	}
+}
+
+func (r *stockLotRepository) GetByID(id uuid.UUID) (*entities.StockLot, error) {
+	var lot entities.StockLot
+	err := r.db.Preload("Product").
+		Preload("Supplier").
+		Preload("Stock", "quantity > 0").
+		Preload("Stock.Shop").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetLotsWithFilters lists lots, oldest first so the lot FIFO would pick
+// comes first. With a shop_id filter only the stock held by that shop is
+// loaded, and in_stock leaves out lots the shop has none of.
+func (r *stockLotRepository) GetLotsWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.StockLot, int64, error) {
+	var lots []entities.StockLot
+	var total int64
+
+	query := r.db.Model(&entities.StockLot{}).
+		Preload("Supplier").
+		Where("stock_lots.is_marked_to_delete = ?", false)
+
+	shopID, scoped := filters["shop_id"]
+	if scoped {
+		query = query.Preload("Stock", "shop_id = ?", shopID)
+	} else {
+		query = query.Preload("Stock", "quantity > 0")
+	}
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "product_id", "supplier_id":
+			query = query.Where("stock_lots."+field+" = ?", value)
+		case "lot_number":
+			query = query.Where("stock_lots.lot_number ILIKE ?", "%"+value.(string)+"%")
+		case "in_stock":
+			stock := r.db.Table("lot_inventories").
+				Select("1").
+				Where("lot_inventories.lot_id = stock_lots.id AND lot_inventories.quantity > 0")
+			if scoped {
+				stock = stock.Where("lot_inventories.shop_id = ?", shopID)
+			}
+			query = query.Where("EXISTS (?)", stock)
+		}
+	}
+
+	// Count total before pagination
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err := query.Order("stock_lots.received_at").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&lots).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lots, total, nil
+}
+
+// GetLotTrace follows a lot from the receipts that brought it in to every
+// invoice that sold it
+func (r *stockLotRepository) GetLotTrace(id uuid.UUID) (*entities.LotTrace, error) {
+	lot, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &entities.LotTrace{Lot: lot, Stock: lot.Stock}
+	for _, stock := range lot.Stock {
+		trace.OnHand += stock.Quantity
+	}
+
+	err = r.db.Preload("Shop").
+		Where("lot_id = ?", id).
+		Order("moved_at").
+		Order("created_at").
+		Find(&trace.Movements).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, movement := range trace.Movements {
+		switch movement.MovementType {
+		case entities.MovementPurchase, entities.MovementGoodsReceipt,
+			entities.MovementPurchaseReversal, entities.MovementSupplierReturn:
+			trace.Received += movement.Quantity
+		}
+	}
+
+	err = r.db.Table("stock_lot_movements m").
+		Select(`m.document_id AS invoice_id,
+			s.sale_date_time AS sale_date_time,
+			m.shop_id,
+			shops.name AS shop_name,
+			s.customer_id,
+			COALESCE(customers.name, '') AS customer_name,
+			-SUM(m.quantity) AS quantity`).
+		Joins("JOIN sales_invoices s ON s.id = m.document_id").
+		Joins("JOIN shops ON shops.shop_id = m.shop_id").
+		Joins("LEFT JOIN customers ON customers.id = s.customer_id").
+		Where("m.lot_id = ? AND m.movement_type IN ?", id, []entities.StockMovementType{entities.MovementSale, entities.MovementSaleReturn}).
+		Group("m.document_id, s.sale_date_time, m.shop_id, shops.name, s.customer_id, customers.name").
+		Having("SUM(m.quantity) <> 0").
+		Order("s.sale_date_time").
+		Scan(&trace.Sales).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, sale := range trace.Sales {
+		trace.Sold += sale.Quantity
+	}
+
+	return trace, nil
+}
+
+// SetLotTracking turns lot tracking on or off for a product. Stock already on
+// hand when tracking starts is in no lot and is sold first.
+func (r *stockLotRepository) SetLotTracking(productID uuid.UUID, enabled bool) error {
+	result := r.db.Model(&entities.Product{}).
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Update("track_lots", enabled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// productTracksLots reports whether a product keeps its stock per lot
+func productTracksLots(tx *gorm.DB, productID uuid.UUID) (bool, error) {
+	var trackLots bool
+	err := tx.Model(&entities.Product{}).
+		Select("track_lots").
+		Where("id = ?", productID).
+		Scan(&trackLots).Error
+	return trackLots, err
+}
+
+// receiptLot finds or opens the lot goods are received into, or returns nil
+// for a product that doesn't track lots. Goods received without a lot number
+// get one made from the receipt date and document.
+func receiptLot(tx *gorm.DB, productID uuid.UUID, lotNumber string, supplierID *uuid.UUID, ref entities.StockMovementRef) (*entities.StockLot, error) {
+	tracked, err := productTracksLots(tx, productID)
+	if err != nil || !tracked {
+		return nil, err
+	}
+
+	lotNumber = strings.TrimSpace(lotNumber)
+	if lotNumber == "" {
+		lotNumber = fmt.Sprintf("%s-%s", time.Now().Format("20060102"), strings.ToUpper(ref.DocumentID.String()[:8]))
+	}
+
+	lot := entities.StockLot{
+		ProductID:  productID,
+		LotNumber:  lotNumber,
+		ReceivedAt: time.Now(),
+		SupplierID: supplierID,
+		SourceType: ref.Type,
+	}
+	if ref.DocumentID != uuid.Nil {
+		documentID := ref.DocumentID
+		lot.SourceID = &documentID
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "lot_number"}},
+		DoNothing: true,
+	}).Create(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The lot may have been opened by an earlier receipt
+	var existing entities.StockLot
+	err = tx.Where("product_id = ? AND lot_number = ?", productID, lotNumber).
+		First(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// lineRef is a document's movement reference narrowed to one line's lot
+func lineRef(ref entities.StockMovementRef, lotID *uuid.UUID) entities.StockMovementRef {
+	ref.LotID = lotID
+	return ref
+}
+
+// checkLot makes sure a lot chosen for a sale or transfer is one of the
+// product's lots
+func checkLot(tx *gorm.DB, lotID, productID uuid.UUID) error {
+	tracked, err := productTracksLots(tx, productID)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		return ErrLotNotTracked
+	}
+
+	var lot entities.StockLot
+	if err := tx.Where("id = ?", lotID).First(&lot).Error; err != nil {
+		return err
+	}
+	if lot.ProductID != productID {
+		return ErrLotProductMismatch
+	}
+	return nil
+}
+
+// lotPick is stock taken from or put into one lot
+type lotPick struct {
+	LotID    uuid.UUID
+	Quantity int
+}
+
+// moveLots applies a change of quantity to the lots of a product that tracks
+// them. The inventory row is locked by the caller and before is its quantity
+// ahead of the change.
+//
+// Stock coming in goes into ref.LotID, or back into the lots the document
+// took it out of, as when a sale is returned or a transfer arrives. Anything
+// else stays out of lots.
+//
+// Stock going out must come out of ref.LotID when one is given. Otherwise it
+// leaves from the lots the document brought into the shop, then from stock
+// in no lot, which is the oldest, and then from the oldest lots.
+func moveLots(tx *gorm.DB, productID, shopID uuid.UUID, before, quantity int, ref entities.StockMovementRef) error {
+	tracked, err := productTracksLots(tx, productID)
+	if err != nil || !tracked || quantity == 0 {
+		return err
+	}
+
+	if ref.LotID != nil {
+		return changeLot(tx, *ref.LotID, productID, shopID, quantity, ref)
+	}
+
+	if quantity > 0 {
+		taken, err := documentLots(tx, ref.DocumentID, productID, nil)
+		if err != nil {
+			return err
+		}
+		for _, pick := range limitPicks(taken, quantity) {
+			if err := changeLot(tx, pick.LotID, productID, shopID, pick.Quantity, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	remaining := -quantity
+	brought, err := documentLots(tx, ref.DocumentID, productID, &shopID)
+	if err != nil {
+		return err
+	}
+	for _, pick := range limitPicks(brought, remaining) {
+		if err := changeLot(tx, pick.LotID, productID, shopID, -pick.Quantity, ref); err != nil {
+			return err
+		}
+		remaining -= pick.Quantity
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	var stock []entities.LotInventory
+	err = tx.Table("lot_inventories").
+		Select("lot_inventories.*").
+		Joins("JOIN stock_lots ON stock_lots.id = lot_inventories.lot_id").
+		Where("lot_inventories.product_id = ? AND lot_inventories.shop_id = ?", productID, shopID).
+		Order("stock_lots.received_at").
+		Order("stock_lots.lot_number").
+		Find(&stock).Error
+	if err != nil {
+		return err
+	}
+
+	// Taking the picks above out of before leaves what is in no lot
+	inLots := 0
+	for _, lot := range stock {
+		inLots += lot.Quantity
+	}
+	unlotted := before - (-quantity - remaining) - inLots
+	if unlotted > 0 {
+		remaining -= min(unlotted, remaining)
+	}
+
+	for _, lot := range stock {
+		if remaining == 0 {
+			break
+		}
+		take := min(lot.Quantity, remaining)
+		if take == 0 {
+			continue
+		}
+		if err := changeLot(tx, lot.LotID, productID, shopID, -take, ref); err != nil {
+			return err
+		}
+		remaining -= take
+	}
+	if remaining > 0 {
+		return ErrInsufficientLotStock
+	}
+	return nil
+}
+
+// documentLots nets a document's lot movements of a product per lot. Given a
+// shop, it returns the lots the document brought into that shop; otherwise
+// the lots it took out and hasn't put back anywhere.
+func documentLots(tx *gorm.DB, documentID, productID uuid.UUID, shopID *uuid.UUID) ([]lotPick, error) {
+	if documentID == uuid.Nil {
+		return nil, nil
+	}
+
+	var picks []lotPick
+	query := tx.Table("stock_lot_movements").
+		Where("document_id = ? AND product_id = ?", documentID, productID).
+		Group("lot_id").
+		Order("MIN(moved_at)")
+	if shopID != nil {
+		query = query.Select("lot_id, SUM(quantity) AS quantity").
+			Where("shop_id = ?", *shopID).
+			Having("SUM(quantity) > 0")
+	} else {
+		query = query.Select("lot_id, -SUM(quantity) AS quantity").
+			Having("SUM(quantity) < 0")
+	}
+	err := query.Scan(&picks).Error
+	return picks, err
+}
+
+// limitPicks trims picks in order so they add up to no more than quantity
+func limitPicks(picks []lotPick, quantity int) []lotPick {
+	var limited []lotPick
+	for _, pick := range picks {
+		if quantity == 0 {
+			break
+		}
+		pick.Quantity = min(pick.Quantity, quantity)
+		quantity -= pick.Quantity
+		limited = append(limited, pick)
+	}
+	return limited
+}
+
+// changeLot adds to or takes from a lot's stock in a shop and records the
+// change. Taking more than the shop holds of the lot fails with
+// ErrInsufficientLotStock.
+func changeLot(tx *gorm.DB, lotID, productID, shopID uuid.UUID, quantity int, ref entities.StockMovementRef) error {
+	if quantity > 0 {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "lot_id"}, {Name: "shop_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("lot_inventories.quantity + ?", quantity), "updated_at": time.Now()}),
+		}).Create(&entities.LotInventory{
+			LotID:     lotID,
+			ShopID:    shopID,
+			ProductID: productID,
+			Quantity:  quantity,
+		}).Error
+		if err != nil {
+			return err
+		}
+	} else {
+		result := tx.Model(&entities.LotInventory{}).
+			Where("lot_id = ? AND shop_id = ? AND quantity >= ?", lotID, shopID, -quantity).
+			Update("quantity", gorm.Expr("quantity - ?", -quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientLotStock
+		}
+	}
+
+	movement := &entities.StockLotMovement{
+		LotID:        lotID,
+		ProductID:    productID,
+		ShopID:       shopID,
+		MovedAt:      time.Now(),
+		MovementType: ref.Type,
+		Quantity:     quantity,
+	}
+	if ref.DocumentID != uuid.Nil {
+		documentID := ref.DocumentID
+		movement.DocumentID = &documentID
+	}
+	return tx.Create(movement).Error
+}
@@ -0,0 +1,134 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockMovementRepository interface {
+	GetMovements(productID, shopID uuid.UUID, from, to *time.Time) ([]entities.StockMovement, error)
+	GetBalanceBefore(productID, shopID uuid.UUID, before time.Time) (int, error)
+	SeedOpeningBalances() (int64, error)
+	RebuildInventory(shopID *uuid.UUID, dryRun bool) ([]entities.InventoryDrift, error)
+}
+
+type stockMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewStockMovementRepository(db *gorm.DB) StockMovementRepository {
+	return &stockMovementRepository{
+		db: db,
+	}
+}
+
+// GetMovements returns a product's movements in a shop, oldest first. to is
+// exclusive.
+func (r *stockMovementRepository) GetMovements(productID, shopID uuid.UUID, from, to *time.Time) ([]entities.StockMovement, error) {
+	var movements []entities.StockMovement
+	query := r.db.Preload("User").
+		Where("product_id = ? AND shop_id = ?", productID, shopID)
+	if from != nil {
+		query = query.Where("moved_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("moved_at < ?", *to)
+	}
+	err := query.Order("moved_at").Order("created_at").Find(&movements).Error
+	return movements, err
+}
+
+// GetBalanceBefore is the ledger quantity of a product in a shop just before
+// the given time
+func (r *stockMovementRepository) GetBalanceBefore(productID, shopID uuid.UUID, before time.Time) (int, error) {
+	var balance int
+	err := r.db.Model(&entities.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND shop_id = ? AND moved_at < ?", productID, shopID, before).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// SeedOpeningBalances puts stock that predates the ledger on it as an
+// opening balance, once per product and shop. It returns how many balances
+// were added.
+func (r *stockMovementRepository) SeedOpeningBalances() (int64, error) {
+	return seedOpeningBalances(r.db)
+}
+
+func seedOpeningBalances(tx *gorm.DB) (int64, error) {
+	result := tx.Exec(`
+		INSERT INTO stock_movements (product_id, shop_id, moved_at, movement_type, quantity, quantity_before, quantity_after, unit_cost, created_at)
+		SELECT i.product_id, i.shop_id, NOW(), ?, i.quantity, 0, i.quantity, i.average_cost, NOW()
+		FROM inventories i
+		WHERE i.is_marked_to_delete = false
+			AND i.quantity <> 0
+			AND NOT EXISTS (
+				SELECT 1 FROM stock_movements m
+				WHERE m.product_id = i.product_id AND m.shop_id = i.shop_id
+			)`, entities.MovementOpeningBalance)
+	return result.RowsAffected, result.Error
+}
+
+// errDryRun rolls back a dry run's transaction
+var errDryRun = errors.New("dry run")
+
+// RebuildInventory puts stock older than the ledger on it, recomputes
+// inventory quantities from the ledger and returns the rows that disagreed
+// with it. Rows whose ledger balance is negative can't be stock and are
+// reported as skipped rather than written. A dry run only reports the rows,
+// seeding the ledger in a transaction it rolls back so it finds what a real
+// run would.
+func (r *stockMovementRepository) RebuildInventory(shopID *uuid.UUID, dryRun bool) ([]entities.InventoryDrift, error) {
+	var drifts []entities.InventoryDrift
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := seedOpeningBalances(tx); err != nil {
+			return err
+		}
+
+		query := tx.Table("inventories i").
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "i"}}).
+			Select("i.product_id, i.shop_id, i.quantity, COALESCE(m.quantity, 0) AS ledger_quantity").
+			Joins(`LEFT JOIN (
+				SELECT product_id, shop_id, SUM(quantity) AS quantity
+				FROM stock_movements
+				GROUP BY product_id, shop_id
+			) m ON m.product_id = i.product_id AND m.shop_id = i.shop_id`).
+			Where("i.is_marked_to_delete = ? AND i.quantity <> COALESCE(m.quantity, 0)", false)
+		if shopID != nil {
+			query = query.Where("i.shop_id = ?", *shopID)
+		}
+		if err := query.Scan(&drifts).Error; err != nil {
+			return err
+		}
+		for i := range drifts {
+			drifts[i].Skipped = drifts[i].LedgerQuantity < 0
+		}
+		if dryRun {
+			return errDryRun
+		}
+
+		for _, drift := range drifts {
+			if drift.Skipped {
+				continue
+			}
+			err := tx.Model(&entities.Inventory{}).
+				Where("product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", drift.ProductID, drift.ShopID, false).
+				Update("quantity", drift.LedgerQuantity).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return drifts, err
+}
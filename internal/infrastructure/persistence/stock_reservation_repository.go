@@ -0,0 +1,266 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservationNotActive = errors.New("stock reservation is no longer active")
+	ErrReservationMismatch  = errors.New("stock reservation is for another product or shop")
+)
+
+type StockReservationRepository interface {
+	BaseRepository[entities.StockReservation]
+	GetStockReservationsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockReservation, int64, error)
+	Reserve(reservation *entities.StockReservation) error
+	Release(id uuid.UUID) (*entities.StockReservation, error)
+	ExpireDue(now time.Time) (int, error)
+}
+
+type stockReservationRepository struct {
+	BaseRepositoryImpl[entities.StockReservation]
+}
+
+func NewStockReservationRepository(db *gorm.DB) StockReservationRepository {
+	return &stockReservationRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.StockReservation]{DB: db},
+	}
+}
+
+func (r *stockReservationRepository) GetByID(id uuid.UUID) (*entities.StockReservation, error) {
+	var reservation entities.StockReservation
+	err := r.DB.Preload("Shop").
+		Preload("Product").
+		Preload("Customer").
+		Preload("CreatedBy").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&reservation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *stockReservationRepository) GetStockReservationsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.StockReservation, int64, error) {
+	var reservations []entities.StockReservation
+	var total int64
+
+	query := r.DB.Model(&entities.StockReservation{}).
+		Preload("Product").
+		Preload("Customer").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "shop_id", "product_id", "source_type", "source_id", "customer_id", "status":
+			query = query.Where(field+" = ?", value)
+		case "expires_before":
+			query = query.Where("expires_at < ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+	if len(sorts) == 0 {
+		query = query.Order("created_at DESC")
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&reservations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reservations, total, nil
+}
+
+// Reserve holds the reservation's quantity out of the stock available in the
+// shop. It fails with an InsufficientStockError when less is available.
+func (r *stockReservationRepository) Reserve(reservation *entities.StockReservation) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		inventory, err := lockAvailableStock(tx, reservation.ProductID, reservation.ShopID, reservation.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(inventory).
+			Update("reserved_quantity", gorm.Expr("reserved_quantity + ?", reservation.Quantity)).Error
+		if err != nil {
+			return err
+		}
+
+		reservation.Status = entities.StockReservationStatusActive
+		return tx.Create(reservation).Error
+	})
+}
+
+// Release gives a reservation's stock back to the shop without selling it
+func (r *stockReservationRepository) Release(id uuid.UUID) (*entities.StockReservation, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		reservation, err := lockActiveReservation(tx, id)
+		if err != nil {
+			return err
+		}
+		return closeReservation(tx, reservation, entities.StockReservationStatusReleased)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// ExpireDue expires every active reservation whose time ran out and returns
+// how many there were. Each product and shop is expired in a transaction of
+// its own, so the sweep never holds more than one inventory row at a time.
+func (r *stockReservationRepository) ExpireDue(now time.Time) (int, error) {
+	var due []struct {
+		ProductID uuid.UUID
+		ShopID    uuid.UUID
+	}
+	err := r.DB.Model(&entities.StockReservation{}).
+		Distinct("product_id", "shop_id").
+		Where("status = ? AND expires_at <= ? AND is_marked_to_delete = ?", entities.StockReservationStatusActive, now, false).
+		Scan(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int
+	for _, stock := range due {
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			inventory, err := lockInventory(tx, stock.ProductID, stock.ShopID)
+			if err != nil || inventory == nil {
+				return err
+			}
+			count, err := expireDueReservations(tx, inventory, now)
+			expired += count
+			return err
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// lockAvailableStock locks a product's inventory in a shop and checks that
+// quantity of it is on hand and not reserved. Reservations of the product
+// that have run out are expired first so they don't hold stock back.
+func lockAvailableStock(tx *gorm.DB, productID, shopID uuid.UUID, quantity int) (*entities.Inventory, error) {
+	inventory, err := lockInventory(tx, productID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if inventory == nil {
+		return nil, &InsufficientStockError{ProductID: productID, ShopID: shopID, Requested: quantity}
+	}
+
+	if _, err := expireDueReservations(tx, inventory, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if available := inventory.Available(); available < quantity {
+		return nil, &InsufficientStockError{ProductID: productID, ShopID: shopID, Requested: quantity, Available: available}
+	}
+	return inventory, nil
+}
+
+// expireDueReservations expires the reservations of a locked inventory row
+// that ran out by now and returns how many there were
+func expireDueReservations(tx *gorm.DB, inventory *entities.Inventory, now time.Time) (int, error) {
+	var due []entities.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND shop_id = ? AND status = ? AND expires_at <= ? AND is_marked_to_delete = ?",
+			inventory.ProductID, inventory.ShopID, entities.StockReservationStatusActive, now, false).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		if err := closeReservation(tx, &due[i], entities.StockReservationStatusExpired); err != nil {
+			return 0, err
+		}
+		inventory.ReservedQuantity -= due[i].Quantity
+	}
+	if inventory.ReservedQuantity < 0 {
+		inventory.ReservedQuantity = 0
+	}
+	return len(due), nil
+}
+
+// fulfilReservation closes the reservation a posted document takes its stock
+// from, so the stock counts as available again just before it is issued.
+// Whatever the document doesn't take of the reservation is released with it.
+func fulfilReservation(tx *gorm.DB, id, productID, shopID uuid.UUID) error {
+	reservation, err := lockActiveReservation(tx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.ProductID != productID || reservation.ShopID != shopID {
+		return ErrReservationMismatch
+	}
+	return closeReservation(tx, reservation, entities.StockReservationStatusFulfilled)
+}
+
+// lockActiveReservation loads and locks a reservation that still holds stock.
+// Its inventory row is locked first, in the same order as stock is reserved
+// and expired, so the two can't deadlock.
+func lockActiveReservation(tx *gorm.DB, id uuid.UUID) (*entities.StockReservation, error) {
+	var reservation entities.StockReservation
+	err := tx.Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&reservation).Error
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lockInventory(tx, reservation.ProductID, reservation.ShopID); err != nil {
+		return nil, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&reservation).Error
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != entities.StockReservationStatusActive {
+		return nil, ErrReservationNotActive
+	}
+	return &reservation, nil
+}
+
+// closeReservation ends an active reservation and takes its quantity off the
+// stock reserved in the shop
+func closeReservation(tx *gorm.DB, reservation *entities.StockReservation, status entities.StockReservationStatus) error {
+	err := tx.Model(&entities.Inventory{}).
+		Where("product_id = ? AND shop_id = ? AND is_marked_to_delete = ?", reservation.ProductID, reservation.ShopID, false).
+		Update("reserved_quantity", gorm.Expr("GREATEST(reserved_quantity - ?, 0)", reservation.Quantity)).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reservation.Status = status
+	reservation.ClosedAt = &now
+	return tx.Model(reservation).Updates(map[string]interface{}{
+		"status":    status,
+		"closed_at": now,
+	}).Error
+}
@@ -8,6 +8,12 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransferCancelled     = errors.New("stock transfer is already cancelled")
+	ErrTransferWithoutSource = errors.New("stock transfer has no source shop")
 )
 
 // StockTransferRepository defines the interface for stock transfer operations
@@ -23,6 +29,9 @@ type StockTransferRepository interface {
 	AddHistory(history *entities.StockTransferHistory) error
 	GetStockTransfersWithFilters(filters map[string]interface{}, orderBy []string, page, pageSize int) ([]entities.StockTransfer, int64, error)
 	GetTransfersByShop(shopID uuid.UUID, startDate, endDate time.Time) ([]entities.StockTransfer, error)
+	CreateWithStock(stockTransfer *entities.StockTransfer) error
+	UpdateWithStock(stockTransfer *entities.StockTransfer) error
+	CancelWithStock(id uuid.UUID) error
 }
 
 type stockTransferRepository struct {
@@ -159,3 +168,103 @@ func (r *stockTransferRepository) GetTransfersByShop(shopID uuid.UUID, startDate
 		Find(&stockTransfers).Error
 	return stockTransfers, err
 }
+
+// CreateWithStock saves the transfer and moves its stock in one transaction,
+// fulfilling the reservation that held the stock for it, if any
+func (r *stockTransferRepository) CreateWithStock(stockTransfer *entities.StockTransfer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if stockTransfer.ReservationID != nil {
+			if err := fulfilReservation(tx, *stockTransfer.ReservationID, stockTransfer.ProductID, *stockTransfer.FromShopID); err != nil {
+				return err
+			}
+		}
+		if stockTransfer.LotID != nil {
+			if err := checkLot(tx, *stockTransfer.LotID, stockTransfer.ProductID); err != nil {
+				return err
+			}
+		}
+		if err := checkSerials(tx, stockTransfer.ProductID, stockTransfer.Quantity, stockTransfer.SerialNumbers); err != nil {
+			return err
+		}
+		if err := tx.Create(stockTransfer).Error; err != nil {
+			return err
+		}
+		return transferStock(tx, stockTransfer.ID, *stockTransfer.FromShopID, stockTransfer.ToShopID, stockTransfer.ProductID, stockTransfer.Quantity, stockTransfer.LotID, stockTransfer.SerialNumbers, &stockTransfer.TransferredBy)
+	})
+}
+
+// UpdateWithStock saves a changed quantity or remark and moves only the
+// difference in quantity. The shops, product and lot of a transfer stay as
+// they were created; stock sent back returns to the lots it came from.
+// Serialised products name the units sent on top, and may name the units
+// sent back.
+func (r *stockTransferRepository) UpdateWithStock(stockTransfer *entities.StockTransfer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockTransfer(tx, stockTransfer.ID)
+		if err != nil {
+			return err
+		}
+		// Its stock already went back when it was cancelled
+		if existing.Status == entities.StatusCancelled {
+			return ErrTransferCancelled
+		}
+
+		switch delta := stockTransfer.Quantity - existing.Quantity; {
+		case delta > 0:
+			if err := checkSerials(tx, existing.ProductID, delta, stockTransfer.SerialNumbers); err != nil {
+				return err
+			}
+			err = transferStock(tx, existing.ID, *existing.FromShopID, existing.ToShopID, existing.ProductID, delta, existing.LotID, stockTransfer.SerialNumbers, &existing.TransferredBy)
+		case delta < 0:
+			err = transferStock(tx, existing.ID, existing.ToShopID, *existing.FromShopID, existing.ProductID, -delta, nil, stockTransfer.SerialNumbers, &existing.TransferredBy)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(existing).Updates(map[string]interface{}{
+			"quantity": stockTransfer.Quantity,
+			"remarks":  stockTransfer.Remarks,
+		}).Error
+		if err != nil {
+			return err
+		}
+		*stockTransfer = *existing
+		return nil
+	})
+}
+
+// CancelWithStock moves the transferred stock back to the source shop and
+// marks the transfer cancelled, keeping it for the movement ledger
+func (r *stockTransferRepository) CancelWithStock(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		if existing.Status == entities.StatusCancelled {
+			return ErrTransferCancelled
+		}
+
+		err = transferStock(tx, existing.ID, existing.ToShopID, *existing.FromShopID, existing.ProductID, existing.Quantity, nil, nil, &existing.TransferredBy)
+		if err != nil {
+			return err
+		}
+		return tx.Model(existing).Update("status", entities.StatusCancelled).Error
+	})
+}
+
+// lockTransfer loads and locks a transfer that moved stock between shops
+func lockTransfer(tx *gorm.DB, id uuid.UUID) (*entities.StockTransfer, error) {
+	var stockTransfer entities.StockTransfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&stockTransfer).Error
+	if err != nil {
+		return nil, err
+	}
+	if stockTransfer.FromShopID == nil {
+		return nil, ErrTransferWithoutSource
+	}
+	return &stockTransfer, nil
+}
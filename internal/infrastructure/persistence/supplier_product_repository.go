@@ -0,0 +1,158 @@
+package persistence
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SupplierProductRepository interface {
+	BaseRepository[entities.SupplierProduct]
+	GetSupplierProductsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.SupplierProduct, int64, error)
+	GetBySupplierAndProduct(supplierID, productID uuid.UUID) (*entities.SupplierProduct, error)
+	GetBySupplierCodes(supplierID uuid.UUID, codes []string) ([]entities.SupplierProduct, error)
+	GetByProducts(productIDs []uuid.UUID) ([]entities.SupplierProduct, error)
+	Save(link *entities.SupplierProduct) error
+	SaveSupplierCodes(links []entities.SupplierProduct) error
+}
+
+type supplierProductRepository struct {
+	BaseRepositoryImpl[entities.SupplierProduct]
+}
+
+func NewSupplierProductRepository(db *gorm.DB) SupplierProductRepository {
+	return &supplierProductRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.SupplierProduct]{DB: db},
+	}
+}
+
+func (r *supplierProductRepository) GetSupplierProductsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.SupplierProduct, int64, error) {
+	var links []entities.SupplierProduct
+	var total int64
+
+	query := r.DB.Model(&entities.SupplierProduct{}).
+		Preload("Supplier").
+		Preload("Product").
+		Joins("JOIN suppliers ON suppliers.id = supplier_products.supplier_id").
+		Joins("JOIN products ON products.id = supplier_products.product_id").
+		Where("supplier_products.is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "supplier_id", "product_id", "is_preferred":
+			query = query.Where("supplier_products."+field+" = ?", value)
+		case "search":
+			search := "%" + value.(string) + "%"
+			query = query.Where("supplier_products.supplier_code ILIKE ? OR products.code ILIKE ? OR products.name ILIKE ? OR suppliers.name ILIKE ?",
+				search, search, search, search)
+		case "max_lead_time_days":
+			query = query.Where("supplier_products.lead_time_days <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return links, total, nil
+}
+
+// GetBySupplierAndProduct also returns entries marked as deleted, since a
+// supplier and product can only be linked once
+func (r *supplierProductRepository) GetBySupplierAndProduct(supplierID, productID uuid.UUID) (*entities.SupplierProduct, error) {
+	var link entities.SupplierProduct
+	err := r.DB.Where("supplier_id = ? AND product_id = ?", supplierID, productID).
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetBySupplierCodes finds the products a supplier sells under the given codes
+func (r *supplierProductRepository) GetBySupplierCodes(supplierID uuid.UUID, codes []string) ([]entities.SupplierProduct, error) {
+	var links []entities.SupplierProduct
+	if len(codes) == 0 {
+		return links, nil
+	}
+	err := r.DB.Preload("Product").
+		Where("supplier_id = ? AND supplier_code IN ? AND is_marked_to_delete = ?", supplierID, codes, false).
+		Find(&links).Error
+	return links, err
+}
+
+// GetByProducts returns every supplier's catalog entry for the products,
+// preferred suppliers first
+func (r *supplierProductRepository) GetByProducts(productIDs []uuid.UUID) ([]entities.SupplierProduct, error) {
+	var links []entities.SupplierProduct
+	if len(productIDs) == 0 {
+		return links, nil
+	}
+	err := r.DB.Preload("Supplier").
+		Where("product_id IN ? AND is_marked_to_delete = ?", productIDs, false).
+		Order("is_preferred DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// Save creates or updates a catalog entry. Marking it preferred clears the
+// flag on the product's other suppliers.
+func (r *supplierProductRepository) Save(link *entities.SupplierProduct) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if link.IsPreferred {
+			err := tx.Model(&entities.SupplierProduct{}).
+				Where("product_id = ? AND id <> ? AND is_preferred = ?", link.ProductID, link.ID, true).
+				Update("is_preferred", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Save(link).Error
+	})
+}
+
+// Delete marks the catalog entry deleted and drops its preferred flag, so
+// reviving it later can't leave the product with two preferred suppliers
+func (r *supplierProductRepository) Delete(id uuid.UUID) error {
+	return r.DB.Model(&entities.SupplierProduct{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"is_marked_to_delete": true,
+			"is_preferred":        false,
+		}).Error
+}
+
+// SaveSupplierCodes records the supplier's code for each product, replacing
+// any code saved before
+func (r *supplierProductRepository) SaveSupplierCodes(links []entities.SupplierProduct) error {
+	if len(links) == 0 {
+		return nil
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "supplier_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"supplier_code", "is_marked_to_delete", "updated_at"}),
+	}).Create(&links).Error
+}
+
+// updateCatalogPrice records the latest price paid to a supplier on the
+// product's catalog entry, if the supplier has one
+func updateCatalogPrice(tx *gorm.DB, supplierID, productID uuid.UUID, price float64) error {
+	return tx.Model(&entities.SupplierProduct{}).
+		Where("supplier_id = ? AND product_id = ? AND is_marked_to_delete = ?", supplierID, productID, false).
+		Update("last_price", price).Error
+}
@@ -0,0 +1,174 @@
+package persistence
+
+import (
+	"errors"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReturnExceedsPurchase = errors.New("returned quantity exceeds the quantity purchased on the invoice line")
+	ErrUnknownPurchaseLine   = errors.New("return line does not belong to the purchase invoice")
+)
+
+type SupplierReturnRepository interface {
+	BaseRepository[entities.SupplierReturn]
+	GetSupplierReturnsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.SupplierReturn, int64, error)
+	CreateWithStock(supplierReturn *entities.SupplierReturn) error
+}
+
+type supplierReturnRepository struct {
+	BaseRepositoryImpl[entities.SupplierReturn]
+}
+
+func NewSupplierReturnRepository(db *gorm.DB) SupplierReturnRepository {
+	return &supplierReturnRepository{
+		BaseRepositoryImpl: BaseRepositoryImpl[entities.SupplierReturn]{DB: db},
+	}
+}
+
+func (r *supplierReturnRepository) GetByID(id uuid.UUID) (*entities.SupplierReturn, error) {
+	var supplierReturn entities.SupplierReturn
+	err := r.DB.Preload("Supplier").
+		Preload("PurchaseInvoice").
+		Preload("ReturnedBy").
+		Preload("Lines").
+		Preload("Lines.Product").
+		Where("id = ? AND is_marked_to_delete = ?", id, false).
+		First(&supplierReturn).Error
+	if err != nil {
+		return nil, err
+	}
+	return &supplierReturn, nil
+}
+
+func (r *supplierReturnRepository) GetSupplierReturnsWithFilters(filters map[string]interface{}, sorts []string, page, pageSize int) ([]entities.SupplierReturn, int64, error) {
+	var returns []entities.SupplierReturn
+	var total int64
+
+	query := r.DB.Model(&entities.SupplierReturn{}).
+		Preload("Supplier").
+		Preload("Lines").
+		Preload("Lines.Product").
+		Where("is_marked_to_delete = ?", false)
+
+	// Apply filters
+	for field, value := range filters {
+		switch field {
+		case "supplier_id", "purchase_invoice_id", "shop_id":
+			query = query.Where(field+" = ?", value)
+		case "date_from":
+			query = query.Where("return_date_time >= ?", value)
+		case "date_to":
+			query = query.Where("return_date_time <= ?", value)
+		}
+	}
+
+	// Count total before pagination
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting
+	for _, sort := range sorts {
+		query = query.Order(sort)
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	err = query.Offset(offset).Limit(pageSize).Find(&returns).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return returns, total, nil
+}
+
+// CreateWithStock validates the returned lines against the original purchase,
+// prices them at the purchase price, takes the goods out of the receiving
+// shop's inventory and saves the return with its debit note amount.
+func (r *supplierReturnRepository) CreateWithStock(supplierReturn *entities.SupplierReturn) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the invoice so concurrent returns are checked one at a time
+		var purchase entities.PurchaseInvoice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_marked_to_delete = ?", supplierReturn.PurchaseInvoiceID, false).
+			First(&purchase).Error
+		if err != nil {
+			return err
+		}
+
+		var details []entities.PurchaseDetail
+		if err := tx.Where("purchase_invoice_id = ? AND is_marked_to_delete = ?", purchase.ID, false).Find(&details).Error; err != nil {
+			return err
+		}
+		detailsByID := make(map[uuid.UUID]entities.PurchaseDetail, len(details))
+		for _, detail := range details {
+			detailsByID[detail.ID] = detail
+		}
+
+		// Quantities already sent back on earlier returns
+		type returnedQuantity struct {
+			PurchaseDetailID uuid.UUID
+			Quantity         int
+		}
+		var previous []returnedQuantity
+		err = tx.Model(&entities.SupplierReturnLine{}).
+			Select("supplier_return_lines.purchase_detail_id, SUM(supplier_return_lines.quantity) AS quantity").
+			Joins("JOIN supplier_returns ON supplier_returns.id = supplier_return_lines.supplier_return_id").
+			Where("supplier_returns.purchase_invoice_id = ? AND supplier_returns.is_marked_to_delete = ?", purchase.ID, false).
+			Group("supplier_return_lines.purchase_detail_id").
+			Scan(&previous).Error
+		if err != nil {
+			return err
+		}
+		returned := make(map[uuid.UUID]int, len(previous))
+		for _, p := range previous {
+			returned[p.PurchaseDetailID] = p.Quantity
+		}
+
+		if supplierReturn.ID == uuid.Nil {
+			supplierReturn.ID = uuid.New()
+		}
+		supplierReturn.SupplierID = purchase.SupplierID
+		supplierReturn.ShopID = purchase.ShopID
+		supplierReturn.DebitNoteAmount = 0
+		for i := range supplierReturn.Lines {
+			line := &supplierReturn.Lines[i]
+			detail, ok := detailsByID[line.PurchaseDetailID]
+			if !ok {
+				return ErrUnknownPurchaseLine
+			}
+			returned[detail.ID] += line.Quantity
+			if returned[detail.ID] > detail.Quantity {
+				return ErrReturnExceedsPurchase
+			}
+
+			line.ProductID = detail.ProductID
+			line.UnitPrice = detail.PurchasePrice
+			line.Subtotal = detail.PurchasePrice * float64(line.Quantity)
+			supplierReturn.DebitNoteAmount += line.Subtotal
+
+			if err := checkSerials(tx, line.ProductID, line.Quantity, line.SerialNumbers); err != nil {
+				return err
+			}
+			err := reverseReceipt(tx, line.ProductID, purchase.ShopID, line.Quantity, receivedUnitCost(detail), detail.ID, entities.StockMovementRef{
+				Type:          entities.MovementSupplierReturn,
+				DocumentID:    supplierReturn.ID,
+				UserID:        &supplierReturn.ReturnedByID,
+				LotID:         detail.LotID,
+				SerialNumbers: line.SerialNumbers,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(supplierReturn).Error
+	})
+}
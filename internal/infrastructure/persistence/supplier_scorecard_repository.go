@@ -0,0 +1,158 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SupplierScorecardRepository interface {
+	GetScorecardFacts(from, to time.Time) (*ScorecardFacts, error)
+}
+
+// ScorecardOrderLine is a line of a purchase order placed in the period.
+// Due is set once the order is closed or past its expected date, and only
+// due lines count towards the fill rate.
+type ScorecardOrderLine struct {
+	PurchaseOrderID  uuid.UUID
+	SupplierID       uuid.UUID
+	Category         string
+	OrderedQuantity  int
+	ReceivedQuantity int
+	Due              bool
+}
+
+// ScorecardDelivery is a goods receipt in the period, once per product
+// category it brought in
+type ScorecardDelivery struct {
+	GoodsReceiptID   uuid.UUID
+	SupplierID       uuid.UUID
+	Category         string
+	OrderDateTime    time.Time
+	ExpectedDateTime *time.Time
+	ReceivedDateTime time.Time
+}
+
+// ScorecardPurchaseLine is goods bought in the period, from a purchase
+// invoice line or a goods receipt against an order
+type ScorecardPurchaseLine struct {
+	SupplierID uuid.UUID
+	ProductID  uuid.UUID
+	Category   string
+	Quantity   int
+	UnitPrice  float64
+}
+
+// ScorecardReturnLine is goods sent back to a supplier in the period
+type ScorecardReturnLine struct {
+	SupplierID uuid.UUID
+	Category   string
+	Quantity   int
+}
+
+// ScorecardFacts is everything a period's supplier scorecards are built from
+type ScorecardFacts struct {
+	OrderLines    []ScorecardOrderLine
+	Deliveries    []ScorecardDelivery
+	PurchaseLines []ScorecardPurchaseLine
+	ReturnLines   []ScorecardReturnLine
+}
+
+// Draft orders were never sent, so the supplier is not judged on them
+const scorecardOrderLinesSQL = `
+	SELECT purchase_orders.id AS purchase_order_id, purchase_orders.supplier_id,
+		products.master_category AS category,
+		purchase_order_lines.ordered_quantity, purchase_order_lines.received_quantity,
+		(purchase_orders.status = 'CLOSED' OR purchase_orders.expected_date_time < NOW()) IS TRUE AS due
+	FROM purchase_order_lines
+	JOIN purchase_orders ON purchase_orders.id = purchase_order_lines.purchase_order_id
+	JOIN products ON products.id = purchase_order_lines.product_id
+	WHERE purchase_order_lines.is_marked_to_delete = false
+		AND purchase_orders.is_marked_to_delete = false
+		AND purchase_orders.status <> 'DRAFT'
+		AND purchase_orders.order_date_time >= @from
+		AND purchase_orders.order_date_time < @to`
+
+const scorecardDeliveriesSQL = `
+	SELECT DISTINCT goods_receipts.id AS goods_receipt_id, purchase_orders.supplier_id,
+		products.master_category AS category,
+		purchase_orders.order_date_time, purchase_orders.expected_date_time,
+		goods_receipts.received_date_time
+	FROM goods_receipt_lines
+	JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id
+	JOIN purchase_orders ON purchase_orders.id = goods_receipts.purchase_order_id
+	JOIN products ON products.id = goods_receipt_lines.product_id
+	WHERE goods_receipts.is_marked_to_delete = false
+		AND goods_receipts.received_date_time >= @from
+		AND goods_receipts.received_date_time < @to`
+
+const scorecardPurchaseLinesSQL = `
+	SELECT purchase_invoices.supplier_id, purchase_details.product_id,
+		products.master_category AS category,
+		purchase_details.quantity, purchase_details.purchase_price AS unit_price
+	FROM purchase_details
+	JOIN purchase_invoices ON purchase_invoices.id = purchase_details.purchase_invoice_id
+	JOIN products ON products.id = purchase_details.product_id
+	WHERE purchase_details.deleted_at IS NULL
+		AND purchase_details.is_marked_to_delete = false
+		AND purchase_invoices.is_marked_to_delete = false
+		AND purchase_invoices.purchase_order_id IS NULL
+		AND purchase_invoices.purchase_date_time >= @from
+		AND purchase_invoices.purchase_date_time < @to
+	UNION ALL
+	SELECT purchase_orders.supplier_id, goods_receipt_lines.product_id,
+		products.master_category,
+		goods_receipt_lines.quantity, purchase_order_lines.unit_price
+	FROM goods_receipt_lines
+	JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id
+	JOIN purchase_order_lines ON purchase_order_lines.id = goods_receipt_lines.purchase_order_line_id
+	JOIN purchase_orders ON purchase_orders.id = goods_receipts.purchase_order_id
+	JOIN products ON products.id = goods_receipt_lines.product_id
+	WHERE goods_receipts.is_marked_to_delete = false
+		AND goods_receipts.received_date_time >= @from
+		AND goods_receipts.received_date_time < @to`
+
+const scorecardReturnLinesSQL = `
+	SELECT supplier_returns.supplier_id, products.master_category AS category,
+		supplier_return_lines.quantity
+	FROM supplier_return_lines
+	JOIN supplier_returns ON supplier_returns.id = supplier_return_lines.supplier_return_id
+	JOIN products ON products.id = supplier_return_lines.product_id
+	WHERE supplier_returns.is_marked_to_delete = false
+		AND supplier_returns.return_date_time >= @from
+		AND supplier_returns.return_date_time < @to`
+
+type supplierScorecardRepository struct {
+	db *gorm.DB
+}
+
+func NewSupplierScorecardRepository(db *gorm.DB) SupplierScorecardRepository {
+	return &supplierScorecardRepository{
+		db: db,
+	}
+}
+
+// GetScorecardFacts loads the orders, deliveries, purchases and returns of
+// every supplier between from and to
+func (r *supplierScorecardRepository) GetScorecardFacts(from, to time.Time) (*ScorecardFacts, error) {
+	params := map[string]interface{}{
+		"from": from,
+		"to":   to,
+	}
+
+	var facts ScorecardFacts
+	if err := r.db.Raw(scorecardOrderLinesSQL, params).Scan(&facts.OrderLines).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw(scorecardDeliveriesSQL, params).Scan(&facts.Deliveries).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw(scorecardPurchaseLinesSQL, params).Scan(&facts.PurchaseLines).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Raw(scorecardReturnLinesSQL, params).Scan(&facts.ReturnLines).Error; err != nil {
+		return nil, err
+	}
+	return &facts, nil
+}
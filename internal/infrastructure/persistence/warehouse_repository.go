@@ -0,0 +1,147 @@
+package persistence
+
+import (
+	"errors"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSaleAtWarehouse    = errors.New("warehouses don't sell to customers; transfer the stock to a shop first")
+	ErrNoCentralWarehouse = errors.New("the company has no central warehouse to transfer from")
+)
+
+type WarehouseRepository interface {
+	GetByID(id uuid.UUID) (*entities.Shop, error)
+	GetWarehousesWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.Shop, int64, error)
+	Create(warehouse *entities.Shop, central bool) error
+	SetCentral(id uuid.UUID) (*entities.Shop, error)
+	IsCentral(warehouse *entities.Shop) (bool, error)
+	GetCentralWarehouseFor(shopID uuid.UUID) (uuid.UUID, error)
+}
+
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{
+		db: db,
+	}
+}
+
+func (r *warehouseRepository) GetByID(id uuid.UUID) (*entities.Shop, error) {
+	var warehouse entities.Shop
+	err := r.db.Where("shop_id = ? AND type = ?", id, entities.LocationTypeWarehouse).First(&warehouse).Error
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *warehouseRepository) GetWarehousesWithFilters(filters map[string]interface{}, page, pageSize int) ([]entities.Shop, int64, error) {
+	var warehouses []entities.Shop
+	var total int64
+
+	query := r.db.Model(&entities.Shop{}).Where("type = ?", entities.LocationTypeWarehouse)
+
+	for field, value := range filters {
+		switch field {
+		case "company_id":
+			query = query.Where("company_id = ?", value)
+		case "name":
+			query = query.Where("name ILIKE ?", "%"+value.(string)+"%")
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	err := query.Order("name").Find(&warehouses).Error
+	return warehouses, total, err
+}
+
+// Create saves the warehouse and makes it the company's central warehouse
+// when asked to or when the company has none yet
+func (r *warehouseRepository) Create(warehouse *entities.Shop, central bool) error {
+	warehouse.Type = entities.LocationTypeWarehouse
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var company entities.Company
+		if err := tx.Where("id = ? AND is_marked_to_delete = ?", warehouse.CompanyID, false).First(&company).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(warehouse).Error; err != nil {
+			return err
+		}
+
+		if central || company.CentralWarehouseID == nil {
+			return tx.Model(&company).Update("central_warehouse_id", warehouse.ShopID).Error
+		}
+		return nil
+	})
+}
+
+// SetCentral makes the warehouse its company's central warehouse
+func (r *warehouseRepository) SetCentral(id uuid.UUID) (*entities.Shop, error) {
+	warehouse, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Model(&entities.Company{}).
+		Where("id = ?", warehouse.CompanyID).
+		Update("central_warehouse_id", warehouse.ShopID).Error
+	if err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+func (r *warehouseRepository) IsCentral(warehouse *entities.Shop) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.Company{}).
+		Where("id = ? AND central_warehouse_id = ?", warehouse.CompanyID, warehouse.ShopID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetCentralWarehouseFor returns the central warehouse of the company the
+// shop belongs to
+func (r *warehouseRepository) GetCentralWarehouseFor(shopID uuid.UUID) (uuid.UUID, error) {
+	var company entities.Company
+	err := r.db.Model(&entities.Company{}).
+		Joins("JOIN shops ON shops.company_id = companies.id").
+		Where("shops.shop_id = ?", shopID).
+		First(&company).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if company.CentralWarehouseID == nil {
+		return uuid.Nil, ErrNoCentralWarehouse
+	}
+	return *company.CentralWarehouseID, nil
+}
+
+// checkSellingLocation fails for sales at a warehouse
+func checkSellingLocation(tx *gorm.DB, shopID uuid.UUID) error {
+	var count int64
+	err := tx.Model(&entities.Shop{}).
+		Where("shop_id = ? AND type = ?", shopID, entities.LocationTypeWarehouse).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSaleAtWarehouse
+	}
+	return nil
+}
@@ -128,12 +128,16 @@ func (h *CompanyHandler) CreateCompany(c *gin.Context) {
 	}
 
 	company := &entities.Company{
-		Name:    req.Name,
-		Address: req.Address,
-		Phone:   req.Phone,
-		Email:   req.Email,
-		Slogan:  req.Slogan,
-		Remarks: req.Remarks,
+		Name:          req.Name,
+		Address:       req.Address,
+		Phone:         req.Phone,
+		Email:         req.Email,
+		Slogan:        req.Slogan,
+		Remarks:       req.Remarks,
+		CostingMethod: entities.CostingMethod(req.CostingMethod),
+	}
+	if company.CostingMethod == "" {
+		company.CostingMethod = entities.CostingMethodAverage
 	}
 
 	if err := h.companyService.CreateCompany(company); err != nil {
@@ -189,6 +193,9 @@ func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 	existingCompany.Email = req.Email
 	existingCompany.Slogan = req.Slogan
 	existingCompany.Remarks = req.Remarks
+	if req.CostingMethod != "" {
+		existingCompany.CostingMethod = entities.CostingMethod(req.CostingMethod)
+	}
 
 	if err := h.companyService.UpdateCompany(existingCompany); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update company"})
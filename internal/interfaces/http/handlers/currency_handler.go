@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyHandler struct {
+	currencyService services.CurrencyService
+}
+
+func NewCurrencyHandler(currencyService services.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+	}
+}
+
+// GetCurrencies godoc
+// @Summary List currencies
+// @Description Get the currencies set up for foreign purchases and payments
+// @Tags currencies
+// @Produce json
+// @Success 200 {array} entities.Currency
+// @Router /currencies [get]
+// @Security BearerAuth
+func (h *CurrencyHandler) GetCurrencies(c *gin.Context) {
+	currencies, err := h.currencyService.GetCurrencies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch currencies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+}
+
+// CreateCurrency godoc
+// @Summary Set up a currency
+// @Tags currencies
+// @Accept json
+// @Produce json
+// @Param currency body entities.CreateCurrencyRequest true "Currency"
+// @Success 201 {object} entities.Currency
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /currencies [post]
+// @Security BearerAuth
+func (h *CurrencyHandler) CreateCurrency(c *gin.Context) {
+	var req entities.CreateCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency := &entities.Currency{
+		Code:   req.Code,
+		Name:   req.Name,
+		Symbol: req.Symbol,
+	}
+	if err := h.currencyService.CreateCurrency(currency); err != nil {
+		if errors.Is(err, services.ErrCurrencyExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create currency"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, currency)
+}
+
+// GetExchangeRates godoc
+// @Summary List exchange rates
+// @Description Get a paginated list of entered exchange rates, newest first
+// @Tags currencies
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param currency_code query string false "Currency code"
+// @Param date_from query string false "Rates from date"
+// @Param date_to query string false "Rates to date"
+// @Success 200 {object} map[string]interface{}
+// @Router /exchange-rates [get]
+// @Security BearerAuth
+func (h *CurrencyHandler) GetExchangeRates(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if currencyCode := c.Query("currency_code"); currencyCode != "" {
+		filters["currency_code"] = strings.ToUpper(currencyCode)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	rates, total, err := h.currencyService.GetRates(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rates,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// SaveExchangeRate godoc
+// @Summary Enter an exchange rate
+// @Description Set what one unit of a currency is worth in taka on a day, replacing any rate already entered for that day
+// @Tags currencies
+// @Accept json
+// @Produce json
+// @Param rate body entities.SaveExchangeRateRequest true "Exchange rate"
+// @Success 200 {object} entities.ExchangeRate
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /exchange-rates [put]
+// @Security BearerAuth
+func (h *CurrencyHandler) SaveExchangeRate(c *gin.Context) {
+	var req entities.SaveExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate := &entities.ExchangeRate{
+		CurrencyCode: req.CurrencyCode,
+		RateDate:     req.RateDate,
+		Rate:         req.Rate,
+	}
+	if err := h.currencyService.SaveRate(rate); err != nil {
+		if errors.Is(err, services.ErrUnknownCurrency) || errors.Is(err, services.ErrBaseCurrencyRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// GetRateOn godoc
+// @Summary Look up the rate for a day
+// @Description Get the latest rate entered on or before a day, as a purchase or payment dated that day would use
+// @Tags currencies
+// @Produce json
+// @Param currency_code query string true "Currency code"
+// @Param date query string false "Day (YYYY-MM-DD), today by default"
+// @Success 200 {object} entities.ExchangeRate
+// @Router /exchange-rates/lookup [get]
+// @Security BearerAuth
+func (h *CurrencyHandler) GetRateOn(c *gin.Context) {
+	currencyCode := c.Query("currency_code")
+	if currencyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency_code is required"})
+		return
+	}
+
+	date := time.Now()
+	if day := c.Query("date"); day != "" {
+		parsed, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	rate, err := h.currencyService.GetRateOn(currencyCode, date)
+	if err != nil {
+		if errors.Is(err, services.ErrNoExchangeRate) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
@@ -2,12 +2,31 @@ package handlers
 
 // Handlers groups all HTTP handlers
 type Handlers struct {
-	Auth     *AuthHandler
-	User     *UserHandler
-	Product  *ProductHandler
-	Sales    *SalesHandler
-	Purchase *PurchaseHandler
-	Supplier *SupplierHandler
-	Company  *CompanyHandler
-	Shop     *ShopHandler
+	Auth              *AuthHandler
+	User              *UserHandler
+	Product           *ProductHandler
+	Sales             *SalesHandler
+	Purchase          *PurchaseHandler
+	PurchaseOrder     *PurchaseOrderHandler
+	LandedCost        *LandedCostHandler
+	PurchaseImport    *PurchaseImportHandler
+	Supplier          *SupplierHandler
+	SupplierProduct   *SupplierProductHandler
+	SupplierReturn    *SupplierReturnHandler
+	SupplierPayment   *SupplierPaymentHandler
+	Currency          *CurrencyHandler
+	SupplierScorecard *SupplierScorecardHandler
+	Replenishment     *ReplenishmentHandler
+	Company           *CompanyHandler
+	Shop              *ShopHandler
+	Inventory         *InventoryHandler
+	StockAdjustment   *StockAdjustmentHandler
+	StockCount        *StockCountHandler
+	StockReservation  *StockReservationHandler
+	StockLot          *StockLotHandler
+	SerialNumber      *SerialNumberHandler
+	ProductStyle      *ProductStyleHandler
+	Notification      *NotificationHandler
+	StockTransfer     *StockTransferHandler
+	Warehouse         *WarehouseHandler
 }
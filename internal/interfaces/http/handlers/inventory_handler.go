@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InventoryHandler struct {
+	inventoryService services.InventoryService
+}
+
+func NewInventoryHandler(inventoryService services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// GetInventory godoc
+// @Summary List stock
+// @Description Get a paginated list of stock levels with product and shop details. Users other than admins only see their own shop.
+// @Tags inventory
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param shop_id query string false "Shop ID, all shops by default for admins"
+// @Param product_id query string false "Product ID"
+// @Param category query string false "Master category"
+// @Param sub_category query string false "Sub category"
+// @Param size query string false "Size"
+// @Param color query string false "Color"
+// @Param low_stock query bool false "Only stock at or below its reorder point, its category default or the threshold"
+// @Param threshold query int false "Low stock threshold for products without a reorder rule or category default"
+// @Param in_stock query bool false "Only products with stock on hand"
+// @Param location_type query string false "SHOP or WAREHOUSE"
+// @Param search query string false "Search product code or name"
+// @Param sort_by query string false "quantity, average_cost, updated_at, code, name or category"
+// @Param sort_order query string false "asc or desc"
+// @Success 200 {object} map[string]interface{}
+// @Router /inventory [get]
+// @Security BearerAuth
+func (h *InventoryHandler) GetInventory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		filters["product_id"] = productID
+	}
+	for _, field := range []string{"category", "sub_category", "size", "color", "search"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	if lowStock, _ := strconv.ParseBool(c.Query("low_stock")); lowStock {
+		threshold, err := strconv.Atoi(c.Query("threshold"))
+		if err != nil || threshold <= 0 {
+			threshold = services.DefaultLowStockThreshold
+		}
+		filters["low_stock_threshold"] = threshold
+	}
+	if inStock, _ := strconv.ParseBool(c.Query("in_stock")); inStock {
+		filters["in_stock"] = true
+	}
+	if locationType := c.Query("location_type"); locationType != "" {
+		filters["location_type"] = locationType
+	}
+
+	inventory, total, err := h.inventoryService.GetInventory(page, pageSize, filters, c.Query("sort_by"), c.Query("sort_order"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch inventory"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": inventory,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetLowStock godoc
+// @Summary List low stock
+// @Description Get a shop's products at or below their reorder point, or their category's default when they have no reorder rule, or the threshold when neither is set
+// @Tags inventory
+// @Produce json
+// @Param shop_id query string false "Shop ID (defaults to the user's shop)"
+// @Param threshold query int false "Low stock threshold for products without a reorder rule or category default"
+// @Success 200 {array} entities.Inventory
+// @Router /inventory/low-stock [get]
+// @Security BearerAuth
+func (h *InventoryHandler) GetLowStock(c *gin.Context) {
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	threshold, _ := strconv.Atoi(c.Query("threshold"))
+	inventory, err := h.inventoryService.GetLowStock(*shopID, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch low stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
+
+// GetProductStock godoc
+// @Summary Get a product's stock by shop
+// @Description Get one product's stock in every shop that holds it. Users other than admins only see their own shop.
+// @Tags inventory
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Success 200 {object} entities.ProductStock
+// @Router /inventory/products/{product_id} [get]
+// @Security BearerAuth
+func (h *InventoryHandler) GetProductStock(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	stock, err := h.inventoryService.GetProductStock(productID, shopID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch product stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// GetShopTotals godoc
+// @Summary Get stock totals by shop
+// @Description Get the quantity and value of stock held by each shop. Users other than admins only see their own shop.
+// @Tags inventory
+// @Produce json
+// @Param shop_id query string false "Shop ID"
+// @Success 200 {array} entities.ShopStockTotal
+// @Router /inventory/totals [get]
+// @Security BearerAuth
+func (h *InventoryHandler) GetShopTotals(c *gin.Context) {
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	totals, err := h.inventoryService.GetShopTotals(shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock totals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, totals)
+}
+
+// GetStockCard godoc
+// @Summary Get a product's stock card
+// @Description Get every stock movement of a product in a shop over a period, with the document, user, cost and running balance
+// @Tags inventory
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param shop_id query string false "Shop ID (defaults to the user's shop)"
+// @Param date_from query string false "Start date (YYYY-MM-DD), from the first movement by default"
+// @Param date_to query string false "End date (YYYY-MM-DD), up to now by default"
+// @Success 200 {object} entities.StockCard
+// @Router /inventory/products/{product_id}/stock-card [get]
+// @Security BearerAuth
+func (h *InventoryHandler) GetStockCard(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	var from, to *time.Time
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		parsed, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_from format, use YYYY-MM-DD"})
+			return
+		}
+		from = &parsed
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		parsed, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_to format, use YYYY-MM-DD"})
+			return
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		to = &parsed
+	}
+
+	card, err := h.inventoryService.GetStockCard(productID, *shopID, from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock card"})
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// scopedShopID limits a query to the caller's shop. Admins may ask for any
+// shop, or for every shop by asking for none.
+func scopedShopID(c *gin.Context, requested string) (*uuid.UUID, bool) {
+	if c.GetString("role") != string(entities.RoleAdmin) {
+		var own *uuid.UUID
+		if shopID, ok := c.Get("shop_id"); ok {
+			own, _ = shopID.(*uuid.UUID)
+		}
+		if own == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "shop access not configured for user"})
+			return nil, false
+		}
+		if requested != "" && requested != own.String() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied to requested shop"})
+			return nil, false
+		}
+		return own, true
+	}
+
+	if requested == "" {
+		return nil, true
+	}
+	shopID, err := uuid.Parse(requested)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop ID"})
+		return nil, false
+	}
+	return &shopID, true
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type LandedCostHandler struct {
+	landedCostService services.LandedCostService
+}
+
+func NewLandedCostHandler(landedCostService services.LandedCostService) *LandedCostHandler {
+	return &LandedCostHandler{
+		landedCostService: landedCostService,
+	}
+}
+
+// GetLandedCosts godoc
+// @Summary Get purchase landed costs
+// @Description Get a purchase's landed costs and the landed unit cost and margin of each line
+// @Tags purchases
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Success 200 {object} entities.LandedCostSummary
+// @Router /purchases/{id}/landed-costs [get]
+// @Security BearerAuth
+func (h *LandedCostHandler) GetLandedCosts(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	summary, err := h.landedCostService.GetLandedCostSummary(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// AddLandedCost godoc
+// @Summary Add landed cost
+// @Description Add a freight, duty or other charge to a purchase and allocate it to the lines
+// @Tags purchases
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Param cost body entities.CreateLandedCostRequest true "Landed cost"
+// @Success 201 {object} entities.LandedCostSummary
+// @Router /purchases/{id}/landed-costs [post]
+// @Security BearerAuth
+func (h *LandedCostHandler) AddLandedCost(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	var req entities.CreateLandedCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cost := &entities.LandedCost{
+		PurchaseInvoiceID: id,
+		CostType:          entities.LandedCostType(req.CostType),
+		Amount:            req.Amount,
+		AllocationMethod:  entities.LandedCostAllocationMethod(req.AllocationMethod),
+		Remarks:           req.Remarks,
+	}
+
+	summary, err := h.landedCostService.AddLandedCost(cost)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoAllocationBasis) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrNoReceivedStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add landed cost"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, summary)
+}
+
+// RemoveLandedCost godoc
+// @Summary Remove landed cost
+// @Description Remove a landed cost from a purchase and reallocate the remaining costs
+// @Tags purchases
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Param cost_id path string true "Landed Cost ID"
+// @Success 200 {object} entities.LandedCostSummary
+// @Router /purchases/{id}/landed-costs/{cost_id} [delete]
+// @Security BearerAuth
+func (h *LandedCostHandler) RemoveLandedCost(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	costID, err := uuid.Parse(c.Param("cost_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid landed cost ID"})
+		return
+	}
+
+	summary, err := h.landedCostService.RemoveLandedCost(id, costID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationHandler(notificationService services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotifications godoc
+// @Summary List notifications
+// @Description Get the user's inbox, newest first, with how each notification went out through email, SMS or webhook
+// @Tags notifications
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param type query string false "LOW_STOCK, AWAITING_APPROVAL, LARGE_DISCOUNT, IMPORT_FAILED or TEST"
+// @Param shop_id query string false "Shop ID"
+// @Param unread query bool false "Only notifications not read yet"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	for _, field := range []string{"type", "shop_id"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	if unread, _ := strconv.ParseBool(c.Query("unread")); unread {
+		filters["unread"] = true
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	notifications, total, err := h.notificationService.GetNotifications(userID, page, pageSize, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": notifications,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetUnreadCount godoc
+// @Summary Count unread notifications
+// @Description Get how many notifications in the user's inbox are not read yet
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/unread-count [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	count, err := h.notificationService.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count unread notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification read
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 204
+// @Router /notifications/{id}/read [put]
+// @Security BearerAuth
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	if err := h.notificationService.MarkRead(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark notification read"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark every notification read
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/read-all [put]
+// @Security BearerAuth
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	marked, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark notifications read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// GetNotificationPreferences godoc
+// @Summary List notification preferences
+// @Description Get whether each channel is on for each type of alert. Only the in-app inbox is on until the user changes it.
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} entities.NotificationPreference
+// @Router /notifications/preferences [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// SaveNotificationPreference godoc
+// @Summary Save a notification preference
+// @Description Turn a channel on or off for one type of alert, optionally with an email address, phone number or webhook URL other than the user's own. Webhook URLs must be on the configured webhook host or one of NOTIFY_WEBHOOK_HOSTS.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param preference body entities.SaveNotificationPreferenceRequest true "Preference"
+// @Success 200 {object} entities.NotificationPreference
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /notifications/preferences [put]
+// @Security BearerAuth
+func (h *NotificationHandler) SaveNotificationPreference(c *gin.Context) {
+	var req entities.SaveNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preference := &entities.NotificationPreference{
+		UserID:  c.MustGet("user_id").(uuid.UUID),
+		Type:    entities.NotificationType(req.Type),
+		Channel: entities.NotificationChannel(req.Channel),
+		Enabled: *req.Enabled,
+		Target:  req.Target,
+	}
+
+	if err := h.notificationService.SavePreference(preference); err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationTarget) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save notification preference"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// SendTestNotification godoc
+// @Summary Send a test notification
+// @Description Send a notification to the user through the inbox and every channel the user turned on, to check they are set up. Deliveries show on the notification once sent.
+// @Tags notifications
+// @Produce json
+// @Success 201 {object} entities.Notification
+// @Router /notifications/test [post]
+// @Security BearerAuth
+func (h *NotificationHandler) SendTestNotification(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	notification, err := h.notificationService.SendTest(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send test notification"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, notification)
+}
@@ -48,6 +48,9 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	if style := c.Query("style"); style != "" {
 		filters["style"] = style
 	}
+	if styleID := c.Query("style_id"); styleID != "" {
+		filters["style_id"] = styleID
+	}
 	if category := c.Query("master_category"); category != "" {
 		filters["master_category"] = category
 	}
@@ -136,6 +139,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		PurchasePrice:  req.PurchasePrice,
 		SalesPrice:     req.SalesPrice,
 		SalesType:      entities.SalesType(req.SalesType),
+		TrackLots:      req.TrackLots,
+		TrackSerials:   req.TrackSerials,
+		WarrantyMonths: req.WarrantyMonths,
 	}
 
 	shopID, err := uuid.Parse(req.ShopID)
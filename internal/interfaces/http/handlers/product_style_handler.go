@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProductStyleHandler struct {
+	productStyleService services.ProductStyleService
+}
+
+func NewProductStyleHandler(productStyleService services.ProductStyleService) *ProductStyleHandler {
+	return &ProductStyleHandler{
+		productStyleService: productStyleService,
+	}
+}
+
+// GetProductStyles godoc
+// @Summary List product styles
+// @Description Get a paginated list of styles whose colours and sizes are sold as variants
+// @Tags product-styles
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param code query string false "Style code"
+// @Param name query string false "Name, or part of it"
+// @Param master_category query string false "Master category"
+// @Param sub_category query string false "Sub category"
+// @Success 200 {object} map[string]interface{}
+// @Router /product-styles [get]
+// @Security BearerAuth
+func (h *ProductStyleHandler) GetProductStyles(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	for _, field := range []string{"code", "name", "master_category", "sub_category", "sales_type"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	styles, total, err := h.productStyleService.GetProductStyles(page, pageSize, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch product styles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": styles,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetProductStyle godoc
+// @Summary Get a product style by ID
+// @Description Get a style with all of its variants
+// @Tags product-styles
+// @Produce json
+// @Param id path string true "Product Style ID"
+// @Success 200 {object} entities.ProductStyle
+// @Router /product-styles/{id} [get]
+// @Security BearerAuth
+func (h *ProductStyleHandler) GetProductStyle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product style ID"})
+		return
+	}
+
+	style, err := h.productStyleService.GetProductStyleByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product style not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, style)
+}
+
+// CreateProductStyle godoc
+// @Summary Create product style
+// @Description Create a style, with a variant for every combination of the given colours and sizes
+// @Tags product-styles
+// @Accept json
+// @Produce json
+// @Param style body entities.CreateProductStyleRequest true "Style details"
+// @Success 201 {object} entities.ProductStyle
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /product-styles [post]
+// @Security BearerAuth
+func (h *ProductStyleHandler) CreateProductStyle(c *gin.Context) {
+	var req entities.CreateProductStyleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	style := &entities.ProductStyle{
+		Code:           req.Code,
+		Name:           req.Name,
+		MasterCategory: req.MasterCategory,
+		SubCategory:    req.SubCategory,
+		PurchasePrice:  req.PurchasePrice,
+		SalesPrice:     req.SalesPrice,
+		SalesType:      entities.SalesType(req.SalesType),
+		ShopID:         uuid.MustParse(req.ShopID),
+		Remarks:        req.Remarks,
+	}
+
+	if err := h.productStyleService.CreateProductStyle(style, req.Colors, req.Sizes); err != nil {
+		writeProductStyleError(c, err, "failed to create product style")
+		return
+	}
+
+	c.JSON(http.StatusCreated, style)
+}
+
+// UpdateProductStyle godoc
+// @Summary Update product style
+// @Description Change a style's name, categories, prices or sales type. Every variant takes on the change.
+// @Tags product-styles
+// @Accept json
+// @Produce json
+// @Param id path string true "Product Style ID"
+// @Param style body entities.UpdateProductStyleRequest true "Style details"
+// @Success 200 {object} entities.ProductStyle
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /product-styles/{id} [put]
+// @Security BearerAuth
+func (h *ProductStyleHandler) UpdateProductStyle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product style ID"})
+		return
+	}
+
+	var req entities.UpdateProductStyleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	style := &entities.ProductStyle{
+		Name:           req.Name,
+		MasterCategory: req.MasterCategory,
+		SubCategory:    req.SubCategory,
+		PurchasePrice:  req.PurchasePrice,
+		SalesPrice:     req.SalesPrice,
+		SalesType:      entities.SalesType(req.SalesType),
+		Remarks:        req.Remarks,
+	}
+	style.ID = id
+
+	if err := h.productStyleService.UpdateProductStyle(style); err != nil {
+		writeProductStyleError(c, err, "failed to update product style")
+		return
+	}
+
+	c.JSON(http.StatusOK, style)
+}
+
+// GenerateVariants godoc
+// @Summary Generate style variants
+// @Description Add a variant for every combination of the given colours and sizes that the style doesn't have yet
+// @Tags product-styles
+// @Accept json
+// @Produce json
+// @Param id path string true "Product Style ID"
+// @Param grid body entities.GenerateVariantsRequest true "Colours and sizes"
+// @Success 201 {array} entities.Product
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /product-styles/{id}/variants [post]
+// @Security BearerAuth
+func (h *ProductStyleHandler) GenerateVariants(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product style ID"})
+		return
+	}
+
+	var req entities.GenerateVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := h.productStyleService.GenerateVariants(id, req.Colors, req.Sizes)
+	if err != nil {
+		writeProductStyleError(c, err, "failed to generate variants")
+		return
+	}
+
+	c.JSON(http.StatusCreated, variants)
+}
+
+// AttachVariants godoc
+// @Summary Attach products to a style
+// @Description Make existing products variants of the style. They keep their code, colour, size and stock and take on the style's shared attributes.
+// @Tags product-styles
+// @Accept json
+// @Produce json
+// @Param id path string true "Product Style ID"
+// @Param products body entities.AttachVariantsRequest true "Products"
+// @Success 200 {object} entities.ProductStyle
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /product-styles/{id}/variants/attach [post]
+// @Security BearerAuth
+func (h *ProductStyleHandler) AttachVariants(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product style ID"})
+		return
+	}
+
+	var req entities.AttachVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.ProductIDs))
+	var productIDs []uuid.UUID
+	for _, productID := range req.ProductIDs {
+		id := uuid.MustParse(productID)
+		if !seen[id] {
+			seen[id] = true
+			productIDs = append(productIDs, id)
+		}
+	}
+
+	style, err := h.productStyleService.AttachVariants(id, productIDs)
+	if err != nil {
+		writeProductStyleError(c, err, "failed to attach variants")
+		return
+	}
+
+	c.JSON(http.StatusOK, style)
+}
+
+// GetStockMatrix godoc
+// @Summary Get a style's stock matrix
+// @Description Get a style's stock with a row per colour and a column per size. Users other than admins only see their own shop; admins see every shop unless they name one.
+// @Tags product-styles
+// @Produce json
+// @Param id path string true "Product Style ID"
+// @Param shop_id query string false "Shop ID"
+// @Success 200 {object} entities.StockMatrix
+// @Router /product-styles/{id}/stock-matrix [get]
+// @Security BearerAuth
+func (h *ProductStyleHandler) GetStockMatrix(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product style ID"})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	matrix, err := h.productStyleService.GetStockMatrix(id, shopID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product style not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock matrix"})
+		return
+	}
+
+	c.JSON(http.StatusOK, matrix)
+}
+
+// GetStyleStock godoc
+// @Summary Get stock per style
+// @Description Get the stock of every style with its variants added together. Users other than admins only see their own shop.
+// @Tags product-styles
+// @Produce json
+// @Param shop_id query string false "Shop ID"
+// @Param master_category query string false "Master category"
+// @Param sub_category query string false "Sub category"
+// @Success 200 {array} entities.StyleStock
+// @Router /product-styles/stock [get]
+// @Security BearerAuth
+func (h *ProductStyleHandler) GetStyleStock(c *gin.Context) {
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"master_category", "sub_category"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	stock, err := h.productStyleService.GetStyleStock(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch style stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// writeProductStyleError reports styles or products that don't exist as not
+// found, blank colours and sizes as bad requests and clashing variants as
+// conflicts
+func writeProductStyleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrBlankColorOrSize):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "product style or product not found"})
+	case errors.Is(err, repository.ErrStyleCodeTaken), errors.Is(err, repository.ErrVariantCodeTaken), errors.Is(err, repository.ErrVariantExists), errors.Is(err, repository.ErrVariantOfAnotherStyle):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,16 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
 	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PurchaseHandler struct {
@@ -31,6 +34,9 @@ func (h *PurchaseHandler) GetPurchases(c *gin.Context) {
 	if supplierID := c.Query("supplier_id"); supplierID != "" {
 		filters["supplier_id"] = supplierID
 	}
+	if shopID := c.Query("shop_id"); shopID != "" {
+		filters["shop_id"] = shopID
+	}
 	if paymentType := c.Query("payment_type"); paymentType != "" {
 		filters["payment_type"] = paymentType
 	}
@@ -93,14 +99,32 @@ func (h *PurchaseHandler) CreatePurchase(c *gin.Context) {
 	purchase.PurchaseDateTime = time.Now()
 
 	// Get user from context for entry_by
-	user, exists := c.Get("user")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
 		return
 	}
-	purchase.EntryByID = user.(entities.User).ID
+	purchase.EntryByID = userID.(uuid.UUID)
+
+	// Receive into the user's own shop when none is given
+	if purchase.ShopID == uuid.Nil {
+		if shopID, ok := c.Get("shop_id"); ok {
+			if sid, ok := shopID.(*uuid.UUID); ok && sid != nil {
+				purchase.ShopID = *sid
+			}
+		}
+	}
 
 	if err := h.purchaseService.CreatePurchase(&purchase); err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrPurchaseShopRequired) || errors.Is(err, services.ErrInvalidPurchaseQuantity) ||
+			errors.Is(err, services.ErrPurchaseOrderMismatch) || errors.Is(err, services.ErrUnknownCurrency) ||
+			errors.Is(err, services.ErrNoExchangeRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create purchase"})
 		return
 	}
@@ -116,9 +140,147 @@ func (h *PurchaseHandler) DeletePurchase(c *gin.Context) {
 	}
 
 	if err := h.purchaseService.DeletePurchase(id); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": "purchased stock has already been sold or moved and cannot be reversed"})
+			return
+		}
+		if errors.Is(err, repository.ErrPurchaseHasReturns) || errors.Is(err, repository.ErrPurchaseHasPayments) ||
+			errors.Is(err, repository.ErrPurchaseLocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "purchase deleted successfully"})
-}
\ No newline at end of file
+}
+
+// UpdatePurchase godoc
+// @Summary Edit a purchase
+// @Description Replace a purchase's header and lines, keeping the previous version as a revision. Only the change in each line's quantity moves stock. Locked, fully paid and returned-against purchases can't be edited.
+// @Tags purchases
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Param purchase body entities.UpdatePurchaseRequest true "Edited purchase"
+// @Success 200 {object} entities.PurchaseInvoice
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /purchases/{id} [put]
+// @Security BearerAuth
+func (h *PurchaseHandler) UpdatePurchase(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	var req entities.UpdatePurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchase, err := h.purchaseService.UpdatePurchase(id, &req, c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+		case errors.Is(err, services.ErrInvalidPurchaseQuantity) || errors.Is(err, services.ErrPurchaseOrderMismatch) ||
+			errors.Is(err, repository.ErrUnknownPurchaseEdit) || errors.Is(err, repository.ErrNoAllocationBasis):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrPurchaseLocked) || errors.Is(err, repository.ErrPurchaseHasReturns) ||
+			errors.Is(err, repository.ErrPurchaseSettled) || errors.Is(err, repository.ErrPurchaseBelowPaid) ||
+			errors.Is(err, repository.ErrNoReceivedStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "purchased stock has already been sold or moved and cannot be reduced"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update purchase"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, purchase)
+}
+
+// LockPurchase godoc
+// @Summary Lock a purchase
+// @Description Lock a purchase so it can no longer be edited or deleted
+// @Tags purchases
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Success 200 {object} entities.PurchaseInvoice
+// @Router /purchases/{id}/lock [post]
+// @Security BearerAuth
+func (h *PurchaseHandler) LockPurchase(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	purchase, err := h.purchaseService.LockPurchase(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lock purchase"})
+		return
+	}
+
+	c.JSON(http.StatusOK, purchase)
+}
+
+// GetRevisions godoc
+// @Summary List purchase revisions
+// @Description Get the earlier versions of a purchase, each with its lines, who edited it and why
+// @Tags purchases
+// @Produce json
+// @Param id path string true "Purchase ID"
+// @Success 200 {array} entities.PurchaseRevision
+// @Router /purchases/{id}/revisions [get]
+// @Security BearerAuth
+func (h *PurchaseHandler) GetRevisions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase ID"})
+		return
+	}
+
+	revisions, err := h.purchaseService.GetRevisions(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get purchase revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *PurchaseHandler) GetPriceHistory(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	history, err := h.purchaseService.GetPriceHistory(productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get price history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
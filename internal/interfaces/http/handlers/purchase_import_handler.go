@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PurchaseImportHandler struct {
+	purchaseImportService services.PurchaseImportService
+}
+
+func NewPurchaseImportHandler(purchaseImportService services.PurchaseImportService) *PurchaseImportHandler {
+	return &PurchaseImportHandler{
+		purchaseImportService: purchaseImportService,
+	}
+}
+
+// PreviewImport godoc
+// @Summary Preview a supplier invoice import
+// @Description Parse a supplier's CSV or XLSX invoice and match its lines to products without saving anything
+// @Tags purchases
+// @Accept multipart/form-data
+// @Produce json
+// @Param supplier_id formData string true "Supplier ID"
+// @Param file formData file true "CSV or XLSX file with code, quantity and price columns"
+// @Success 200 {object} entities.PurchaseImportPreview
+// @Failure 400 {object} map[string]string
+// @Router /purchases/import/preview [post]
+// @Security BearerAuth
+func (h *PurchaseImportHandler) PreviewImport(c *gin.Context) {
+	supplierID, err := uuid.Parse(c.PostForm("supplier_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file uploaded"})
+		return
+	}
+
+	if file.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty file"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer f.Close()
+
+	preview, err := h.purchaseImportService.PreviewImport(supplierID, file.Filename, f)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedImportFile) || errors.Is(err, services.ErrImportMissingColumns) ||
+			errors.Is(err, services.ErrImportEmpty) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read supplier invoice"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// ConfirmImport godoc
+// @Summary Confirm a supplier invoice import
+// @Description Create the purchase from the reviewed import lines and remember the supplier's product codes
+// @Tags purchases
+// @Accept json
+// @Produce json
+// @Param import body entities.ConfirmPurchaseImportRequest true "Reviewed import"
+// @Success 201 {object} entities.PurchaseInvoice
+// @Router /purchases/import/confirm [post]
+// @Security BearerAuth
+func (h *PurchaseImportHandler) ConfirmImport(c *gin.Context) {
+	var req entities.ConfirmPurchaseImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchase := &entities.PurchaseInvoice{
+		SupplierID:       uuid.MustParse(req.SupplierID),
+		PurchaseDateTime: time.Now(),
+		PaymentType:      entities.PaymentType(req.PaymentType),
+		CurrencyCode:     req.CurrencyCode,
+		ExchangeRate:     req.ExchangeRate,
+		EntryByID:        c.MustGet("user_id").(uuid.UUID),
+		Remarks:          req.Remarks,
+	}
+
+	// Receive into the user's own shop when none is given
+	if req.ShopID != "" {
+		purchase.ShopID = uuid.MustParse(req.ShopID)
+	} else if shopID, ok := c.Get("shop_id"); ok {
+		if sid, ok := shopID.(*uuid.UUID); ok && sid != nil {
+			purchase.ShopID = *sid
+		}
+	}
+
+	supplierCodes := make(map[uuid.UUID]string)
+	for _, line := range req.Lines {
+		productID := uuid.MustParse(line.ProductID)
+		purchase.PurchaseDetails = append(purchase.PurchaseDetails, entities.PurchaseDetail{
+			ProductID:     productID,
+			Quantity:      line.Quantity,
+			PurchasePrice: line.UnitPrice,
+			CurrencyPrice: line.UnitPrice,
+		})
+		if line.SupplierCode != "" {
+			supplierCodes[productID] = line.SupplierCode
+		}
+	}
+
+	if err := h.purchaseImportService.ConfirmImport(purchase, supplierCodes); err != nil {
+		if errors.Is(err, services.ErrPurchaseShopRequired) || errors.Is(err, services.ErrInvalidPurchaseQuantity) ||
+			errors.Is(err, services.ErrUnknownCurrency) || errors.Is(err, services.ErrNoExchangeRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create purchase"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchase)
+}
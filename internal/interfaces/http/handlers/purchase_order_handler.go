@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderService services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(purchaseOrderService services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderService: purchaseOrderService,
+	}
+}
+
+// GetPurchaseOrders godoc
+// @Summary List purchase orders
+// @Description Get a paginated list of purchase orders with optional filters
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param status query string false "Order status"
+// @Success 200 {object} map[string]interface{}
+// @Router /purchase-orders [get]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		filters["supplier_id"] = supplierID
+	}
+	if shopID := c.Query("shop_id"); shopID != "" {
+		filters["shop_id"] = shopID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	orders, total, err := h.purchaseOrderService.GetPurchaseOrders(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch purchase orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": orders,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetPurchaseOrder godoc
+// @Summary Get a purchase order by ID
+// @Description Get a purchase order with its lines and received quantities
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} entities.PurchaseOrder
+// @Router /purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	order, err := h.purchaseOrderService.GetPurchaseOrderByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "purchase order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CreatePurchaseOrder godoc
+// @Summary Create purchase order
+// @Description Create a draft purchase order for a supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param order body entities.CreatePurchaseOrderRequest true "Purchase order details"
+// @Success 201 {object} entities.PurchaseOrder
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /purchase-orders [post]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req entities.CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := &entities.PurchaseOrder{
+		SupplierID:       uuid.MustParse(req.SupplierID),
+		ShopID:           uuid.MustParse(req.ShopID),
+		OrderDateTime:    time.Now(),
+		ExpectedDateTime: req.ExpectedDateTime,
+		CreatedByID:      c.MustGet("user_id").(uuid.UUID),
+		Remarks:          req.Remarks,
+	}
+	for _, item := range req.Items {
+		order.Lines = append(order.Lines, entities.PurchaseOrderLine{
+			ProductID:       uuid.MustParse(item.ProductID),
+			OrderedQuantity: item.Quantity,
+			UnitPrice:       item.UnitPrice,
+		})
+	}
+
+	if err := h.purchaseOrderService.CreatePurchaseOrder(order); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// SendPurchaseOrder godoc
+// @Summary Send purchase order
+// @Description Mark a draft purchase order as sent to the supplier
+// @Tags purchase-orders
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} entities.PurchaseOrder
+// @Router /purchase-orders/{id}/send [post]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) SendPurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	order, err := h.purchaseOrderService.SendPurchaseOrder(id)
+	if err != nil {
+		if errors.Is(err, services.ErrPurchaseOrderNotDraft) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ClosePurchaseOrder godoc
+// @Summary Close purchase order
+// @Description Close a purchase order, cancelling any quantities still outstanding
+// @Tags purchase-orders
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} entities.PurchaseOrder
+// @Router /purchase-orders/{id}/close [post]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	order, err := h.purchaseOrderService.ClosePurchaseOrder(id)
+	if err != nil {
+		if errors.Is(err, services.ErrPurchaseOrderClosed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ReceiveGoods godoc
+// @Summary Receive goods
+// @Description Record a goods receipt against a purchase order and add the quantities to the shop's stock
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Param receipt body entities.CreateGoodsReceiptRequest true "Received lines"
+// @Success 201 {object} entities.GoodsReceipt
+// @Router /purchase-orders/{id}/receipts [post]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) ReceiveGoods(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	var req entities.CreateGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	receipt := &entities.GoodsReceipt{
+		PurchaseOrderID:  id,
+		ReceivedDateTime: time.Now(),
+		ReceivedByID:     c.MustGet("user_id").(uuid.UUID),
+		Remarks:          req.Remarks,
+	}
+	for _, item := range req.Items {
+		receipt.Lines = append(receipt.Lines, entities.GoodsReceiptLine{
+			PurchaseOrderLineID: uuid.MustParse(item.PurchaseOrderLineID),
+			Quantity:            item.Quantity,
+			LotNumber:           item.LotNumber,
+			SerialNumbers:       item.SerialNumbers,
+		})
+	}
+
+	if err := h.purchaseOrderService.ReceiveGoods(receipt); err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrPurchaseOrderNotReceiving):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrReceiptExceedsOrder), errors.Is(err, repository.ErrUnknownOrderLine):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, receipt)
+}
+
+// GetGoodsReceipts godoc
+// @Summary List goods receipts
+// @Description Get all goods receipts recorded against a purchase order
+// @Tags purchase-orders
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {array} entities.GoodsReceipt
+// @Router /purchase-orders/{id}/receipts [get]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) GetGoodsReceipts(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	receipts, err := h.purchaseOrderService.GetGoodsReceipts(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, receipts)
+}
+
+// GetMatchReport godoc
+// @Summary Purchase order match report
+// @Description Compare ordered, received and invoiced quantities and prices for a purchase order
+// @Tags purchase-orders
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} entities.PurchaseMatchReport
+// @Router /purchase-orders/{id}/match [get]
+// @Security BearerAuth
+func (h *PurchaseOrderHandler) GetMatchReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid purchase order ID"})
+		return
+	}
+
+	report, err := h.purchaseOrderService.GetMatchReport(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "purchase order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errShopRequired = errors.New("shop_id is required")
+
+type ReplenishmentHandler struct {
+	replenishmentService services.ReplenishmentService
+}
+
+func NewReplenishmentHandler(replenishmentService services.ReplenishmentService) *ReplenishmentHandler {
+	return &ReplenishmentHandler{
+		replenishmentService: replenishmentService,
+	}
+}
+
+// GetReorderRules godoc
+// @Summary List reorder rules
+// @Description Get the min, max and reorder point of every product with a rule in a shop
+// @Tags replenishment
+// @Produce json
+// @Param shop_id query string false "Shop ID (defaults to the user's shop)"
+// @Success 200 {array} entities.ReorderRule
+// @Router /replenishment/rules [get]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) GetReorderRules(c *gin.Context) {
+	shopID, err := resolveShopID(c, c.Query("shop_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := h.replenishmentService.GetRules(shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reorder rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// SaveReorderRule godoc
+// @Summary Save a reorder rule
+// @Description Create or replace a product's min, max and reorder point in a shop
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param rule body entities.UpsertReorderRuleRequest true "Reorder rule"
+// @Success 200 {object} entities.ReorderRule
+// @Router /replenishment/rules [put]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) SaveReorderRule(c *gin.Context) {
+	var req entities.UpsertReorderRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, err := resolveShopID(c, req.ShopID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &entities.ReorderRule{
+		ProductID:    uuid.MustParse(req.ProductID),
+		ShopID:       shopID,
+		MinQuantity:  req.MinQuantity,
+		MaxQuantity:  req.MaxQuantity,
+		ReorderPoint: req.ReorderPoint,
+	}
+
+	if err := h.replenishmentService.SaveRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save reorder rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// ImportReorderRules godoc
+// @Summary Import reorder rules
+// @Description Set the min, max and reorder point of many products in a shop from a CSV or XLSX file. Rows that can't be applied are reported and the rest are saved.
+// @Tags replenishment
+// @Accept multipart/form-data
+// @Produce json
+// @Param shop_id formData string false "Shop ID (defaults to the user's shop)"
+// @Param file formData file true "CSV or XLSX file with code, min, max and reorder_point columns"
+// @Success 200 {object} entities.ReorderRuleImportResult
+// @Failure 400 {object} map[string]string
+// @Router /replenishment/rules/import [post]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) ImportReorderRules(c *gin.Context) {
+	shopID, err := resolveShopID(c, c.PostForm("shop_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file uploaded"})
+		return
+	}
+
+	if file.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty file"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer f.Close()
+
+	result, err := h.replenishmentService.ImportRules(shopID, file.Filename, f)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedImportFile) || errors.Is(err, services.ErrReorderImportMissingColumns) ||
+			errors.Is(err, services.ErrReorderImportEmpty) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import reorder rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCategoryDefaults godoc
+// @Summary List category reorder defaults
+// @Description Get the min, max and reorder point used in a shop for products of each master category that have no reorder rule
+// @Tags replenishment
+// @Produce json
+// @Param shop_id query string false "Shop ID (defaults to the user's shop)"
+// @Success 200 {array} entities.CategoryReorderDefault
+// @Router /replenishment/category-defaults [get]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) GetCategoryDefaults(c *gin.Context) {
+	shopID, err := resolveShopID(c, c.Query("shop_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	defaults, err := h.replenishmentService.GetCategoryDefaults(shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get category reorder defaults"})
+		return
+	}
+
+	c.JSON(http.StatusOK, defaults)
+}
+
+// SaveCategoryDefault godoc
+// @Summary Save a category reorder default
+// @Description Create or replace the min, max and reorder point of a master category in a shop
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param default body entities.UpsertCategoryReorderDefaultRequest true "Category default"
+// @Success 200 {object} entities.CategoryReorderDefault
+// @Router /replenishment/category-defaults [put]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) SaveCategoryDefault(c *gin.Context) {
+	var req entities.UpsertCategoryReorderDefaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, err := resolveShopID(c, req.ShopID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categoryDefault := &entities.CategoryReorderDefault{
+		ShopID:         shopID,
+		MasterCategory: req.MasterCategory,
+		MinQuantity:    req.MinQuantity,
+		MaxQuantity:    req.MaxQuantity,
+		ReorderPoint:   req.ReorderPoint,
+	}
+
+	if err := h.replenishmentService.SaveCategoryDefault(categoryDefault); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save category reorder default"})
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryDefault)
+}
+
+// GetSuggestions godoc
+// @Summary Get reorder suggestions
+// @Description Work out suggested order quantities from reorder rules, category defaults and recent sales, grouped by supplier
+// @Tags replenishment
+// @Produce json
+// @Param shop_id query string false "Shop ID (defaults to the user's shop)"
+// @Param velocity_days query int false "Days of sales used for the sales velocity" default(30)
+// @Param lead_time_days query int false "Supplier lead time in days" default(7)
+// @Param cover_days query int false "Days of sales to order for beyond the lead time" default(30)
+// @Success 200 {object} entities.ReplenishmentPlan
+// @Router /replenishment/suggestions [get]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) GetSuggestions(c *gin.Context) {
+	var req entities.ReplenishmentRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, err := resolveShopID(c, req.ShopID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.replenishmentService.GetSuggestions(shopID, replenishmentParams(req))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to work out reorder suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// CreateDraftOrders godoc
+// @Summary Create draft purchase orders
+// @Description Raise one draft purchase order per supplier from the current reorder suggestions
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param run body entities.ReplenishmentRequest true "Replenishment run"
+// @Success 201 {array} entities.PurchaseOrder
+// @Router /replenishment/purchase-orders [post]
+// @Security BearerAuth
+func (h *ReplenishmentHandler) CreateDraftOrders(c *gin.Context) {
+	var req entities.ReplenishmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, err := resolveShopID(c, req.ShopID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		productIDs = append(productIDs, uuid.MustParse(id))
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	orders, err := h.replenishmentService.CreateDraftOrders(shopID, replenishmentParams(req), productIDs, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNothingToReorder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create draft purchase orders"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, orders)
+}
+
+func replenishmentParams(req entities.ReplenishmentRequest) entities.ReplenishmentParams {
+	return entities.ReplenishmentParams{
+		VelocityDays: req.VelocityDays,
+		LeadTimeDays: req.LeadTimeDays,
+		CoverDays:    req.CoverDays,
+	}
+}
+
+// resolveShopID picks the shop a request works on. Users assigned to a shop
+// always work on their own shop; others must name one.
+func resolveShopID(c *gin.Context, requested string) (uuid.UUID, error) {
+	if shopID, ok := c.Get("shop_id"); ok {
+		if sid, ok := shopID.(*uuid.UUID); ok && sid != nil {
+			return *sid, nil
+		}
+	}
+	if requested == "" {
+		return uuid.Nil, errShopRequired
+	}
+	return uuid.Parse(requested)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
 
 	"github.com/gin-gonic/gin"
@@ -118,13 +120,26 @@ func (h *SalesHandler) CreateSale(c *gin.Context) {
 	}
 
 	// Set current shop if not admin
-	if shopID, exists := c.Get("shop_id"); exists && shopID != nil {
-		sale.ShopID = shopID.(uuid.UUID)
+	if shopID, exists := c.Get("shop_id"); exists {
+		if sid, ok := shopID.(*uuid.UUID); ok && sid != nil {
+			sale.ShopID = *sid
+		}
 	}
 
 	sale.SaleDateTime = time.Now()
 
 	if err := h.salesService.CreateSale(&sale); err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrReservationNotActive):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, repository.ErrReservationMismatch), errors.Is(err, repository.ErrLotNotTracked), errors.Is(err, repository.ErrLotProductMismatch), errors.Is(err, repository.ErrSaleAtWarehouse):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -248,3 +263,46 @@ func (h *SalesHandler) GetLast7DaysSales(c *gin.Context) {
 
 	c.JSON(http.StatusOK, sales)
 }
+
+// GetGrossMargins godoc
+// @Summary Get gross margins
+// @Description Get revenue, cost of goods sold and gross margin grouped by invoice, product, shop or style. Grouping by style rolls the sales of a style's colours and sizes into one line.
+// @Tags sales
+// @Accept json
+// @Produce json
+// @Param group_by query string false "Grouping (invoice, product, shop or style)" default(product)
+// @Param shop_id query string false "Shop ID"
+// @Param product_id query string false "Product ID"
+// @Param style_id query string false "Product style ID"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {array} entities.GrossMargin
+// @Router /sales/margins [get]
+// @Security BearerAuth
+func (h *SalesHandler) GetGrossMargins(c *gin.Context) {
+	filters := make(map[string]interface{})
+	for _, key := range []string{"shop_id", "product_id", "style_id", "date_from", "date_to"} {
+		if value := c.Query(key); value != "" {
+			filters[key] = value
+		}
+	}
+
+	// Shop users only see their own shop
+	if shopID, exists := c.Get("shop_id"); exists {
+		if sid, ok := shopID.(*uuid.UUID); ok && sid != nil {
+			filters["shop_id"] = *sid
+		}
+	}
+
+	margins, err := h.salesService.GetGrossMargins(c.DefaultQuery("group_by", "product"), filters)
+	if err != nil {
+		if errors.Is(err, repository.ErrUnknownMarginGrouping) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, margins)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SerialNumberHandler struct {
+	serialNumberService services.SerialNumberService
+}
+
+func NewSerialNumberHandler(serialNumberService services.SerialNumberService) *SerialNumberHandler {
+	return &SerialNumberHandler{
+		serialNumberService: serialNumberService,
+	}
+}
+
+// GetSerialNumbers godoc
+// @Summary List serial numbers
+// @Description Get a paginated list of serialised units. Users other than admins only see their own shop.
+// @Tags serial-numbers
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param product_id query string false "Product ID"
+// @Param shop_id query string false "Shop ID"
+// @Param status query string false "IN_STOCK, IN_TRANSIT, SOLD, RETURNED_TO_SUPPLIER or REMOVED"
+// @Param serial_number query string false "Serial number, or part of it"
+// @Param sales_invoice_id query string false "Sales invoice ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /serial-numbers [get]
+// @Security BearerAuth
+func (h *SerialNumberHandler) GetSerialNumbers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"product_id", "status", "serial_number", "sales_invoice_id"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	serials, total, err := h.serialNumberService.GetSerialNumbers(page, pageSize, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch serial numbers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": serials,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetSerialNumber godoc
+// @Summary Get a serial number by ID
+// @Description Get a serialised unit with its full history and warranty cover
+// @Tags serial-numbers
+// @Produce json
+// @Param id path string true "Serial Number ID"
+// @Success 200 {object} entities.SerialLookup
+// @Router /serial-numbers/{id} [get]
+// @Security BearerAuth
+func (h *SerialNumberHandler) GetSerialNumber(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid serial number ID"})
+		return
+	}
+
+	serial, err := h.serialNumberService.GetSerialNumberByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "serial number not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, serial)
+}
+
+// LookupSerialNumber godoc
+// @Summary Look up a serial number
+// @Description Find a unit by its serial number, for example for a warranty claim, with where it was received, moved and sold, the customer who bought it and whether its warranty still runs
+// @Tags serial-numbers
+// @Produce json
+// @Param serial_number path string true "Serial number"
+// @Success 200 {array} entities.SerialLookup
+// @Router /serial-numbers/lookup/{serial_number} [get]
+// @Security BearerAuth
+func (h *SerialNumberHandler) LookupSerialNumber(c *gin.Context) {
+	serials, err := h.serialNumberService.LookupSerialNumber(c.Param("serial_number"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up serial number"})
+		return
+	}
+	if len(serials) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "serial number not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, serials)
+}
+
+// RegisterSerialNumbers godoc
+// @Summary Register serial numbers of stock on hand
+// @Description Record the serial numbers of units a shop held before their product tracked serial numbers, so they can be sold and transferred
+// @Tags serial-numbers
+// @Accept json
+// @Produce json
+// @Param serials body entities.RegisterSerialNumbersRequest true "Serial numbers"
+// @Success 201 {array} entities.SerialNumber
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /serial-numbers/register [post]
+// @Security BearerAuth
+func (h *SerialNumberHandler) RegisterSerialNumbers(c *gin.Context) {
+	var req entities.RegisterSerialNumbersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, req.ShopID)
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	serials, err := h.serialNumberService.RegisterSerialNumbers(uuid.MustParse(req.ProductID), *shopID, req.SerialNumbers, c.MustGet("user_id").(uuid.UUID))
+	if err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrSerialsExceedStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register serial numbers"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, serials)
+}
+
+// SetSerialTracking godoc
+// @Summary Turn serial number tracking on or off
+// @Description Make a product follow every unit by its serial number, and set the warranty its units are sold with. Units on hand when tracking starts must be registered before they can be sold.
+// @Tags serial-numbers
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param tracking body entities.SetSerialTrackingRequest true "Tracking"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /serial-numbers/products/{product_id}/tracking [put]
+// @Security BearerAuth
+func (h *SerialNumberHandler) SetSerialTracking(c *gin.Context) {
+	if c.GetString("role") != string(entities.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change serial number tracking"})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	var req entities.SetSerialTrackingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.serialNumberService.SetSerialTracking(productID, *req.Enabled, req.WarrantyMonths); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change serial number tracking"})
+		return
+	}
+
+	response := gin.H{"product_id": productID, "track_serials": *req.Enabled}
+	if req.WarrantyMonths != nil {
+		response["warranty_months"] = *req.WarrantyMonths
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeSerialError reports serial numbers that are missing, doubled or in
+// the wrong place and returns whether err was one of those
+func writeSerialError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, repository.ErrSerialsRequired), errors.Is(err, repository.ErrSerialNotTracked), errors.Is(err, repository.ErrDuplicateSerial):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrSerialNotInStock), errors.Is(err, repository.ErrSerialInStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
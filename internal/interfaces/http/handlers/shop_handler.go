@@ -31,6 +31,7 @@ func NewShopHandler(shopService services.ShopService) *ShopHandler {
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
 // @Param company_id query string false "Filter by company ID"
+// @Param type query string false "SHOP or WAREHOUSE"
 // @Success 200 {object} map[string]interface{}
 // @Router /shops [get]
 // @Security BearerAuth
@@ -58,6 +59,9 @@ func (h *ShopHandler) GetShops(c *gin.Context) {
 	if companyID := c.Query("company_id"); companyID != "" {
 		filters["company_id"] = companyID
 	}
+	if locationType := c.Query("type"); locationType != "" {
+		filters["type"] = locationType
+	}
 
 	// Get sort parameters
 	var sorts []string
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockAdjustmentHandler struct {
+	stockAdjustmentService services.StockAdjustmentService
+}
+
+func NewStockAdjustmentHandler(stockAdjustmentService services.StockAdjustmentService) *StockAdjustmentHandler {
+	return &StockAdjustmentHandler{
+		stockAdjustmentService: stockAdjustmentService,
+	}
+}
+
+// GetStockAdjustments godoc
+// @Summary List stock adjustments
+// @Description Get a paginated list of stock adjustments. Users other than admins only see their own shop.
+// @Tags stock-adjustments
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param shop_id query string false "Shop ID"
+// @Param reason query string false "DAMAGE, THEFT, LOSS, COUNT_CORRECTION, GIFT, SAMPLE or OTHER"
+// @Param status query string false "PENDING_APPROVAL, APPROVED or REJECTED"
+// @Param date_from query string false "Start date"
+// @Param date_to query string false "End date"
+// @Success 200 {object} map[string]interface{}
+// @Router /stock-adjustments [get]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) GetStockAdjustments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"reason", "status", "date_from", "date_to"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	adjustments, total, err := h.stockAdjustmentService.GetStockAdjustments(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock adjustments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": adjustments,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetStockAdjustment godoc
+// @Summary Get a stock adjustment by ID
+// @Description Get a stock adjustment with its lines
+// @Tags stock-adjustments
+// @Produce json
+// @Param id path string true "Stock Adjustment ID"
+// @Success 200 {object} entities.StockAdjustment
+// @Router /stock-adjustments/{id} [get]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) GetStockAdjustment(c *gin.Context) {
+	adjustment, ok := h.loadScopedAdjustment(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// CreateStockAdjustment godoc
+// @Summary Create stock adjustment
+// @Description Adjust a shop's stock for damage, theft, count corrections, gifts or samples. Adjustments raised by staff and worth more than the approval threshold wait for a manager.
+// @Tags stock-adjustments
+// @Accept json
+// @Produce json
+// @Param adjustment body entities.CreateStockAdjustmentRequest true "Stock adjustment details"
+// @Success 201 {object} entities.StockAdjustment
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /stock-adjustments [post]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) CreateStockAdjustment(c *gin.Context) {
+	var req entities.CreateStockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, req.ShopID)
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	adjustment := &entities.StockAdjustment{
+		ShopID:             *shopID,
+		AdjustmentDateTime: time.Now(),
+		Reason:             entities.AdjustmentReason(req.Reason),
+		Notes:              req.Notes,
+		CreatedByID:        c.MustGet("user_id").(uuid.UUID),
+	}
+	for _, line := range req.Lines {
+		adjustment.Lines = append(adjustment.Lines, entities.StockAdjustmentLine{
+			ProductID: uuid.MustParse(line.ProductID),
+			Quantity:  line.Quantity,
+		})
+	}
+
+	if err := h.stockAdjustmentService.CreateStockAdjustment(adjustment, c.GetString("role")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptyAdjustment):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "product not found"})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "not enough stock in the shop to write off"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create stock adjustment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustment)
+}
+
+// ApproveStockAdjustment godoc
+// @Summary Approve stock adjustment
+// @Description Approve a pending stock adjustment and move its stock. Managers and admins only.
+// @Tags stock-adjustments
+// @Produce json
+// @Param id path string true "Stock Adjustment ID"
+// @Success 200 {object} entities.StockAdjustment
+// @Router /stock-adjustments/{id}/approve [post]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) ApproveStockAdjustment(c *gin.Context) {
+	existing, ok := h.loadScopedAdjustment(c)
+	if !ok {
+		return
+	}
+
+	adjustment, err := h.stockAdjustmentService.ApproveStockAdjustment(existing.ID, c.MustGet("user_id").(uuid.UUID), c.GetString("role"))
+	if err != nil {
+		respondAdjustmentDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// RejectStockAdjustment godoc
+// @Summary Reject stock adjustment
+// @Description Reject a pending stock adjustment without moving stock. Managers and admins only.
+// @Tags stock-adjustments
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock Adjustment ID"
+// @Param rejection body entities.RejectStockAdjustmentRequest true "Rejection reason"
+// @Success 200 {object} entities.StockAdjustment
+// @Router /stock-adjustments/{id}/reject [post]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) RejectStockAdjustment(c *gin.Context) {
+	var req entities.RejectStockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, ok := h.loadScopedAdjustment(c)
+	if !ok {
+		return
+	}
+
+	adjustment, err := h.stockAdjustmentService.RejectStockAdjustment(existing.ID, c.MustGet("user_id").(uuid.UUID), c.GetString("role"), req.Reason)
+	if err != nil {
+		respondAdjustmentDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// GetShrinkageReport godoc
+// @Summary Shrinkage report
+// @Description Total the stock lost and found through approved adjustments by shop and reason. Users other than admins only see their own shop.
+// @Tags stock-adjustments
+// @Produce json
+// @Param shop_id query string false "Shop ID"
+// @Param reason query string false "Adjustment reason"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} entities.ShrinkageReport
+// @Router /stock-adjustments/shrinkage [get]
+// @Security BearerAuth
+func (h *StockAdjustmentHandler) GetShrinkageReport(c *gin.Context) {
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	if reason := c.Query("reason"); reason != "" {
+		filters["reason"] = reason
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		from, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_from format, use YYYY-MM-DD"})
+			return
+		}
+		filters["date_from"] = from
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		to, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_to format, use YYYY-MM-DD"})
+			return
+		}
+		filters["date_to"] = to.AddDate(0, 0, 1)
+	}
+
+	report, err := h.stockAdjustmentService.GetShrinkageReport(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build shrinkage report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// loadScopedAdjustment loads the adjustment in the path, refusing users of
+// other shops
+func (h *StockAdjustmentHandler) loadScopedAdjustment(c *gin.Context) (*entities.StockAdjustment, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stock adjustment ID"})
+		return nil, false
+	}
+
+	adjustment, err := h.stockAdjustmentService.GetStockAdjustmentByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stock adjustment not found"})
+		return nil, false
+	}
+	if _, ok := scopedShopID(c, adjustment.ShopID.String()); !ok {
+		return nil, false
+	}
+	return adjustment, true
+}
+
+func respondAdjustmentDecisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAdjustmentNotPermitted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrAdjustmentNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{"error": "not enough stock in the shop to write off"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update stock adjustment"})
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockCountHandler struct {
+	stockCountService services.StockCountService
+}
+
+func NewStockCountHandler(stockCountService services.StockCountService) *StockCountHandler {
+	return &StockCountHandler{
+		stockCountService: stockCountService,
+	}
+}
+
+// GetStockCounts godoc
+// @Summary List stock counts
+// @Description Get a paginated list of stock counts. Users other than admins only see their own shop.
+// @Tags stock-counts
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param shop_id query string false "Shop ID"
+// @Param status query string false "OPEN, APPROVED or CANCELLED"
+// @Param scope query string false "FULL or CATEGORY"
+// @Success 200 {object} map[string]interface{}
+// @Router /stock-counts [get]
+// @Security BearerAuth
+func (h *StockCountHandler) GetStockCounts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"status", "scope", "date_from", "date_to"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	counts, total, err := h.stockCountService.GetStockCounts(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock counts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": counts,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetStockCount godoc
+// @Summary Get a stock count by ID
+// @Description Get a stock count with its lines and every counter's entries
+// @Tags stock-counts
+// @Produce json
+// @Param id path string true "Stock Count ID"
+// @Success 200 {object} entities.StockCount
+// @Router /stock-counts/{id} [get]
+// @Security BearerAuth
+func (h *StockCountHandler) GetStockCount(c *gin.Context) {
+	count, ok := h.loadScopedCount(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// StartStockCount godoc
+// @Summary Start stock count
+// @Description Open a full or category stock count and snapshot the quantities the shop should hold
+// @Tags stock-counts
+// @Accept json
+// @Produce json
+// @Param count body entities.StartStockCountRequest true "Stock count details"
+// @Success 201 {object} entities.StockCount
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /stock-counts [post]
+// @Security BearerAuth
+func (h *StockCountHandler) StartStockCount(c *gin.Context) {
+	var req entities.StartStockCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, req.ShopID)
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	count := &entities.StockCount{
+		ShopID:      *shopID,
+		Scope:       entities.StockCountScope(req.Scope),
+		Category:    req.Category,
+		SubCategory: req.SubCategory,
+		Notes:       req.Notes,
+		StartedByID: c.MustGet("user_id").(uuid.UUID),
+	}
+
+	if err := h.stockCountService.StartStockCount(count); err != nil {
+		if errors.Is(err, services.ErrCountCategoryRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start stock count"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, count)
+}
+
+// AddStockCountEntries godoc
+// @Summary Add counted quantities
+// @Description Record one counter's or scanner's tallies. Tallies for the same product from several counters are added together.
+// @Tags stock-counts
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock Count ID"
+// @Param entries body entities.StockCountEntriesRequest true "Counted quantities"
+// @Success 200 {object} entities.StockCountVariance
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /stock-counts/{id}/entries [post]
+// @Security BearerAuth
+func (h *StockCountHandler) AddStockCountEntries(c *gin.Context) {
+	var req entities.StockCountEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, ok := h.loadScopedCount(c)
+	if !ok {
+		return
+	}
+
+	entries := make([]services.StockCountEntry, 0, len(req.Entries))
+	for _, item := range req.Entries {
+		entry := services.StockCountEntry{Code: item.Code, Quantity: item.Quantity}
+		if item.ProductID != "" {
+			productID := uuid.MustParse(item.ProductID)
+			entry.ProductID = &productID
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := h.stockCountService.AddEntries(count.ID, c.MustGet("user_id").(uuid.UUID), req.Device, entries); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownProductCode), errors.Is(err, repository.ErrProductOutsideCount), errors.Is(err, repository.ErrNegativeCount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "product not found"})
+		case errors.Is(err, repository.ErrStockCountNotOpen):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record counted quantities"})
+		}
+		return
+	}
+
+	variance, err := h.stockCountService.GetVariance(count.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build variance report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variance)
+}
+
+// GetStockCountVariance godoc
+// @Summary Stock count variance report
+// @Description Compare counted with expected quantities, allowing for sales made during the count, valued at cost and at retail
+// @Tags stock-counts
+// @Produce json
+// @Param id path string true "Stock Count ID"
+// @Success 200 {object} entities.StockCountVariance
+// @Router /stock-counts/{id}/variance [get]
+// @Security BearerAuth
+func (h *StockCountHandler) GetStockCountVariance(c *gin.Context) {
+	count, ok := h.loadScopedCount(c)
+	if !ok {
+		return
+	}
+
+	variance, err := h.stockCountService.GetVariance(count.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build variance report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variance)
+}
+
+// ApproveStockCount godoc
+// @Summary Approve stock count
+// @Description Post the count's differences to inventory as a count correction stock adjustment. Managers and admins only.
+// @Tags stock-counts
+// @Accept json
+// @Produce json
+// @Param id path string true "Stock Count ID"
+// @Param approval body entities.ApproveStockCountRequest false "Approval options"
+// @Success 200 {object} entities.StockCount
+// @Router /stock-counts/{id}/approve [post]
+// @Security BearerAuth
+func (h *StockCountHandler) ApproveStockCount(c *gin.Context) {
+	var req entities.ApproveStockCountRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	existing, ok := h.loadScopedCount(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.stockCountService.ApproveStockCount(existing.ID, c.MustGet("user_id").(uuid.UUID), c.GetString("role"), req.ZeroUncounted)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCountNotPermitted):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrStockCountNotOpen):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "stock moved since the count; recount the short products"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to approve stock count"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// CancelStockCount godoc
+// @Summary Cancel stock count
+// @Description Cancel an open stock count without changing inventory
+// @Tags stock-counts
+// @Produce json
+// @Param id path string true "Stock Count ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /stock-counts/{id}/cancel [post]
+// @Security BearerAuth
+func (h *StockCountHandler) CancelStockCount(c *gin.Context) {
+	count, ok := h.loadScopedCount(c)
+	if !ok {
+		return
+	}
+
+	if err := h.stockCountService.CancelStockCount(count.ID); err != nil {
+		if errors.Is(err, repository.ErrStockCountNotOpen) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel stock count"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "stock count cancelled"})
+}
+
+// loadScopedCount loads the count in the path, refusing users of other shops
+func (h *StockCountHandler) loadScopedCount(c *gin.Context) (*entities.StockCount, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stock count ID"})
+		return nil, false
+	}
+
+	count, err := h.stockCountService.GetStockCountByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stock count not found"})
+		return nil, false
+	}
+	if _, ok := scopedShopID(c, count.ShopID.String()); !ok {
+		return nil, false
+	}
+	return count, true
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockLotHandler struct {
+	stockLotService services.StockLotService
+}
+
+func NewStockLotHandler(stockLotService services.StockLotService) *StockLotHandler {
+	return &StockLotHandler{
+		stockLotService: stockLotService,
+	}
+}
+
+// GetLots godoc
+// @Summary List stock lots
+// @Description Get a paginated list of lots, oldest first. Users other than admins only see the stock of their own shop.
+// @Tags lots
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param product_id query string false "Product ID"
+// @Param shop_id query string false "Shop ID"
+// @Param supplier_id query string false "Supplier ID"
+// @Param lot_number query string false "Lot number, or part of it"
+// @Param in_stock query bool false "Only lots with stock on hand"
+// @Success 200 {object} map[string]interface{}
+// @Router /lots [get]
+// @Security BearerAuth
+func (h *StockLotHandler) GetLots(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"product_id", "supplier_id", "lot_number"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+	if inStock, _ := strconv.ParseBool(c.Query("in_stock")); inStock {
+		filters["in_stock"] = true
+	}
+
+	lots, total, err := h.stockLotService.GetLots(page, pageSize, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch lots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": lots,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetLot godoc
+// @Summary Get a stock lot by ID
+// @Description Get a lot with its product, supplier and the shops holding it
+// @Tags lots
+// @Produce json
+// @Param id path string true "Lot ID"
+// @Success 200 {object} entities.StockLot
+// @Router /lots/{id} [get]
+// @Security BearerAuth
+func (h *StockLotHandler) GetLot(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lot ID"})
+		return
+	}
+
+	lot, err := h.stockLotService.GetLotByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lot not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lot)
+}
+
+// GetLotTrace godoc
+// @Summary Trace a stock lot
+// @Description Follow a lot from its receipt to the shops holding it and the invoices that sold it, for example to find the customers of a faulty batch
+// @Tags lots
+// @Produce json
+// @Param id path string true "Lot ID"
+// @Success 200 {object} entities.LotTrace
+// @Router /lots/{id}/trace [get]
+// @Security BearerAuth
+func (h *StockLotHandler) GetLotTrace(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lot ID"})
+		return
+	}
+
+	trace, err := h.stockLotService.GetLotTrace(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "lot not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to trace lot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// SetLotTracking godoc
+// @Summary Turn lot tracking on or off
+// @Description Make a product keep its stock per lot. Stock on hand when tracking starts is in no lot and is sold first.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param tracking body entities.SetLotTrackingRequest true "Tracking"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /lots/products/{product_id}/tracking [put]
+// @Security BearerAuth
+func (h *StockLotHandler) SetLotTracking(c *gin.Context) {
+	if c.GetString("role") != string(entities.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change lot tracking"})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	var req entities.SetLotTrackingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.stockLotService.SetLotTracking(productID, *req.Enabled); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change lot tracking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "track_lots": *req.Enabled})
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StockReservationHandler struct {
+	stockReservationService services.StockReservationService
+}
+
+func NewStockReservationHandler(stockReservationService services.StockReservationService) *StockReservationHandler {
+	return &StockReservationHandler{
+		stockReservationService: stockReservationService,
+	}
+}
+
+// GetStockReservations godoc
+// @Summary List stock reservations
+// @Description Get a paginated list of stock reservations. Users other than admins only see their own shop.
+// @Tags stock-reservations
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param shop_id query string false "Shop ID"
+// @Param product_id query string false "Product ID"
+// @Param source_type query string false "DRAFT_SALE, LAYAWAY, ONLINE_ORDER or TRANSFER"
+// @Param source_id query string false "Source document ID"
+// @Param customer_id query string false "Customer ID"
+// @Param status query string false "ACTIVE, FULFILLED, RELEASED or EXPIRED"
+// @Success 200 {object} map[string]interface{}
+// @Router /stock-reservations [get]
+// @Security BearerAuth
+func (h *StockReservationHandler) GetStockReservations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	shopID, ok := scopedShopID(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if shopID != nil {
+		filters["shop_id"] = *shopID
+	}
+	for _, field := range []string{"product_id", "source_type", "source_id", "customer_id", "status"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	reservations, total, err := h.stockReservationService.GetStockReservations(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stock reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": reservations,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetStockReservation godoc
+// @Summary Get a stock reservation by ID
+// @Description Get a stock reservation with its product and customer
+// @Tags stock-reservations
+// @Produce json
+// @Param id path string true "Stock Reservation ID"
+// @Success 200 {object} entities.StockReservation
+// @Router /stock-reservations/{id} [get]
+// @Security BearerAuth
+func (h *StockReservationHandler) GetStockReservation(c *gin.Context) {
+	reservation, ok := h.loadScopedReservation(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CreateStockReservation godoc
+// @Summary Reserve stock
+// @Description Hold stock for a draft sale, layaway, online order or transfer so it can't be sold to anyone else. The stock must be available, not just on hand.
+// @Tags stock-reservations
+// @Accept json
+// @Produce json
+// @Param reservation body entities.CreateStockReservationRequest true "Reservation details"
+// @Success 201 {object} entities.StockReservation
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /stock-reservations [post]
+// @Security BearerAuth
+func (h *StockReservationHandler) CreateStockReservation(c *gin.Context) {
+	var req entities.CreateStockReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shopID, ok := scopedShopID(c, req.ShopID)
+	if !ok {
+		return
+	}
+	if shopID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errShopRequired.Error()})
+		return
+	}
+
+	reservation := &entities.StockReservation{
+		ShopID:      *shopID,
+		ProductID:   uuid.MustParse(req.ProductID),
+		Quantity:    req.Quantity,
+		SourceType:  entities.ReservationSource(req.SourceType),
+		Reference:   req.Reference,
+		ExpiresAt:   req.ExpiresAt,
+		Notes:       req.Notes,
+		CreatedByID: c.MustGet("user_id").(uuid.UUID),
+	}
+	if req.SourceID != "" {
+		sourceID := uuid.MustParse(req.SourceID)
+		reservation.SourceID = &sourceID
+	}
+	if req.CustomerID != "" {
+		customerID := uuid.MustParse(req.CustomerID)
+		reservation.CustomerID = &customerID
+	}
+
+	if err := h.stockReservationService.CreateStockReservation(reservation); err != nil {
+		switch {
+		case errors.Is(err, services.ErrReservationExpiryPassed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reserve stock"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// ReleaseStockReservation godoc
+// @Summary Release stock reservation
+// @Description Give a reservation's stock back to the shop, for example when a draft is discarded or a layaway is cancelled
+// @Tags stock-reservations
+// @Produce json
+// @Param id path string true "Stock Reservation ID"
+// @Success 200 {object} entities.StockReservation
+// @Router /stock-reservations/{id}/release [post]
+// @Security BearerAuth
+func (h *StockReservationHandler) ReleaseStockReservation(c *gin.Context) {
+	existing, ok := h.loadScopedReservation(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.stockReservationService.ReleaseStockReservation(existing.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrReservationNotActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release stock reservation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// loadScopedReservation loads the reservation in the path, refusing users of
+// other shops
+func (h *StockReservationHandler) loadScopedReservation(c *gin.Context) (*entities.StockReservation, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stock reservation ID"})
+		return nil, false
+	}
+
+	reservation, err := h.stockReservationService.GetStockReservationByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stock reservation not found"})
+		return nil, false
+	}
+	if _, ok := scopedShopID(c, reservation.ShopID.String()); !ok {
+		return nil, false
+	}
+	return reservation, true
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 	usecases "Sheikh-Enterprise-Backend/internal/usecases/impl"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -86,11 +88,11 @@ func (h *StockTransferHandler) GetStockTransfer(c *gin.Context) {
 // @Tags stock-transfers
 // @Accept json
 // @Produce json
-// @Param shop_id path string true "Shop ID"
+// @Param id path string true "Shop ID"
 // @Success 200 {array} entities.StockTransfer
-// @Router /shops/{shop_id}/stock-transfers [get]
+// @Router /shops/{id}/stock-transfers [get]
 func (h *StockTransferHandler) GetStockTransfersByShopID(c *gin.Context) {
-	shopID, err := uuid.Parse(c.Param("shop_id"))
+	shopID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID format"})
 		return
@@ -107,7 +109,7 @@ func (h *StockTransferHandler) GetStockTransfersByShopID(c *gin.Context) {
 
 // CreateStockTransfer godoc
 // @Summary Create a new stock transfer
-// @Description Create a new stock transfer between shops
+// @Description Create a new stock transfer between shops or warehouses. Without from_shop_id the stock comes from the central warehouse of the destination's company.
 // @Tags stock-transfers
 // @Accept json
 // @Produce json
@@ -133,23 +135,33 @@ func (h *StockTransferHandler) CreateStockTransfer(c *gin.Context) {
 		return
 	}
 
-	fromShopID, err := uuid.Parse(request.FromShopID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from shop ID format"})
-		return
-	}
-
 	transfer := &entities.StockTransfer{
 		ProductID:        productID,
 		ToShopID:         toShopID,
-		FromShopID:       &fromShopID,
 		Quantity:         request.Quantity,
 		TransferDateTime: request.TransferDateTime,
-		TransferredBy:    uuid.MustParse(c.GetString("user_id")), // Assuming user ID is stored in context
+		TransferredBy:    c.MustGet("user_id").(uuid.UUID),
+	}
+	if request.FromShopID != "" {
+		fromShopID, err := uuid.Parse(request.FromShopID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from shop ID format"})
+			return
+		}
+		transfer.FromShopID = &fromShopID
 	}
+	if request.ReservationID != "" {
+		reservationID := uuid.MustParse(request.ReservationID)
+		transfer.ReservationID = &reservationID
+	}
+	if request.LotID != "" {
+		lotID := uuid.MustParse(request.LotID)
+		transfer.LotID = &lotID
+	}
+	transfer.SerialNumbers = request.SerialNumbers
 
 	if err := h.stockTransferService.CreateStockTransfer(transfer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStockTransferError(c, err)
 		return
 	}
 
@@ -181,7 +193,7 @@ func (h *StockTransferHandler) UpdateStockTransfer(c *gin.Context) {
 
 	transfer.ID = id
 	if err := h.stockTransferService.UpdateStockTransfer(&transfer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStockTransferError(c, err)
 		return
 	}
 
@@ -205,9 +217,26 @@ func (h *StockTransferHandler) DeleteStockTransfer(c *gin.Context) {
 	}
 
 	if err := h.stockTransferService.DeleteStockTransfer(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStockTransferError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// writeStockTransferError reports stock that can't be moved as a conflict,
+// naming the product and shop that fell short
+func writeStockTransferError(c *gin.Context, err error) {
+	if writeSerialError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrTransferCancelled), errors.Is(err, repository.ErrReservationNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrReservationMismatch), errors.Is(err, repository.ErrLotNotTracked), errors.Is(err, repository.ErrLotProductMismatch),
+		errors.Is(err, repository.ErrNoCentralWarehouse), errors.Is(err, usecases.ErrSameShopTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
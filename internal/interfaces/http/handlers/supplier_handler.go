@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
@@ -185,6 +187,62 @@ func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
 	c.JSON(http.StatusOK, existingSupplier)
 }
 
+// GetSupplierLedger godoc
+// @Summary Get supplier ledger
+// @Description Get the supplier's purchases, payments and debit notes with a running balance
+// @Tags suppliers
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} entities.SupplierLedger
+// @Router /suppliers/{id}/ledger [get]
+// @Security BearerAuth
+func (h *SupplierHandler) GetSupplierLedger(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier ID"})
+		return
+	}
+
+	ledger, err := h.supplierService.GetSupplierLedger(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "supplier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ledger)
+}
+
+// ExportStatement godoc
+// @Summary Export supplier statement
+// @Description Export the supplier ledger as an Excel statement
+// @Tags suppliers
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "Supplier ID"
+// @Success 200 {file} file
+// @Router /suppliers/{id}/statement/export [get]
+// @Security BearerAuth
+func (h *SupplierHandler) ExportStatement(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier ID"})
+		return
+	}
+
+	file, err := h.supplierService.ExportStatement(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export supplier statement"})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=supplier_statement_%s.xlsx", time.Now().Format("20060102150405")))
+
+	if err := file.Write(c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write file"})
+		return
+	}
+}
+
 // DeleteSupplier godoc
 // @Summary Delete a supplier
 // @Description Mark a supplier as deleted
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SupplierPaymentHandler struct {
+	supplierPaymentService services.SupplierPaymentService
+}
+
+func NewSupplierPaymentHandler(supplierPaymentService services.SupplierPaymentService) *SupplierPaymentHandler {
+	return &SupplierPaymentHandler{
+		supplierPaymentService: supplierPaymentService,
+	}
+}
+
+// GetSupplierPayments godoc
+// @Summary List supplier payments
+// @Description Get a paginated list of payments made to suppliers
+// @Tags supplier-payments
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param supplier_id query string false "Supplier ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /supplier-payments [get]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) GetSupplierPayments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		filters["supplier_id"] = supplierID
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	payments, total, err := h.supplierPaymentService.GetSupplierPayments(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch supplier payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": payments,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetSupplierPayment godoc
+// @Summary Get a supplier payment by ID
+// @Description Get a supplier payment with its invoice allocations
+// @Tags supplier-payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} entities.Payment
+// @Router /supplier-payments/{id} [get]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) GetSupplierPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	payment, err := h.supplierPaymentService.GetSupplierPaymentByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// RecordSupplierPayment godoc
+// @Summary Record supplier payment
+// @Description Record a payment to a supplier, optionally allocating it to purchase invoices
+// @Tags supplier-payments
+// @Accept json
+// @Produce json
+// @Param payment body entities.CreateSupplierPaymentRequest true "Payment details"
+// @Success 201 {object} entities.Payment
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /supplier-payments [post]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) RecordSupplierPayment(c *gin.Context) {
+	var req entities.CreateSupplierPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplierID := uuid.MustParse(req.SupplierID)
+	payment := &entities.Payment{
+		SupplierID:     &supplierID,
+		CurrencyCode:   req.CurrencyCode,
+		ExchangeRate:   req.ExchangeRate,
+		CurrencyAmount: req.Amount,
+		Remarks:        req.Remarks,
+		Allocations:    toPaymentAllocations(req.Allocations),
+	}
+	if req.PaymentDateTime != nil {
+		payment.PaymentDateTime = *req.PaymentDateTime
+	}
+
+	if err := h.supplierPaymentService.RecordSupplierPayment(payment); err != nil {
+		respondAllocationError(c, err, "failed to record supplier payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// AllocatePayment godoc
+// @Summary Allocate supplier payment
+// @Description Allocate an existing supplier payment to one or more purchase invoices
+// @Tags supplier-payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param allocations body entities.AllocatePaymentRequest true "Allocations"
+// @Success 200 {object} entities.Payment
+// @Router /supplier-payments/{id}/allocations [post]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) AllocatePayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	var req entities.AllocatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.supplierPaymentService.AllocatePayment(id, toPaymentAllocations(req.Allocations))
+	if err != nil {
+		respondAllocationError(c, err, "failed to allocate payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// GetOutstandingInvoices godoc
+// @Summary List outstanding supplier invoices
+// @Description Get credit purchases that are not fully paid, oldest first
+// @Tags supplier-payments
+// @Produce json
+// @Param supplier_id query string false "Supplier ID"
+// @Success 200 {array} entities.OutstandingInvoice
+// @Router /supplier-payments/outstanding [get]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) GetOutstandingInvoices(c *gin.Context) {
+	supplierID, ok := parseOptionalSupplierID(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := h.supplierPaymentService.GetOutstandingInvoices(supplierID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// GetPayablesAging godoc
+// @Summary Supplier payables aging
+// @Description Get each supplier's outstanding balance bucketed by invoice age
+// @Tags supplier-payments
+// @Produce json
+// @Param supplier_id query string false "Supplier ID"
+// @Success 200 {array} entities.SupplierAging
+// @Router /supplier-payments/aging [get]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) GetPayablesAging(c *gin.Context) {
+	supplierID, ok := parseOptionalSupplierID(c)
+	if !ok {
+		return
+	}
+
+	aging, err := h.supplierPaymentService.GetPayablesAging(supplierID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aging)
+}
+
+// GetFxGainLoss godoc
+// @Summary Realised exchange gain and loss
+// @Description List the exchange differences realised by settling foreign currency invoices, with totals
+// @Tags supplier-payments
+// @Produce json
+// @Param supplier_id query string false "Supplier ID"
+// @Param currency_code query string false "Currency code"
+// @Param date_from query string false "Payments from date"
+// @Param date_to query string false "Payments to date"
+// @Success 200 {object} entities.FxGainLossReport
+// @Router /supplier-payments/fx-gain-loss [get]
+// @Security BearerAuth
+func (h *SupplierPaymentHandler) GetFxGainLoss(c *gin.Context) {
+	supplierID, ok := parseOptionalSupplierID(c)
+	if !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if supplierID != nil {
+		filters["supplier_id"] = *supplierID
+	}
+	if currencyCode := c.Query("currency_code"); currencyCode != "" {
+		filters["currency_code"] = strings.ToUpper(currencyCode)
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	report, err := h.supplierPaymentService.GetFxGainLoss(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func parseOptionalSupplierID(c *gin.Context) (*uuid.UUID, bool) {
+	supplierIDStr := c.Query("supplier_id")
+	if supplierIDStr == "" {
+		return nil, true
+	}
+
+	supplierID, err := uuid.Parse(supplierIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier ID"})
+		return nil, false
+	}
+	return &supplierID, true
+}
+
+func toPaymentAllocations(items []entities.PaymentAllocationRequest) []entities.PaymentAllocation {
+	var allocations []entities.PaymentAllocation
+	for _, item := range items {
+		allocations = append(allocations, entities.PaymentAllocation{
+			PurchaseInvoiceID: uuid.MustParse(item.PurchaseInvoiceID),
+			Amount:            item.Amount,
+		})
+	}
+	return allocations
+}
+
+func respondAllocationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrAllocationExceedsPayment),
+		errors.Is(err, repository.ErrAllocationExceedsInvoice),
+		errors.Is(err, repository.ErrInvoiceNotPayable),
+		errors.Is(err, repository.ErrCurrencyMismatch),
+		errors.Is(err, services.ErrNotSupplierPayment),
+		errors.Is(err, services.ErrUnknownCurrency),
+		errors.Is(err, services.ErrNoExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
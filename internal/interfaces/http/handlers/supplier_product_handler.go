@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SupplierProductHandler struct {
+	supplierProductService services.SupplierProductService
+}
+
+func NewSupplierProductHandler(supplierProductService services.SupplierProductService) *SupplierProductHandler {
+	return &SupplierProductHandler{
+		supplierProductService: supplierProductService,
+	}
+}
+
+// GetSupplierProducts godoc
+// @Summary Search the supplier catalog
+// @Description Get a paginated list of supplier catalog entries
+// @Tags supplier-products
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param supplier_id query string false "Supplier ID"
+// @Param product_id query string false "Product ID"
+// @Param is_preferred query bool false "Only preferred suppliers"
+// @Param max_lead_time_days query int false "Longest acceptable lead time"
+// @Param search query string false "Search supplier code, product code or name, or supplier name"
+// @Success 200 {object} map[string]interface{}
+// @Router /supplier-products [get]
+// @Security BearerAuth
+func (h *SupplierProductHandler) GetSupplierProducts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		filters["supplier_id"] = supplierID
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		filters["product_id"] = productID
+	}
+	if preferred, err := strconv.ParseBool(c.Query("is_preferred")); err == nil {
+		filters["is_preferred"] = preferred
+	}
+	if leadTime, err := strconv.Atoi(c.Query("max_lead_time_days")); err == nil {
+		filters["max_lead_time_days"] = leadTime
+	}
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	links, total, err := h.supplierProductService.GetSupplierProducts(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch supplier catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": links,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetSupplierProduct godoc
+// @Summary Get a supplier catalog entry
+// @Tags supplier-products
+// @Produce json
+// @Param id path string true "Catalog entry ID"
+// @Success 200 {object} entities.SupplierProduct
+// @Router /supplier-products/{id} [get]
+// @Security BearerAuth
+func (h *SupplierProductHandler) GetSupplierProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid catalog entry ID"})
+		return
+	}
+
+	link, err := h.supplierProductService.GetSupplierProductByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "catalog entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// CreateSupplierProduct godoc
+// @Summary Add a product to a supplier's catalog
+// @Tags supplier-products
+// @Accept json
+// @Produce json
+// @Param entry body entities.SupplierProductRequest true "Catalog entry"
+// @Success 201 {object} entities.SupplierProduct
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /supplier-products [post]
+// @Security BearerAuth
+func (h *SupplierProductHandler) CreateSupplierProduct(c *gin.Context) {
+	var req entities.SupplierProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := supplierProductFromRequest(req)
+	if err := h.supplierProductService.CreateSupplierProduct(link); err != nil {
+		if errors.Is(err, services.ErrSupplierProductExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create catalog entry"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// UpdateSupplierProduct godoc
+// @Summary Update a supplier catalog entry
+// @Tags supplier-products
+// @Accept json
+// @Produce json
+// @Param id path string true "Catalog entry ID"
+// @Param entry body entities.SupplierProductRequest true "Catalog entry"
+// @Success 200 {object} entities.SupplierProduct
+// @Router /supplier-products/{id} [put]
+// @Security BearerAuth
+func (h *SupplierProductHandler) UpdateSupplierProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid catalog entry ID"})
+		return
+	}
+
+	var req entities.SupplierProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := supplierProductFromRequest(req)
+	link.ID = id
+	if err := h.supplierProductService.UpdateSupplierProduct(link); err != nil {
+		if errors.Is(err, services.ErrSupplierProductChanged) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "catalog entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// DeleteSupplierProduct godoc
+// @Summary Remove a product from a supplier's catalog
+// @Tags supplier-products
+// @Produce json
+// @Param id path string true "Catalog entry ID"
+// @Success 200 {object} map[string]string
+// @Router /supplier-products/{id} [delete]
+// @Security BearerAuth
+func (h *SupplierProductHandler) DeleteSupplierProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid catalog entry ID"})
+		return
+	}
+
+	if err := h.supplierProductService.DeleteSupplierProduct(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "catalog entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "catalog entry deleted successfully"})
+}
+
+func supplierProductFromRequest(req entities.SupplierProductRequest) *entities.SupplierProduct {
+	return &entities.SupplierProduct{
+		SupplierID:       uuid.MustParse(req.SupplierID),
+		ProductID:        uuid.MustParse(req.ProductID),
+		SupplierCode:     req.SupplierCode,
+		LastPrice:        req.LastPrice,
+		MinOrderQuantity: req.MinOrderQuantity,
+		PackSize:         req.PackSize,
+		LeadTimeDays:     req.LeadTimeDays,
+		IsPreferred:      req.IsPreferred,
+	}
+}
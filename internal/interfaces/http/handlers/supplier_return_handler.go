@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SupplierReturnHandler struct {
+	supplierReturnService services.SupplierReturnService
+}
+
+func NewSupplierReturnHandler(supplierReturnService services.SupplierReturnService) *SupplierReturnHandler {
+	return &SupplierReturnHandler{
+		supplierReturnService: supplierReturnService,
+	}
+}
+
+// GetSupplierReturns godoc
+// @Summary List supplier returns
+// @Description Get a paginated list of supplier returns and their debit notes
+// @Tags supplier-returns
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Router /supplier-returns [get]
+// @Security BearerAuth
+func (h *SupplierReturnHandler) GetSupplierReturns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		filters["supplier_id"] = supplierID
+	}
+	if purchaseInvoiceID := c.Query("purchase_invoice_id"); purchaseInvoiceID != "" {
+		filters["purchase_invoice_id"] = purchaseInvoiceID
+	}
+	if shopID := c.Query("shop_id"); shopID != "" {
+		filters["shop_id"] = shopID
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		filters["date_from"] = dateFrom
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		filters["date_to"] = dateTo
+	}
+
+	var sorts []string
+	if sort := c.Query("sort"); sort != "" {
+		sorts = append(sorts, sort)
+	}
+
+	returns, total, err := h.supplierReturnService.GetSupplierReturns(page, pageSize, filters, sorts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch supplier returns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": returns,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetSupplierReturn godoc
+// @Summary Get a supplier return by ID
+// @Description Get detailed information about a supplier return
+// @Tags supplier-returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier Return ID"
+// @Success 200 {object} entities.SupplierReturn
+// @Router /supplier-returns/{id} [get]
+func (h *SupplierReturnHandler) GetSupplierReturn(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid supplier return ID"})
+		return
+	}
+
+	supplierReturn, err := h.supplierReturnService.GetSupplierReturnByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "supplier return not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplierReturn)
+}
+
+// CreateSupplierReturn godoc
+// @Summary Create supplier return
+// @Description Return purchased goods to the supplier and raise a debit note
+// @Tags supplier-returns
+// @Accept json
+// @Produce json
+// @Param return body entities.CreateSupplierReturnRequest true "Supplier return details"
+// @Success 201 {object} entities.SupplierReturn
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /supplier-returns [post]
+// @Security BearerAuth
+func (h *SupplierReturnHandler) CreateSupplierReturn(c *gin.Context) {
+	var req entities.CreateSupplierReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplierReturn := &entities.SupplierReturn{
+		PurchaseInvoiceID: uuid.MustParse(req.PurchaseInvoiceID),
+		ReturnDateTime:    time.Now(),
+		ReturnedByID:      c.MustGet("user_id").(uuid.UUID),
+		Reason:            req.Reason,
+		Remarks:           req.Remarks,
+	}
+	for _, item := range req.Items {
+		supplierReturn.Lines = append(supplierReturn.Lines, entities.SupplierReturnLine{
+			PurchaseDetailID: uuid.MustParse(item.PurchaseDetailID),
+			Quantity:         item.Quantity,
+			SerialNumbers:    item.SerialNumbers,
+		})
+	}
+
+	if err := h.supplierReturnService.CreateSupplierReturn(supplierReturn); err != nil {
+		if writeSerialError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, repository.ErrReturnExceedsPurchase), errors.Is(err, repository.ErrUnknownPurchaseLine):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "not enough stock left in the shop to return"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create supplier return"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplierReturn)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultScorecardDays is the period scored when no dates are given
+const defaultScorecardDays = 90
+
+type SupplierScorecardHandler struct {
+	supplierScorecardService services.SupplierScorecardService
+}
+
+func NewSupplierScorecardHandler(supplierScorecardService services.SupplierScorecardService) *SupplierScorecardHandler {
+	return &SupplierScorecardHandler{
+		supplierScorecardService: supplierScorecardService,
+	}
+}
+
+// GetScorecards godoc
+// @Summary Supplier scorecards
+// @Description Rate suppliers on on-time delivery, fill rate, returns, price variance and purchase volume over a period
+// @Tags supplier-scorecards
+// @Produce json
+// @Param date_from query string false "Start date (YYYY-MM-DD), 90 days ago by default"
+// @Param date_to query string false "End date (YYYY-MM-DD), today by default"
+// @Param supplier_id query string false "Supplier ID"
+// @Param category query string false "Only products of this master category"
+// @Success 200 {object} entities.SupplierScorecardReport
+// @Router /supplier-scorecards [get]
+// @Security BearerAuth
+func (h *SupplierScorecardHandler) GetScorecards(c *gin.Context) {
+	from, to, ok := parseScorecardPeriod(c)
+	if !ok {
+		return
+	}
+	supplierID, ok := parseOptionalSupplierID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.supplierScorecardService.GetScorecards(from, to, supplierID, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCategoryRankings godoc
+// @Summary Supplier rankings per category
+// @Description Rank suppliers by score within each product category over a period
+// @Tags supplier-scorecards
+// @Produce json
+// @Param date_from query string false "Start date (YYYY-MM-DD), 90 days ago by default"
+// @Param date_to query string false "End date (YYYY-MM-DD), today by default"
+// @Param category query string false "Only this master category"
+// @Success 200 {array} entities.SupplierCategoryRanking
+// @Router /supplier-scorecards/rankings [get]
+// @Security BearerAuth
+func (h *SupplierScorecardHandler) GetCategoryRankings(c *gin.Context) {
+	from, to, ok := parseScorecardPeriod(c)
+	if !ok {
+		return
+	}
+
+	rankings, err := h.supplierScorecardService.GetCategoryRankings(from, to, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rankings)
+}
+
+// ExportScorecards godoc
+// @Summary Export supplier scorecards
+// @Description Export the supplier scorecards of a period to Excel
+// @Tags supplier-scorecards
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param date_from query string false "Start date (YYYY-MM-DD), 90 days ago by default"
+// @Param date_to query string false "End date (YYYY-MM-DD), today by default"
+// @Param supplier_id query string false "Supplier ID"
+// @Param category query string false "Only products of this master category"
+// @Success 200 {file} file
+// @Router /supplier-scorecards/export [get]
+// @Security BearerAuth
+func (h *SupplierScorecardHandler) ExportScorecards(c *gin.Context) {
+	from, to, ok := parseScorecardPeriod(c)
+	if !ok {
+		return
+	}
+	supplierID, ok := parseOptionalSupplierID(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.supplierScorecardService.ExportScorecards(from, to, supplierID, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export supplier scorecards"})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=supplier_scorecards_%s.xlsx", time.Now().Format("20060102150405")))
+
+	if err := file.Write(c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write file"})
+		return
+	}
+}
+
+// parseScorecardPeriod reads the date range, returning the end as the start
+// of the day after date_to
+func parseScorecardPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	today := time.Now().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -defaultScorecardDays)
+	to := today
+
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		parsed, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_from format, use YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		parsed, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_to format, use YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_to is before date_from"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to.AddDate(0, 0, 1), true
+}
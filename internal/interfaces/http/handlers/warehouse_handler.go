@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	validator "Sheikh-Enterprise-Backend/internal/infrastructure/validation"
+	services "Sheikh-Enterprise-Backend/internal/usecases/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WarehouseHandler struct {
+	warehouseService services.WarehouseService
+}
+
+func NewWarehouseHandler(warehouseService services.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseService: warehouseService,
+	}
+}
+
+// GetWarehouses godoc
+// @Summary List warehouses
+// @Description Get a paginated list of warehouses. Warehouses hold stock, receive purchases and transfer to shops, but don't sell.
+// @Tags warehouses
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param company_id query string false "Filter by company ID"
+// @Param name query string false "Filter by name"
+// @Success 200 {object} map[string]interface{}
+// @Router /warehouses [get]
+// @Security BearerAuth
+func (h *WarehouseHandler) GetWarehouses(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	filters := make(map[string]interface{})
+	for _, field := range []string{"company_id", "name"} {
+		if value := c.Query(field); value != "" {
+			filters[field] = value
+		}
+	}
+
+	warehouses, total, err := h.warehouseService.GetWarehouses(page, pageSize, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch warehouses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": warehouses,
+		"meta": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetWarehouse godoc
+// @Summary Get warehouse
+// @Description Get a warehouse with the stock it holds and whether it is its company's central warehouse
+// @Tags warehouses
+// @Produce json
+// @Param id path string true "Warehouse ID"
+// @Success 200 {object} entities.WarehouseDetail
+// @Router /warehouses/{id} [get]
+// @Security BearerAuth
+func (h *WarehouseHandler) GetWarehouse(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid warehouse ID"})
+		return
+	}
+
+	warehouse, err := h.warehouseService.GetWarehouse(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "warehouse not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// CreateWarehouse godoc
+// @Summary Create warehouse
+// @Description Create a warehouse under a company. The company's first warehouse becomes its central warehouse, as does one created with central set.
+// @Tags warehouses
+// @Accept json
+// @Produce json
+// @Param warehouse body entities.CreateWarehouseRequest true "Warehouse details"
+// @Success 201 {object} entities.Shop
+// @Failure 400 {object} validator.ValidationErrors
+// @Router /warehouses [post]
+// @Security BearerAuth
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	if c.GetString("role") != string(entities.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can create warehouses"})
+		return
+	}
+
+	var req entities.CreateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if validationErrors := validator.FormatError(err); validationErrors != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse := &entities.Shop{
+		CompanyID:    uuid.MustParse(req.CompanyID),
+		Name:         req.Name,
+		Address:      req.Address,
+		Phone:        req.Phone,
+		Email:        req.Email,
+		ManagerName:  req.ManagerName,
+		ManagerPhone: req.ManagerPhone,
+		Remarks:      req.Remarks,
+	}
+
+	if err := h.warehouseService.CreateWarehouse(warehouse, req.Central); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "company not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// SetCentralWarehouse godoc
+// @Summary Make a warehouse central
+// @Description Make the warehouse its company's central warehouse, which stock transfers without a source come from
+// @Tags warehouses
+// @Produce json
+// @Param id path string true "Warehouse ID"
+// @Success 200 {object} entities.WarehouseDetail
+// @Router /warehouses/{id}/central [put]
+// @Security BearerAuth
+func (h *WarehouseHandler) SetCentralWarehouse(c *gin.Context) {
+	if c.GetString("role") != string(entities.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change the central warehouse"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid warehouse ID"})
+		return
+	}
+
+	warehouse, err := h.warehouseService.SetCentral(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "warehouse not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change the central warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
@@ -39,12 +39,29 @@ func SetupRoutes(router *gin.Engine, handlers *handlers.Handlers) {
 	api.Use(middleware.AuthMiddleware())
 	{
 		setupUserRoutes(api, handlers.User)
-		setupProductRoutes(api, handlers.Product)
+		setupProductRoutes(api, handlers.Product, handlers.Purchase)
+		setupProductStyleRoutes(api, handlers.ProductStyle)
 		setupSalesRoutes(api, handlers.Sales)
-		setupPurchaseRoutes(api, handlers.Purchase)
+		setupPurchaseRoutes(api, handlers.Purchase, handlers.LandedCost, handlers.PurchaseImport)
+		setupPurchaseOrderRoutes(api, handlers.PurchaseOrder)
 		setupSupplierRoutes(api, handlers.Supplier)
+		setupSupplierProductRoutes(api, handlers.SupplierProduct)
+		setupSupplierReturnRoutes(api, handlers.SupplierReturn)
+		setupSupplierPaymentRoutes(api, handlers.SupplierPayment)
+		setupCurrencyRoutes(api, handlers.Currency)
+		setupSupplierScorecardRoutes(api, handlers.SupplierScorecard)
+		setupReplenishmentRoutes(api, handlers.Replenishment)
 		setupCompanyRoutes(api, handlers.Company)
 		setupShopRoutes(api, handlers.Shop)
+		setupInventoryRoutes(api, handlers.Inventory)
+		setupStockAdjustmentRoutes(api, handlers.StockAdjustment)
+		setupStockCountRoutes(api, handlers.StockCount)
+		setupStockReservationRoutes(api, handlers.StockReservation)
+		setupStockLotRoutes(api, handlers.StockLot)
+		setupSerialNumberRoutes(api, handlers.SerialNumber)
+		setupNotificationRoutes(api, handlers.Notification)
+		setupStockTransferRoutes(api, handlers.StockTransfer)
+		setupWarehouseRoutes(api, handlers.Warehouse)
 	}
 }
 
@@ -69,7 +86,7 @@ func setupUserRoutes(api *gin.RouterGroup, userHandler *handlers.UserHandler) {
 }
 
 // setupProductRoutes configures product-related routes
-func setupProductRoutes(api *gin.RouterGroup, productHandler *handlers.ProductHandler) {
+func setupProductRoutes(api *gin.RouterGroup, productHandler *handlers.ProductHandler, purchaseHandler *handlers.PurchaseHandler) {
 	products := api.Group("/products")
 	{
 		products.GET("", productHandler.GetProducts)
@@ -77,6 +94,22 @@ func setupProductRoutes(api *gin.RouterGroup, productHandler *handlers.ProductHa
 		products.POST("", productHandler.CreateProduct)
 		products.GET("/export", productHandler.ExportToExcel)
 		products.POST("/bulk-import", productHandler.BulkImport)
+		products.GET("/:id/price-history", purchaseHandler.GetPriceHistory)
+	}
+}
+
+// setupProductStyleRoutes configures product style and variant routes
+func setupProductStyleRoutes(api *gin.RouterGroup, productStyleHandler *handlers.ProductStyleHandler) {
+	styles := api.Group("/product-styles")
+	{
+		styles.GET("", productStyleHandler.GetProductStyles)
+		styles.GET("/stock", productStyleHandler.GetStyleStock)
+		styles.GET("/:id", productStyleHandler.GetProductStyle)
+		styles.GET("/:id/stock-matrix", productStyleHandler.GetStockMatrix)
+		styles.POST("", productStyleHandler.CreateProductStyle)
+		styles.PUT("/:id", productStyleHandler.UpdateProductStyle)
+		styles.POST("/:id/variants", productStyleHandler.GenerateVariants)
+		styles.POST("/:id/variants/attach", productStyleHandler.AttachVariants)
 	}
 }
 
@@ -89,6 +122,7 @@ func setupSalesRoutes(api *gin.RouterGroup, salesHandler *handlers.SalesHandler)
 		sales.POST("", salesHandler.CreateSale)
 		sales.DELETE("/:id", salesHandler.DeleteSale)
 		sales.GET("/export", salesHandler.ExportToExcel)
+		sales.GET("/margins", salesHandler.GetGrossMargins)
 
 		// Analytics routes
 		analytics := sales.Group("/analytics")
@@ -100,13 +134,40 @@ func setupSalesRoutes(api *gin.RouterGroup, salesHandler *handlers.SalesHandler)
 }
 
 // setupPurchaseRoutes configures purchase-related routes
-func setupPurchaseRoutes(api *gin.RouterGroup, purchaseHandler *handlers.PurchaseHandler) {
+func setupPurchaseRoutes(api *gin.RouterGroup, purchaseHandler *handlers.PurchaseHandler, landedCostHandler *handlers.LandedCostHandler, purchaseImportHandler *handlers.PurchaseImportHandler) {
 	purchases := api.Group("/purchases")
 	{
 		purchases.GET("", purchaseHandler.GetPurchases)
 		purchases.GET("/:id", purchaseHandler.GetPurchase)
 		purchases.POST("", purchaseHandler.CreatePurchase)
+		purchases.PUT("/:id", purchaseHandler.UpdatePurchase)
 		purchases.DELETE("/:id", purchaseHandler.DeletePurchase)
+		purchases.POST("/:id/lock", purchaseHandler.LockPurchase)
+		purchases.GET("/:id/revisions", purchaseHandler.GetRevisions)
+
+		// Landed cost routes
+		purchases.GET("/:id/landed-costs", landedCostHandler.GetLandedCosts)
+		purchases.POST("/:id/landed-costs", landedCostHandler.AddLandedCost)
+		purchases.DELETE("/:id/landed-costs/:cost_id", landedCostHandler.RemoveLandedCost)
+
+		// Supplier invoice import routes
+		purchases.POST("/import/preview", purchaseImportHandler.PreviewImport)
+		purchases.POST("/import/confirm", purchaseImportHandler.ConfirmImport)
+	}
+}
+
+// setupPurchaseOrderRoutes configures purchase order and goods receipt routes
+func setupPurchaseOrderRoutes(api *gin.RouterGroup, purchaseOrderHandler *handlers.PurchaseOrderHandler) {
+	orders := api.Group("/purchase-orders")
+	{
+		orders.GET("", purchaseOrderHandler.GetPurchaseOrders)
+		orders.GET("/:id", purchaseOrderHandler.GetPurchaseOrder)
+		orders.POST("", purchaseOrderHandler.CreatePurchaseOrder)
+		orders.POST("/:id/send", purchaseOrderHandler.SendPurchaseOrder)
+		orders.POST("/:id/close", purchaseOrderHandler.ClosePurchaseOrder)
+		orders.GET("/:id/receipts", purchaseOrderHandler.GetGoodsReceipts)
+		orders.POST("/:id/receipts", purchaseOrderHandler.ReceiveGoods)
+		orders.GET("/:id/match", purchaseOrderHandler.GetMatchReport)
 	}
 }
 
@@ -119,6 +180,84 @@ func setupSupplierRoutes(api *gin.RouterGroup, supplierHandler *handlers.Supplie
 		suppliers.POST("", supplierHandler.CreateSupplier)
 		suppliers.PUT("/:id", supplierHandler.UpdateSupplier)
 		suppliers.DELETE("/:id", supplierHandler.DeleteSupplier)
+		suppliers.GET("/:id/ledger", supplierHandler.GetSupplierLedger)
+		suppliers.GET("/:id/statement/export", supplierHandler.ExportStatement)
+	}
+}
+
+// setupSupplierProductRoutes configures supplier catalog routes
+func setupSupplierProductRoutes(api *gin.RouterGroup, supplierProductHandler *handlers.SupplierProductHandler) {
+	catalog := api.Group("/supplier-products")
+	{
+		catalog.GET("", supplierProductHandler.GetSupplierProducts)
+		catalog.GET("/:id", supplierProductHandler.GetSupplierProduct)
+		catalog.POST("", supplierProductHandler.CreateSupplierProduct)
+		catalog.PUT("/:id", supplierProductHandler.UpdateSupplierProduct)
+		catalog.DELETE("/:id", supplierProductHandler.DeleteSupplierProduct)
+	}
+}
+
+// setupSupplierReturnRoutes configures supplier return routes
+func setupSupplierReturnRoutes(api *gin.RouterGroup, supplierReturnHandler *handlers.SupplierReturnHandler) {
+	returns := api.Group("/supplier-returns")
+	{
+		returns.GET("", supplierReturnHandler.GetSupplierReturns)
+		returns.GET("/:id", supplierReturnHandler.GetSupplierReturn)
+		returns.POST("", supplierReturnHandler.CreateSupplierReturn)
+	}
+}
+
+// setupSupplierPaymentRoutes configures supplier payment and payables routes
+func setupSupplierPaymentRoutes(api *gin.RouterGroup, supplierPaymentHandler *handlers.SupplierPaymentHandler) {
+	payments := api.Group("/supplier-payments")
+	{
+		payments.GET("", supplierPaymentHandler.GetSupplierPayments)
+		payments.GET("/outstanding", supplierPaymentHandler.GetOutstandingInvoices)
+		payments.GET("/aging", supplierPaymentHandler.GetPayablesAging)
+		payments.GET("/fx-gain-loss", supplierPaymentHandler.GetFxGainLoss)
+		payments.GET("/:id", supplierPaymentHandler.GetSupplierPayment)
+		payments.POST("", supplierPaymentHandler.RecordSupplierPayment)
+		payments.POST("/:id/allocations", supplierPaymentHandler.AllocatePayment)
+	}
+}
+
+// setupCurrencyRoutes configures currency and exchange rate routes
+func setupCurrencyRoutes(api *gin.RouterGroup, currencyHandler *handlers.CurrencyHandler) {
+	currencies := api.Group("/currencies")
+	{
+		currencies.GET("", currencyHandler.GetCurrencies)
+		currencies.POST("", currencyHandler.CreateCurrency)
+	}
+
+	rates := api.Group("/exchange-rates")
+	{
+		rates.GET("", currencyHandler.GetExchangeRates)
+		rates.GET("/lookup", currencyHandler.GetRateOn)
+		rates.PUT("", currencyHandler.SaveExchangeRate)
+	}
+}
+
+// setupSupplierScorecardRoutes configures supplier performance routes
+func setupSupplierScorecardRoutes(api *gin.RouterGroup, scorecardHandler *handlers.SupplierScorecardHandler) {
+	scorecards := api.Group("/supplier-scorecards")
+	{
+		scorecards.GET("", scorecardHandler.GetScorecards)
+		scorecards.GET("/rankings", scorecardHandler.GetCategoryRankings)
+		scorecards.GET("/export", scorecardHandler.ExportScorecards)
+	}
+}
+
+// setupReplenishmentRoutes configures reorder rule and suggestion routes
+func setupReplenishmentRoutes(api *gin.RouterGroup, replenishmentHandler *handlers.ReplenishmentHandler) {
+	replenishment := api.Group("/replenishment")
+	{
+		replenishment.GET("/rules", replenishmentHandler.GetReorderRules)
+		replenishment.PUT("/rules", replenishmentHandler.SaveReorderRule)
+		replenishment.POST("/rules/import", replenishmentHandler.ImportReorderRules)
+		replenishment.GET("/category-defaults", replenishmentHandler.GetCategoryDefaults)
+		replenishment.PUT("/category-defaults", replenishmentHandler.SaveCategoryDefault)
+		replenishment.GET("/suggestions", replenishmentHandler.GetSuggestions)
+		replenishment.POST("/purchase-orders", replenishmentHandler.CreateDraftOrders)
 	}
 }
 
@@ -146,3 +285,114 @@ func setupShopRoutes(api *gin.RouterGroup, shopHandler *handlers.ShopHandler) {
 		shops.GET("/company/:company_id", shopHandler.GetShopsByCompany)
 	}
 }
+
+// setupInventoryRoutes configures stock level routes
+func setupInventoryRoutes(api *gin.RouterGroup, inventoryHandler *handlers.InventoryHandler) {
+	inventory := api.Group("/inventory")
+	{
+		inventory.GET("", inventoryHandler.GetInventory)
+		inventory.GET("/low-stock", inventoryHandler.GetLowStock)
+		inventory.GET("/totals", inventoryHandler.GetShopTotals)
+		inventory.GET("/products/:product_id", inventoryHandler.GetProductStock)
+		inventory.GET("/products/:product_id/stock-card", inventoryHandler.GetStockCard)
+	}
+}
+
+// setupStockAdjustmentRoutes configures stock adjustment and shrinkage routes
+func setupStockAdjustmentRoutes(api *gin.RouterGroup, stockAdjustmentHandler *handlers.StockAdjustmentHandler) {
+	adjustments := api.Group("/stock-adjustments")
+	{
+		adjustments.GET("", stockAdjustmentHandler.GetStockAdjustments)
+		adjustments.GET("/shrinkage", stockAdjustmentHandler.GetShrinkageReport)
+		adjustments.GET("/:id", stockAdjustmentHandler.GetStockAdjustment)
+		adjustments.POST("", stockAdjustmentHandler.CreateStockAdjustment)
+		adjustments.POST("/:id/approve", stockAdjustmentHandler.ApproveStockAdjustment)
+		adjustments.POST("/:id/reject", stockAdjustmentHandler.RejectStockAdjustment)
+	}
+}
+
+// setupStockCountRoutes configures stock count routes
+func setupStockCountRoutes(api *gin.RouterGroup, stockCountHandler *handlers.StockCountHandler) {
+	counts := api.Group("/stock-counts")
+	{
+		counts.GET("", stockCountHandler.GetStockCounts)
+		counts.GET("/:id", stockCountHandler.GetStockCount)
+		counts.GET("/:id/variance", stockCountHandler.GetStockCountVariance)
+		counts.POST("", stockCountHandler.StartStockCount)
+		counts.POST("/:id/entries", stockCountHandler.AddStockCountEntries)
+		counts.POST("/:id/approve", stockCountHandler.ApproveStockCount)
+		counts.POST("/:id/cancel", stockCountHandler.CancelStockCount)
+	}
+}
+
+// setupStockReservationRoutes configures stock reservation routes
+func setupStockReservationRoutes(api *gin.RouterGroup, stockReservationHandler *handlers.StockReservationHandler) {
+	reservations := api.Group("/stock-reservations")
+	{
+		reservations.GET("", stockReservationHandler.GetStockReservations)
+		reservations.GET("/:id", stockReservationHandler.GetStockReservation)
+		reservations.POST("", stockReservationHandler.CreateStockReservation)
+		reservations.POST("/:id/release", stockReservationHandler.ReleaseStockReservation)
+	}
+}
+
+// setupStockLotRoutes configures lot tracking routes
+func setupStockLotRoutes(api *gin.RouterGroup, stockLotHandler *handlers.StockLotHandler) {
+	lots := api.Group("/lots")
+	{
+		lots.GET("", stockLotHandler.GetLots)
+		lots.GET("/:id", stockLotHandler.GetLot)
+		lots.GET("/:id/trace", stockLotHandler.GetLotTrace)
+		lots.PUT("/products/:product_id/tracking", stockLotHandler.SetLotTracking)
+	}
+}
+
+// setupSerialNumberRoutes configures serial number tracking routes
+func setupSerialNumberRoutes(api *gin.RouterGroup, serialNumberHandler *handlers.SerialNumberHandler) {
+	serials := api.Group("/serial-numbers")
+	{
+		serials.GET("", serialNumberHandler.GetSerialNumbers)
+		serials.GET("/:id", serialNumberHandler.GetSerialNumber)
+		serials.GET("/lookup/:serial_number", serialNumberHandler.LookupSerialNumber)
+		serials.POST("/register", serialNumberHandler.RegisterSerialNumbers)
+		serials.PUT("/products/:product_id/tracking", serialNumberHandler.SetSerialTracking)
+	}
+}
+
+// setupNotificationRoutes configures inbox and notification preference routes
+func setupNotificationRoutes(api *gin.RouterGroup, notificationHandler *handlers.NotificationHandler) {
+	notifications := api.Group("/notifications")
+	{
+		notifications.GET("", notificationHandler.GetNotifications)
+		notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+		notifications.PUT("/read-all", notificationHandler.MarkAllNotificationsRead)
+		notifications.PUT("/:id/read", notificationHandler.MarkNotificationRead)
+		notifications.GET("/preferences", notificationHandler.GetNotificationPreferences)
+		notifications.PUT("/preferences", notificationHandler.SaveNotificationPreference)
+		notifications.POST("/test", notificationHandler.SendTestNotification)
+	}
+}
+
+// setupStockTransferRoutes configures stock transfer routes
+func setupStockTransferRoutes(api *gin.RouterGroup, stockTransferHandler *handlers.StockTransferHandler) {
+	transfers := api.Group("/stock-transfers")
+	{
+		transfers.GET("", stockTransferHandler.GetStockTransfers)
+		transfers.GET("/:id", stockTransferHandler.GetStockTransfer)
+		transfers.POST("", stockTransferHandler.CreateStockTransfer)
+		transfers.PUT("/:id", stockTransferHandler.UpdateStockTransfer)
+		transfers.DELETE("/:id", stockTransferHandler.DeleteStockTransfer)
+	}
+	api.GET("/shops/:id/stock-transfers", stockTransferHandler.GetStockTransfersByShopID)
+}
+
+// setupWarehouseRoutes configures warehouse routes
+func setupWarehouseRoutes(api *gin.RouterGroup, warehouseHandler *handlers.WarehouseHandler) {
+	warehouses := api.Group("/warehouses")
+	{
+		warehouses.GET("", warehouseHandler.GetWarehouses)
+		warehouses.GET("/:id", warehouseHandler.GetWarehouse)
+		warehouses.POST("", warehouseHandler.CreateWarehouse)
+		warehouses.PUT("/:id/central", warehouseHandler.SetCentralWarehouse)
+	}
+}
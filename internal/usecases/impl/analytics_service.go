@@ -79,24 +79,29 @@ func (s *analyticsService) GetInventoryAnalytics(shopID *uuid.UUID) (map[string]
 		return nil, err
 	}
 
+	// Stock levels are judged against each product's reorder settings
+	levels, err := s.inventoryRepo.GetStockLevelSummary(shopID, DefaultLowStockThreshold)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate analytics
 	totalProducts := len(inventories)
 	totalQuantity := 0
-	lowStockProducts := 0
 	productDistribution := make(map[string]int)
 
 	for _, inventory := range inventories {
 		totalQuantity += inventory.Quantity
-		if inventory.Quantity < 10 { // Assuming 10 is the low stock threshold
-			lowStockProducts++
-		}
 		productDistribution[inventory.Product.MasterCategory]++
 	}
 
 	return map[string]interface{}{
 		"total_products":       totalProducts,
 		"total_quantity":       totalQuantity,
-		"low_stock_products":   lowStockProducts,
+		"low_stock_products":   levels.LowStock,
+		"out_of_stock":         levels.OutOfStock,
+		"below_min_products":   levels.BelowMin,
+		"over_max_products":    levels.OverMax,
 		"product_distribution": productDistribution,
 		"inventory_by_shop":    s.groupInventoryByShop(inventories),
 	}, nil
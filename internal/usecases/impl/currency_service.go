@@ -0,0 +1,119 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCurrencyExists   = errors.New("currency already exists")
+	ErrUnknownCurrency  = errors.New("currency is not set up")
+	ErrBaseCurrencyRate = errors.New("the base currency has no exchange rate")
+	ErrNoExchangeRate   = errors.New("no exchange rate entered for the currency on or before this date")
+)
+
+type CurrencyService interface {
+	GetCurrencies() ([]entities.Currency, error)
+	CreateCurrency(currency *entities.Currency) error
+	GetRates(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.ExchangeRate, int64, error)
+	SaveRate(rate *entities.ExchangeRate) error
+	GetRateOn(code string, date time.Time) (*entities.ExchangeRate, error)
+}
+
+type currencyService struct {
+	currencyRepo repository.CurrencyRepository
+}
+
+func NewCurrencyService(currencyRepo repository.CurrencyRepository) CurrencyService {
+	return &currencyService{
+		currencyRepo: currencyRepo,
+	}
+}
+
+func (s *currencyService) GetCurrencies() ([]entities.Currency, error) {
+	return s.currencyRepo.GetCurrencies()
+}
+
+func (s *currencyService) CreateCurrency(currency *entities.Currency) error {
+	currency.Code = strings.ToUpper(currency.Code)
+
+	_, err := s.currencyRepo.GetByCode(currency.Code)
+	if err == nil {
+		return ErrCurrencyExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.currencyRepo.Create(currency)
+}
+
+func (s *currencyService) GetRates(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.ExchangeRate, int64, error) {
+	return s.currencyRepo.GetRatesWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *currencyService) SaveRate(rate *entities.ExchangeRate) error {
+	rate.CurrencyCode = strings.ToUpper(rate.CurrencyCode)
+	if rate.CurrencyCode == entities.BaseCurrency {
+		return ErrBaseCurrencyRate
+	}
+	if _, err := s.currencyRepo.GetByCode(rate.CurrencyCode); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUnknownCurrency
+		}
+		return err
+	}
+
+	rate.RateDate = time.Date(rate.RateDate.Year(), rate.RateDate.Month(), rate.RateDate.Day(), 0, 0, 0, 0, time.UTC)
+	rate.IsMarkedToDelete = false
+	return s.currencyRepo.SaveRate(rate)
+}
+
+// GetRateOn returns the rate a document dated on the given day would use
+func (s *currencyService) GetRateOn(code string, date time.Time) (*entities.ExchangeRate, error) {
+	code = strings.ToUpper(code)
+	if code == entities.BaseCurrency {
+		return &entities.ExchangeRate{CurrencyCode: code, RateDate: date, Rate: 1}, nil
+	}
+
+	rate, err := s.currencyRepo.GetRateOn(code, date)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoExchangeRate
+	}
+	return rate, err
+}
+
+// exchangeRateFor works out a document's currency and rate. Taka documents
+// always use a rate of one. A foreign currency document keeps the rate it was
+// given, or else takes the latest rate entered on or before its date.
+func exchangeRateFor(currencyRepo repository.CurrencyRepository, code string, rate float64, date time.Time) (string, float64, error) {
+	code = strings.ToUpper(code)
+	if code == "" || code == entities.BaseCurrency {
+		return entities.BaseCurrency, 1, nil
+	}
+
+	if _, err := currencyRepo.GetByCode(code); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, ErrUnknownCurrency
+		}
+		return "", 0, err
+	}
+	if rate > 0 {
+		return code, rate, nil
+	}
+
+	entered, err := currencyRepo.GetRateOn(code, date)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, ErrNoExchangeRate
+		}
+		return "", 0, err
+	}
+	return code, entered.Rate, nil
+}
@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultLowStockThreshold is the stock level counted as low for products
+// without a reorder rule or category default in the shop
+const DefaultLowStockThreshold = 10
+
+type InventoryService interface {
+	GetInventory(page, pageSize int, filters map[string]interface{}, sortBy, sortOrder string) ([]entities.Inventory, int64, error)
+	GetLowStock(shopID uuid.UUID, threshold int) ([]entities.Inventory, error)
+	GetProductStock(productID uuid.UUID, shopID *uuid.UUID) (*entities.ProductStock, error)
+	GetShopTotals(shopID *uuid.UUID) ([]entities.ShopStockTotal, error)
+	GetStockCard(productID, shopID uuid.UUID, from, to *time.Time) (*entities.StockCard, error)
+	RebuildInventory(shopID *uuid.UUID, dryRun bool) ([]entities.InventoryDrift, error)
+}
+
+type inventoryService struct {
+	inventoryRepo     repository.InventoryRepository
+	productRepo       repository.ProductRepository
+	stockMovementRepo repository.StockMovementRepository
+}
+
+func NewInventoryService(inventoryRepo repository.InventoryRepository, productRepo repository.ProductRepository, stockMovementRepo repository.StockMovementRepository) InventoryService {
+	return &inventoryService{
+		inventoryRepo:     inventoryRepo,
+		productRepo:       productRepo,
+		stockMovementRepo: stockMovementRepo,
+	}
+}
+
+func (s *inventoryService) GetInventory(page, pageSize int, filters map[string]interface{}, sortBy, sortOrder string) ([]entities.Inventory, int64, error) {
+	return s.inventoryRepo.GetInventoryWithFilters(filters, sortBy, sortOrder, page, pageSize)
+}
+
+func (s *inventoryService) GetLowStock(shopID uuid.UUID, threshold int) ([]entities.Inventory, error) {
+	if threshold <= 0 {
+		threshold = DefaultLowStockThreshold
+	}
+	return s.inventoryRepo.GetLowStockItems(shopID, threshold)
+}
+
+// GetProductStock returns a product's stock in every shop, or only in the
+// given shop
+func (s *inventoryService) GetProductStock(productID uuid.UUID, shopID *uuid.UUID) (*entities.ProductStock, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	shops, err := s.inventoryRepo.GetByProduct(productID, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &entities.ProductStock{
+		Product: product,
+		Shops:   shops,
+	}
+	for _, inventory := range shops {
+		stock.TotalQuantity += inventory.Quantity
+		stock.TotalReserved += inventory.ReservedQuantity
+		stock.Available += inventory.Available()
+	}
+	return stock, nil
+}
+
+func (s *inventoryService) GetShopTotals(shopID *uuid.UUID) ([]entities.ShopStockTotal, error) {
+	return s.inventoryRepo.GetShopTotals(shopID)
+}
+
+// GetStockCard lists a product's movements in a shop over a period with the
+// running balance after each one. to is exclusive.
+func (s *inventoryService) GetStockCard(productID, shopID uuid.UUID, from, to *time.Time) (*entities.StockCard, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &entities.StockCard{
+		ProductID: productID,
+		ShopID:    shopID,
+		Product:   product,
+		Entries:   []entities.StockCardEntry{},
+	}
+	if from != nil {
+		card.OpeningBalance, err = s.stockMovementRepo.GetBalanceBefore(productID, shopID, *from)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	movements, err := s.stockMovementRepo.GetMovements(productID, shopID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := card.OpeningBalance
+	for _, movement := range movements {
+		balance += movement.Quantity
+		if movement.Quantity > 0 {
+			card.QuantityIn += movement.Quantity
+		} else {
+			card.QuantityOut -= movement.Quantity
+		}
+		card.Entries = append(card.Entries, entities.StockCardEntry{
+			StockMovement: movement,
+			Balance:       balance,
+		})
+	}
+	card.ClosingBalance = balance
+
+	inventory, err := s.inventoryRepo.GetByProductAndShop(productID, shopID)
+	if err == nil {
+		card.OnHand = inventory.Quantity
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// RebuildInventory sets inventory quantities to what the movement ledger adds
+// up to, first putting any stock older than the ledger on it
+func (s *inventoryService) RebuildInventory(shopID *uuid.UUID, dryRun bool) ([]entities.InventoryDrift, error) {
+	return s.stockMovementRepo.RebuildInventory(shopID, dryRun)
+}
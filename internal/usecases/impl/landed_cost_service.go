@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type LandedCostService interface {
+	GetLandedCostSummary(purchaseInvoiceID uuid.UUID) (*entities.LandedCostSummary, error)
+	AddLandedCost(cost *entities.LandedCost) (*entities.LandedCostSummary, error)
+	RemoveLandedCost(purchaseInvoiceID, id uuid.UUID) (*entities.LandedCostSummary, error)
+}
+
+type landedCostService struct {
+	landedCostRepo repository.LandedCostRepository
+	purchaseRepo   repository.PurchaseRepository
+}
+
+func NewLandedCostService(landedCostRepo repository.LandedCostRepository, purchaseRepo repository.PurchaseRepository) LandedCostService {
+	return &landedCostService{
+		landedCostRepo: landedCostRepo,
+		purchaseRepo:   purchaseRepo,
+	}
+}
+
+// GetLandedCostSummary shows each purchase line's landed unit cost and the
+// margin it leaves against the product's sales price
+func (s *landedCostService) GetLandedCostSummary(purchaseInvoiceID uuid.UUID) (*entities.LandedCostSummary, error) {
+	purchase, err := s.purchaseRepo.GetWithDetails(purchaseInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, err := s.landedCostRepo.GetByPurchaseID(purchaseInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entities.LandedCostSummary{
+		PurchaseInvoiceID: purchase.ID,
+		Costs:             costs,
+	}
+	for _, cost := range costs {
+		summary.LandedCostTotal += cost.Amount
+	}
+
+	for _, detail := range purchase.PurchaseDetails {
+		summary.GoodsValue += detail.PurchasePrice * float64(detail.Quantity)
+
+		line := entities.LandedCostLine{
+			PurchaseDetailID: detail.ID,
+			ProductID:        detail.ProductID,
+			Quantity:         detail.Quantity,
+			PurchasePrice:    detail.PurchasePrice,
+			LandedCost:       detail.LandedCost,
+			LandedUnitCost:   detail.LandedUnitCost,
+		}
+		if detail.Product != nil {
+			line.SalesPrice = detail.Product.SalesPrice
+			line.UnitMargin = line.SalesPrice - line.LandedUnitCost
+			if line.SalesPrice > 0 {
+				line.MarginPercent = line.UnitMargin / line.SalesPrice * 100
+			}
+		}
+		summary.Lines = append(summary.Lines, line)
+	}
+
+	return summary, nil
+}
+
+func (s *landedCostService) AddLandedCost(cost *entities.LandedCost) (*entities.LandedCostSummary, error) {
+	if err := s.landedCostRepo.CreateAndAllocate(cost); err != nil {
+		return nil, err
+	}
+	return s.GetLandedCostSummary(cost.PurchaseInvoiceID)
+}
+
+func (s *landedCostService) RemoveLandedCost(purchaseInvoiceID, id uuid.UUID) (*entities.LandedCostSummary, error) {
+	if err := s.landedCostRepo.DeleteAndAllocate(purchaseInvoiceID, id); err != nil {
+		return nil, err
+	}
+	return s.GetLandedCostSummary(purchaseInvoiceID)
+}
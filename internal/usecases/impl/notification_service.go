@@ -0,0 +1,396 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	"Sheikh-Enterprise-Backend/internal/infrastructure/notification"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	"Sheikh-Enterprise-Backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// deliveryTimeout bounds sending one notification through one channel
+const deliveryTimeout = 30 * time.Second
+
+var ErrInvalidNotificationTarget = errors.New("invalid notification target")
+
+// phoneNumber matches phone numbers in international format, with or without
+// the leading plus
+var phoneNumber = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+type NotificationService interface {
+	GetNotifications(userID uuid.UUID, page, pageSize int, filters map[string]interface{}) ([]entities.Notification, int64, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	MarkRead(id, userID uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) (int64, error)
+	GetPreferences(userID uuid.UUID) ([]entities.NotificationPreference, error)
+	SavePreference(preference *entities.NotificationPreference) error
+	Notify(alert entities.Alert) error
+	CheckLowStock(shopID uuid.UUID, productIDs []uuid.UUID) error
+	SendTest(userID uuid.UUID) (*entities.Notification, error)
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	inventoryRepo    repository.InventoryRepository
+	channels         notification.Channels
+	config           config.NotificationConfig
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository, inventoryRepo repository.InventoryRepository, channels notification.Channels, cfg config.NotificationConfig) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		inventoryRepo:    inventoryRepo,
+		channels:         channels,
+		config:           cfg,
+	}
+}
+
+// preferenceKey identifies a user's preference for one channel of one type
+type preferenceKey struct {
+	userID           uuid.UUID
+	notificationType entities.NotificationType
+	channel          entities.NotificationChannel
+}
+
+// pendingDelivery is a notification waiting to go out through a channel
+type pendingDelivery struct {
+	channel entities.NotificationChannel
+	message notification.Message
+}
+
+func (s *notificationService) GetNotifications(userID uuid.UUID, page, pageSize int, filters map[string]interface{}) ([]entities.Notification, int64, error) {
+	return s.notificationRepo.GetNotificationsWithFilters(userID, filters, page, pageSize)
+}
+
+func (s *notificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *notificationService) MarkRead(id, userID uuid.UUID) error {
+	return s.notificationRepo.MarkRead(id, userID)
+}
+
+func (s *notificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+// GetPreferences returns the user's setting for every type and channel,
+// with the default where the user has none
+func (s *notificationService) GetPreferences(userID uuid.UUID) ([]entities.NotificationPreference, error) {
+	stored, err := s.notificationRepo.GetPreferences([]uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	saved := preferenceMap(stored)
+
+	preferences := make([]entities.NotificationPreference, 0, len(entities.NotificationTypes)*len(entities.NotificationChannels))
+	for _, notificationType := range entities.NotificationTypes {
+		for _, channel := range entities.NotificationChannels {
+			preference, ok := saved[preferenceKey{userID, notificationType, channel}]
+			if !ok {
+				preference = entities.DefaultNotificationPreference(userID, notificationType, channel)
+			}
+			preferences = append(preferences, preference)
+		}
+	}
+	return preferences, nil
+}
+
+func (s *notificationService) SavePreference(preference *entities.NotificationPreference) error {
+	preference.Target = strings.TrimSpace(preference.Target)
+	if err := s.checkTarget(preference.Channel, preference.Target); err != nil {
+		return err
+	}
+	preference.IsMarkedToDelete = false
+	return s.notificationRepo.UpsertPreference(preference)
+}
+
+// Notify puts the alert in the inbox of the shop's managers and every admin,
+// or only the admins when it concerns no shop, and sends it through the
+// other channels each of them turned on. Sending happens in the background.
+func (s *notificationService) Notify(alert entities.Alert) error {
+	if alert.Reference != "" {
+		recent, err := s.notificationRepo.HasRecent(alert.Reference, time.Now().Add(-s.config.LowStockRepeat))
+		if err != nil {
+			return err
+		}
+		if recent {
+			return nil
+		}
+	}
+
+	users, err := s.notificationRepo.GetRecipients(alert.ShopID)
+	if err != nil {
+		return err
+	}
+	_, err = s.send(alert, users)
+	return err
+}
+
+// CheckLowStock raises a low stock alert for each of the products that is
+// now at or below its reorder settings in the shop
+func (s *notificationService) CheckLowStock(shopID uuid.UUID, productIDs []uuid.UUID) error {
+	low, err := s.inventoryRepo.GetLowStockByProducts(shopID, productIDs, DefaultLowStockThreshold)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, inventory := range low {
+		code, name, shopName := inventory.ProductID.String(), "", shopID.String()
+		if inventory.Product != nil {
+			code, name = inventory.Product.Code, inventory.Product.Name
+		}
+		if inventory.Shop != nil {
+			shopName = inventory.Shop.Name
+		}
+
+		errs = append(errs, s.Notify(entities.Alert{
+			Type:      entities.NotificationTypeLowStock,
+			Title:     fmt.Sprintf("Low stock: %s", code),
+			Message:   fmt.Sprintf("%s (%s) is down to %d in %s.", name, code, inventory.Quantity, shopName),
+			ShopID:    &shopID,
+			Reference: fmt.Sprintf("%s:%s:%s", entities.NotificationTypeLowStock, shopID, inventory.ProductID),
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// SendTest sends a test notification to the user through the inbox and every
+// channel the user turned on for any type of alert
+func (s *notificationService) SendTest(userID uuid.UUID) (*entities.Notification, error) {
+	user, err := s.notificationRepo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.send(entities.Alert{
+		Type:    entities.NotificationTypeTest,
+		Title:   "Test notification",
+		Message: "Notifications reach you through this channel.",
+	}, []entities.User{*user})
+	if err != nil {
+		return nil, err
+	}
+	return &notifications[0], nil
+}
+
+// send saves a notification for each user and queues it on the channels the
+// user turned on for its type
+func (s *notificationService) send(alert entities.Alert, users []entities.User) ([]entities.Notification, error) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	stored, err := s.notificationRepo.GetPreferences(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	saved := preferenceMap(stored)
+
+	now := time.Now()
+	notifications := make([]entities.Notification, 0, len(users))
+	for _, user := range users {
+		n := entities.Notification{
+			UserID:     user.ID,
+			Type:       alert.Type,
+			Title:      alert.Title,
+			Message:    alert.Message,
+			ShopID:     alert.ShopID,
+			DocumentID: alert.DocumentID,
+			Reference:  alert.Reference,
+		}
+		if _, ok := channelPreference(saved, user.ID, alert.Type, entities.NotificationChannelInApp); !ok {
+			n.ReadAt = &now
+		}
+		notifications = append(notifications, n)
+	}
+	if err := s.notificationRepo.CreateNotifications(notifications); err != nil {
+		return nil, err
+	}
+
+	var pending []pendingDelivery
+	for i, user := range users {
+		for _, channel := range entities.NotificationChannels {
+			if channel == entities.NotificationChannelInApp {
+				continue
+			}
+			preference, ok := channelPreference(saved, user.ID, alert.Type, channel)
+			if !ok {
+				continue
+			}
+			pending = append(pending, pendingDelivery{
+				channel: channel,
+				message: notification.Message{
+					To:           s.target(user, channel, preference.Target),
+					Subject:      alert.Title,
+					Body:         alert.Message,
+					Notification: &notifications[i],
+				},
+			})
+		}
+	}
+	if len(pending) > 0 {
+		go s.deliver(pending)
+	}
+
+	return notifications, nil
+}
+
+// deliver sends each message and records how it went
+func (s *notificationService) deliver(pending []pendingDelivery) {
+	for _, p := range pending {
+		delivery := &entities.NotificationDelivery{
+			NotificationID: p.message.Notification.ID,
+			Channel:        p.channel,
+			Target:         p.message.To,
+			Status:         entities.NotificationDeliverySent,
+		}
+
+		channel, ok := s.channels[p.channel]
+		if !ok {
+			delivery.Status = entities.NotificationDeliveryFailed
+			delivery.Error = "channel is not set up"
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+			if err := channel.Send(ctx, p.message); err != nil {
+				delivery.Status = entities.NotificationDeliveryFailed
+				delivery.Error = err.Error()
+			}
+			cancel()
+		}
+
+		if err := s.notificationRepo.RecordDelivery(delivery); err != nil {
+			logger.Error("Failed to record notification delivery: " + err.Error())
+		}
+	}
+}
+
+// target is where a channel reaches the user: the preference's own target,
+// or else the user's email address or phone number, or the default webhook
+func (s *notificationService) target(user entities.User, channel entities.NotificationChannel, override string) string {
+	if override != "" {
+		return override
+	}
+	switch channel {
+	case entities.NotificationChannelEmail:
+		return user.Email
+	case entities.NotificationChannelSMS:
+		return user.Phone
+	case entities.NotificationChannelWebhook:
+		return s.config.WebhookURL
+	}
+	return ""
+}
+
+// checkTarget makes sure a target fits its channel: an email address, a phone
+// number, or an http(s) URL on the configured webhook host or one of the
+// allowed hosts. The inbox takes no target.
+func (s *notificationService) checkTarget(channel entities.NotificationChannel, target string) error {
+	if target == "" {
+		return nil
+	}
+	if strings.ContainsAny(target, "\r\n") {
+		return fmt.Errorf("%w: line breaks are not allowed", ErrInvalidNotificationTarget)
+	}
+
+	switch channel {
+	case entities.NotificationChannelEmail:
+		address, err := mail.ParseAddress(target)
+		if err != nil || address.Address != target {
+			return fmt.Errorf("%w: not an email address", ErrInvalidNotificationTarget)
+		}
+	case entities.NotificationChannelSMS:
+		if !phoneNumber.MatchString(strings.NewReplacer(" ", "", "-", "").Replace(target)) {
+			return fmt.Errorf("%w: not a phone number", ErrInvalidNotificationTarget)
+		}
+	case entities.NotificationChannelWebhook:
+		webhook, err := url.Parse(target)
+		if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Hostname() == "" {
+			return fmt.Errorf("%w: not an http or https URL", ErrInvalidNotificationTarget)
+		}
+		if !s.webhookHostAllowed(webhook.Hostname()) {
+			return fmt.Errorf("%w: webhooks can't be sent to %s", ErrInvalidNotificationTarget, webhook.Hostname())
+		}
+	default:
+		return fmt.Errorf("%w: the inbox takes no target", ErrInvalidNotificationTarget)
+	}
+	return nil
+}
+
+func (s *notificationService) webhookHostAllowed(host string) bool {
+	allowed := s.config.WebhookHosts
+	if configured, err := url.Parse(s.config.WebhookURL); err == nil && configured.Hostname() != "" {
+		allowed = append(slices.Clone(allowed), configured.Hostname())
+	}
+	return slices.ContainsFunc(allowed, func(allowedHost string) bool {
+		return strings.EqualFold(allowedHost, host)
+	})
+}
+
+func preferenceMap(preferences []entities.NotificationPreference) map[preferenceKey]entities.NotificationPreference {
+	saved := make(map[preferenceKey]entities.NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		saved[preferenceKey{preference.UserID, preference.Type, preference.Channel}] = preference
+	}
+	return saved
+}
+
+// channelPreference returns the user's preference for the channel and
+// whether the channel is on for the type. Test notifications use every
+// channel the user turned on for any type.
+func channelPreference(saved map[preferenceKey]entities.NotificationPreference, userID uuid.UUID, notificationType entities.NotificationType, channel entities.NotificationChannel) (entities.NotificationPreference, bool) {
+	if notificationType == entities.NotificationTypeTest {
+		for _, t := range entities.NotificationTypes {
+			if preference, ok := saved[preferenceKey{userID, t, channel}]; ok && preference.Enabled {
+				return preference, true
+			}
+		}
+		preference := entities.DefaultNotificationPreference(userID, notificationType, channel)
+		return preference, preference.Enabled
+	}
+
+	preference, ok := saved[preferenceKey{userID, notificationType, channel}]
+	if !ok {
+		preference = entities.DefaultNotificationPreference(userID, notificationType, channel)
+	}
+	return preference, preference.Enabled
+}
+
+// raiseAlert sends an alert without letting a failure to notify undo the
+// work that raised it
+func raiseAlert(notificationService NotificationService, alert entities.Alert) {
+	if notificationService == nil {
+		return
+	}
+	if err := notificationService.Notify(alert); err != nil {
+		logger.Error("Failed to raise notification: " + err.Error())
+	}
+}
+
+// raiseLowStock checks the products for low stock after stock left the shop,
+// logging rather than returning any failure
+func raiseLowStock(notificationService NotificationService, shopID uuid.UUID, productIDs []uuid.UUID) {
+	if notificationService == nil || len(productIDs) == 0 {
+		return
+	}
+	if err := notificationService.CheckLowStock(shopID, productIDs); err != nil {
+		logger.Error("Failed to check low stock: " + err.Error())
+	}
+}
@@ -0,0 +1,156 @@
+package usecases
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	"Sheikh-Enterprise-Backend/internal/infrastructure/notification"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+// fakeNotificationRepository keeps notifications and deliveries in memory.
+// Methods the tests don't reach are left to the embedded nil interface.
+type fakeNotificationRepository struct {
+	repository.NotificationRepository
+
+	recipients  []entities.User
+	preferences []entities.NotificationPreference
+
+	mu            sync.Mutex
+	notifications []entities.Notification
+	deliveries    chan entities.NotificationDelivery
+}
+
+func (r *fakeNotificationRepository) HasRecent(reference string, since time.Time) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeNotificationRepository) GetRecipients(shopID *uuid.UUID) ([]entities.User, error) {
+	return r.recipients, nil
+}
+
+func (r *fakeNotificationRepository) GetPreferences(userIDs []uuid.UUID) ([]entities.NotificationPreference, error) {
+	return r.preferences, nil
+}
+
+func (r *fakeNotificationRepository) CreateNotifications(notifications []entities.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range notifications {
+		notifications[i].ID = uuid.New()
+	}
+	r.notifications = append(r.notifications, notifications...)
+	return nil
+}
+
+func (r *fakeNotificationRepository) RecordDelivery(delivery *entities.NotificationDelivery) error {
+	r.deliveries <- *delivery
+	return nil
+}
+
+func (r *fakeNotificationRepository) UpsertPreference(preference *entities.NotificationPreference) error {
+	return nil
+}
+
+func TestNotifySendsThroughEnabledChannels(t *testing.T) {
+	user := entities.User{ID: uuid.New(), Email: "manager@example.com", Phone: "+8801700000000"}
+	repo := &fakeNotificationRepository{
+		recipients: []entities.User{user},
+		preferences: []entities.NotificationPreference{
+			{UserID: user.ID, Type: entities.NotificationTypeLowStock, Channel: entities.NotificationChannelEmail, Enabled: true},
+		},
+		deliveries: make(chan entities.NotificationDelivery, 3),
+	}
+	email, sms := notification.NewOutbox(), notification.NewOutbox()
+	service := NewNotificationService(repo, nil, notification.Channels{
+		entities.NotificationChannelEmail: email,
+		entities.NotificationChannelSMS:   sms,
+	}, config.NotificationConfig{})
+
+	shopID := uuid.New()
+	err := service.Notify(entities.Alert{
+		Type:    entities.NotificationTypeLowStock,
+		Title:   "Low stock: TS100-NAVY-XL",
+		Message: "Navy T-shirt (TS100-NAVY-XL) is down to 2 in Gulshan.",
+		ShopID:  &shopID,
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	// Sending happens in the background and ends with the delivery record
+	select {
+	case delivery := <-repo.deliveries:
+		if delivery.Channel != entities.NotificationChannelEmail || delivery.Status != entities.NotificationDeliverySent {
+			t.Errorf("delivery = %s %s, want EMAIL sent", delivery.Channel, delivery.Status)
+		}
+		if delivery.Target != user.Email {
+			t.Errorf("delivery target = %q, want %q", delivery.Target, user.Email)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery recorded")
+	}
+
+	sent := email.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("email outbox has %d messages, want 1", len(sent))
+	}
+	if sent[0].To != user.Email || sent[0].Subject != "Low stock: TS100-NAVY-XL" {
+		t.Errorf("email = %q to %q", sent[0].Subject, sent[0].To)
+	}
+	if sent[0].Notification == nil || sent[0].Notification.UserID != user.ID {
+		t.Error("email is not tied to the user's notification")
+	}
+	if len(sms.Sent()) != 0 {
+		t.Error("SMS sent although the user didn't turn it on")
+	}
+	if len(repo.notifications) != 1 || repo.notifications[0].ReadAt != nil {
+		t.Error("notification missing from the user's inbox or already read")
+	}
+}
+
+func TestSavePreferenceChecksTarget(t *testing.T) {
+	service := NewNotificationService(&fakeNotificationRepository{}, nil, notification.Channels{}, config.NotificationConfig{
+		WebhookURL:   "https://hooks.example.com/stock",
+		WebhookHosts: []string{"alerts.example.org"},
+	})
+
+	tests := []struct {
+		channel entities.NotificationChannel
+		target  string
+		valid   bool
+	}{
+		{entities.NotificationChannelEmail, "buyer@example.com", true},
+		{entities.NotificationChannelEmail, "Buyer <buyer@example.com>", false},
+		{entities.NotificationChannelEmail, "buyer@example.com\r\nBcc: all@example.com", false},
+		{entities.NotificationChannelSMS, "+880 1700-000000", true},
+		{entities.NotificationChannelSMS, "call me", false},
+		{entities.NotificationChannelWebhook, "https://hooks.example.com/other", true},
+		{entities.NotificationChannelWebhook, "https://ALERTS.example.org/in", true},
+		{entities.NotificationChannelWebhook, "http://169.254.169.254/latest/meta-data", false},
+		{entities.NotificationChannelWebhook, "ftp://hooks.example.com/stock", false},
+		{entities.NotificationChannelInApp, "someone@example.com", false},
+		{entities.NotificationChannelInApp, "", true},
+	}
+	for _, test := range tests {
+		err := service.SavePreference(&entities.NotificationPreference{
+			UserID:  uuid.New(),
+			Type:    entities.NotificationTypeLowStock,
+			Channel: test.channel,
+			Enabled: true,
+			Target:  test.target,
+		})
+		if test.valid && err != nil {
+			t.Errorf("%s %q: unexpected error %v", test.channel, test.target, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidNotificationTarget) {
+			t.Errorf("%s %q: error = %v, want ErrInvalidNotificationTarget", test.channel, test.target, err)
+		}
+	}
+}
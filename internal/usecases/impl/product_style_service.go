@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type ProductStyleService interface {
+	GetProductStyles(page, pageSize int, filters map[string]interface{}) ([]entities.ProductStyle, int64, error)
+	GetProductStyleByID(id uuid.UUID) (*entities.ProductStyle, error)
+	CreateProductStyle(style *entities.ProductStyle, colors, sizes []string) error
+	UpdateProductStyle(style *entities.ProductStyle) error
+	GenerateVariants(id uuid.UUID, colors, sizes []string) ([]entities.Product, error)
+	AttachVariants(id uuid.UUID, productIDs []uuid.UUID) (*entities.ProductStyle, error)
+	GetStockMatrix(id uuid.UUID, shopID *uuid.UUID) (*entities.StockMatrix, error)
+	GetStyleStock(filters map[string]interface{}) ([]entities.StyleStock, error)
+}
+
+type productStyleService struct {
+	productStyleRepo repository.ProductStyleRepository
+}
+
+func NewProductStyleService(productStyleRepo repository.ProductStyleRepository) ProductStyleService {
+	return &productStyleService{
+		productStyleRepo: productStyleRepo,
+	}
+}
+
+func (s *productStyleService) GetProductStyles(page, pageSize int, filters map[string]interface{}) ([]entities.ProductStyle, int64, error) {
+	return s.productStyleRepo.GetStylesWithFilters(filters, page, pageSize)
+}
+
+func (s *productStyleService) GetProductStyleByID(id uuid.UUID) (*entities.ProductStyle, error) {
+	return s.productStyleRepo.GetByID(id)
+}
+
+func (s *productStyleService) CreateProductStyle(style *entities.ProductStyle, colors, sizes []string) error {
+	return s.productStyleRepo.CreateWithVariants(style, colors, sizes)
+}
+
+func (s *productStyleService) UpdateProductStyle(style *entities.ProductStyle) error {
+	return s.productStyleRepo.UpdateWithVariants(style)
+}
+
+func (s *productStyleService) GenerateVariants(id uuid.UUID, colors, sizes []string) ([]entities.Product, error) {
+	return s.productStyleRepo.GenerateVariants(id, colors, sizes)
+}
+
+func (s *productStyleService) AttachVariants(id uuid.UUID, productIDs []uuid.UUID) (*entities.ProductStyle, error) {
+	if err := s.productStyleRepo.AttachVariants(id, productIDs); err != nil {
+		return nil, err
+	}
+	return s.productStyleRepo.GetByID(id)
+}
+
+func (s *productStyleService) GetStockMatrix(id uuid.UUID, shopID *uuid.UUID) (*entities.StockMatrix, error) {
+	return s.productStyleRepo.GetStockMatrix(id, shopID)
+}
+
+func (s *productStyleService) GetStyleStock(filters map[string]interface{}) ([]entities.StyleStock, error) {
+	return s.productStyleRepo.GetStyleStock(filters)
+}
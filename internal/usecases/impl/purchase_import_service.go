@@ -0,0 +1,298 @@
+package usecases
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	"Sheikh-Enterprise-Backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	ErrUnsupportedImportFile = errors.New("only CSV and XLSX files can be imported")
+	ErrImportMissingColumns  = errors.New("file must have code, quantity and price columns")
+	ErrImportEmpty           = errors.New("file has no invoice lines")
+)
+
+// Header names accepted for each column of a supplier invoice file
+var importColumns = map[string][]string{
+	"code":        {"code", "product_code", "item_code", "supplier_code", "sku"},
+	"quantity":    {"quantity", "qty"},
+	"price":       {"price", "unit_price", "unit_cost", "cost", "rate"},
+	"description": {"description", "name", "product_name", "item"},
+}
+
+type PurchaseImportService interface {
+	PreviewImport(supplierID uuid.UUID, fileName string, reader io.Reader) (*entities.PurchaseImportPreview, error)
+	ConfirmImport(purchase *entities.PurchaseInvoice, supplierCodes map[uuid.UUID]string) error
+}
+
+type purchaseImportService struct {
+	purchaseService     PurchaseService
+	purchaseRepo        repository.PurchaseRepository
+	productRepo         repository.ProductRepository
+	supplierProductRepo repository.SupplierProductRepository
+	notificationService NotificationService
+}
+
+func NewPurchaseImportService(purchaseService PurchaseService, purchaseRepo repository.PurchaseRepository, productRepo repository.ProductRepository, supplierProductRepo repository.SupplierProductRepository, notificationService NotificationService) PurchaseImportService {
+	return &purchaseImportService{
+		purchaseService:     purchaseService,
+		purchaseRepo:        purchaseRepo,
+		productRepo:         productRepo,
+		supplierProductRepo: supplierProductRepo,
+		notificationService: notificationService,
+	}
+}
+
+// PreviewImport parses a supplier's invoice file and matches each line to a
+// product, first by the supplier's own code and then by our product code.
+// Nothing is saved. Admins are told about files that can't be read.
+func (s *purchaseImportService) PreviewImport(supplierID uuid.UUID, fileName string, reader io.Reader) (*entities.PurchaseImportPreview, error) {
+	preview, err := s.previewImport(supplierID, fileName, reader)
+	if err != nil {
+		raiseAlert(s.notificationService, entities.Alert{
+			Type:    entities.NotificationTypeImportFailed,
+			Title:   "Supplier invoice import failed",
+			Message: fmt.Sprintf("%s from supplier %s could not be imported: %v", fileName, supplierID, err),
+		})
+	}
+	return preview, err
+}
+
+func (s *purchaseImportService) previewImport(supplierID uuid.UUID, fileName string, reader io.Reader) (*entities.PurchaseImportPreview, error) {
+	rows, err := readImportRows(fileName, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := parseImportRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	for _, line := range lines {
+		if line.Status != entities.ImportLineInvalid {
+			codes = append(codes, line.SupplierCode)
+		}
+	}
+
+	links, err := s.supplierProductRepo.GetBySupplierCodes(supplierID, codes)
+	if err != nil {
+		return nil, err
+	}
+	bySupplierCode := make(map[string]*entities.Product, len(links))
+	for _, link := range links {
+		if link.Product != nil {
+			bySupplierCode[link.SupplierCode] = link.Product
+		}
+	}
+
+	products, err := s.productRepo.GetByCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+	byProductCode := make(map[string]*entities.Product, len(products))
+	for i := range products {
+		byProductCode[products[i].Code] = &products[i]
+	}
+
+	preview := &entities.PurchaseImportPreview{
+		SupplierID: supplierID,
+		FileName:   fileName,
+	}
+	var productIDs []uuid.UUID
+	for i := range lines {
+		line := &lines[i]
+		if line.Status == entities.ImportLineInvalid {
+			continue
+		}
+
+		product, matchedBy := bySupplierCode[line.SupplierCode], entities.ImportMatchSupplierCode
+		if product == nil {
+			product, matchedBy = byProductCode[line.SupplierCode], entities.ImportMatchProductCode
+		}
+		if product == nil {
+			line.Status = entities.ImportLineUnmatched
+			continue
+		}
+
+		productID := product.ID
+		line.Status = entities.ImportLineMatched
+		line.ProductID = &productID
+		line.ProductCode = product.Code
+		line.ProductName = product.Name
+		line.MatchedBy = matchedBy
+		productIDs = append(productIDs, productID)
+	}
+
+	// Compare with what this supplier last charged
+	history, err := s.purchaseRepo.GetPriceHistory(productIDs, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	lastPrice := make(map[uuid.UUID]float64)
+	for _, entry := range history {
+		if entry.SupplierID == supplierID {
+			lastPrice[entry.ProductID] = entry.UnitPrice
+		}
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		switch line.Status {
+		case entities.ImportLineInvalid:
+			preview.InvalidCount++
+			continue
+		case entities.ImportLineUnmatched:
+			preview.UnmatchedCount++
+			continue
+		}
+
+		preview.MatchedCount++
+		preview.MatchedTotal += line.UnitPrice * float64(line.Quantity)
+		if price, ok := lastPrice[*line.ProductID]; ok {
+			line.LastPrice = &price
+			line.PriceDifference = math.Round((line.UnitPrice-price)*100) / 100
+			if price > 0 {
+				line.PriceDifferencePercent = math.Round((line.UnitPrice-price)/price*10000) / 100
+			}
+		}
+	}
+	preview.Lines = lines
+
+	return preview, nil
+}
+
+// ConfirmImport creates the reviewed purchase through the purchase service
+// and remembers the supplier's code for each product it was given for.
+// Failing to save the codes is logged rather than returned, since the
+// purchase is already in and retrying would create it again.
+func (s *purchaseImportService) ConfirmImport(purchase *entities.PurchaseInvoice, supplierCodes map[uuid.UUID]string) error {
+	if err := s.purchaseService.CreatePurchase(purchase); err != nil {
+		return err
+	}
+
+	var links []entities.SupplierProduct
+	for productID, code := range supplierCodes {
+		if code == "" {
+			continue
+		}
+		links = append(links, entities.SupplierProduct{
+			SupplierID:   purchase.SupplierID,
+			ProductID:    productID,
+			SupplierCode: code,
+		})
+	}
+
+	if err := s.supplierProductRepo.SaveSupplierCodes(links); err != nil {
+		logger.Error("Failed to save supplier codes for imported purchase " + purchase.ID.String() + ": " + err.Error())
+	}
+	return nil
+}
+
+// readImportRows reads every row of a CSV file or the first sheet of an
+// XLSX workbook
+func readImportRows(fileName string, reader io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		csvReader := csv.NewReader(reader)
+		csvReader.FieldsPerRecord = -1
+		csvReader.TrimLeadingSpace = true
+		return csvReader.ReadAll()
+	case ".xlsx":
+		f, err := excelize.OpenReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0))
+	default:
+		return nil, ErrUnsupportedImportFile
+	}
+}
+
+// parseImportRows finds the columns from the header row and reads each line.
+// Rows with a bad quantity or price are kept and marked invalid so the
+// preview can show them.
+func parseImportRows(rows [][]string) ([]entities.PurchaseImportLine, error) {
+	if len(rows) < 2 {
+		return nil, ErrImportEmpty
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		header = strings.ToLower(strings.TrimSpace(header))
+		for column, names := range importColumns {
+			for _, name := range names {
+				if _, seen := columns[column]; !seen && header == name {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	for _, column := range []string{"code", "quantity", "price"} {
+		if _, ok := columns[column]; !ok {
+			return nil, ErrImportMissingColumns
+		}
+	}
+
+	cell := func(row []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var lines []entities.PurchaseImportLine
+	for i, row := range rows[1:] {
+		code := cell(row, "code")
+		if code == "" && cell(row, "quantity") == "" {
+			continue // Blank row
+		}
+
+		line := entities.PurchaseImportLine{
+			Row:          i + 2, // Rows are numbered from 1, after the header
+			SupplierCode: code,
+			Description:  cell(row, "description"),
+		}
+
+		quantity, err := strconv.ParseFloat(cell(row, "quantity"), 64)
+		switch {
+		case code == "":
+			line.Error = "missing code"
+		case err != nil || quantity <= 0 || quantity != math.Trunc(quantity):
+			line.Error = fmt.Sprintf("invalid quantity %q", cell(row, "quantity"))
+		}
+		line.Quantity = int(quantity)
+
+		price, err := strconv.ParseFloat(strings.ReplaceAll(cell(row, "price"), ",", ""), 64)
+		if line.Error == "" && (err != nil || price < 0) {
+			line.Error = fmt.Sprintf("invalid price %q", cell(row, "price"))
+		}
+		line.UnitPrice = price
+
+		if line.Error != "" {
+			line.Status = entities.ImportLineInvalid
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return lines, nil
+}
@@ -0,0 +1,191 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPurchaseOrderNotDraft     = errors.New("only draft purchase orders can be sent")
+	ErrPurchaseOrderNotReceiving = repository.ErrOrderNotReceiving
+	ErrPurchaseOrderClosed       = errors.New("purchase order is already closed")
+)
+
+type PurchaseOrderService interface {
+	GetPurchaseOrders(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.PurchaseOrder, int64, error)
+	GetPurchaseOrderByID(id uuid.UUID) (*entities.PurchaseOrder, error)
+	CreatePurchaseOrder(order *entities.PurchaseOrder) error
+	SendPurchaseOrder(id uuid.UUID) (*entities.PurchaseOrder, error)
+	ClosePurchaseOrder(id uuid.UUID) (*entities.PurchaseOrder, error)
+	ReceiveGoods(receipt *entities.GoodsReceipt) error
+	GetGoodsReceipts(purchaseOrderID uuid.UUID) ([]entities.GoodsReceipt, error)
+	GetMatchReport(purchaseOrderID uuid.UUID) (*entities.PurchaseMatchReport, error)
+}
+
+type purchaseOrderService struct {
+	purchaseOrderRepo repository.PurchaseOrderRepository
+}
+
+func NewPurchaseOrderService(purchaseOrderRepo repository.PurchaseOrderRepository) PurchaseOrderService {
+	return &purchaseOrderService{
+		purchaseOrderRepo: purchaseOrderRepo,
+	}
+}
+
+func (s *purchaseOrderService) GetPurchaseOrders(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.PurchaseOrder, int64, error) {
+	return s.purchaseOrderRepo.GetPurchaseOrdersWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(id uuid.UUID) (*entities.PurchaseOrder, error) {
+	return s.purchaseOrderRepo.GetWithLines(id)
+}
+
+func (s *purchaseOrderService) CreatePurchaseOrder(order *entities.PurchaseOrder) error {
+	// Calculate total
+	var total float64
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		line.ReceivedQuantity = 0
+		total += line.UnitPrice * float64(line.OrderedQuantity)
+	}
+	order.Total = total
+	order.Status = entities.PurchaseOrderStatusDraft
+
+	return s.purchaseOrderRepo.Create(order)
+}
+
+func (s *purchaseOrderService) SendPurchaseOrder(id uuid.UUID) (*entities.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != entities.PurchaseOrderStatusDraft {
+		return nil, ErrPurchaseOrderNotDraft
+	}
+
+	now := time.Now()
+	order.Status = entities.PurchaseOrderStatusSent
+	order.SentAt = &now
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ClosePurchaseOrder closes an order short when the supplier will not ship
+// the remaining quantities
+func (s *purchaseOrderService) ClosePurchaseOrder(id uuid.UUID) (*entities.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == entities.PurchaseOrderStatusClosed {
+		return nil, ErrPurchaseOrderClosed
+	}
+
+	now := time.Now()
+	order.Status = entities.PurchaseOrderStatusClosed
+	order.ClosedAt = &now
+	if err := s.purchaseOrderRepo.Update(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ReceiveGoods records a receipt against a sent or partially received order.
+// The order's status is checked while it is locked for the receipt.
+func (s *purchaseOrderService) ReceiveGoods(receipt *entities.GoodsReceipt) error {
+	return s.purchaseOrderRepo.CreateReceipt(receipt)
+}
+
+func (s *purchaseOrderService) GetGoodsReceipts(purchaseOrderID uuid.UUID) ([]entities.GoodsReceipt, error) {
+	return s.purchaseOrderRepo.GetReceipts(purchaseOrderID)
+}
+
+// GetMatchReport compares what was ordered, what arrived and what the
+// supplier billed, line by line
+func (s *purchaseOrderService) GetMatchReport(purchaseOrderID uuid.UUID) (*entities.PurchaseMatchReport, error) {
+	order, err := s.purchaseOrderRepo.GetWithLines(purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.purchaseOrderRepo.GetInvoices(purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.PurchaseMatchReport{
+		PurchaseOrderID: order.ID,
+		OrderTotal:      order.Total,
+		Matched:         true,
+	}
+
+	linesByProduct := make(map[uuid.UUID]*entities.PurchaseMatchLine)
+	var products []uuid.UUID
+	lineFor := func(productID uuid.UUID) *entities.PurchaseMatchLine {
+		line, ok := linesByProduct[productID]
+		if !ok {
+			line = &entities.PurchaseMatchLine{ProductID: productID}
+			linesByProduct[productID] = line
+			products = append(products, productID)
+		}
+		return line
+	}
+
+	for _, orderLine := range order.Lines {
+		line := lineFor(orderLine.ProductID)
+		line.OrderedQuantity += orderLine.OrderedQuantity
+		line.ReceivedQuantity += orderLine.ReceivedQuantity
+		line.OrderPrice = orderLine.UnitPrice
+		report.ReceivedValue += orderLine.UnitPrice * float64(orderLine.ReceivedQuantity)
+	}
+
+	invoicedValue := make(map[uuid.UUID]float64)
+	for _, invoice := range invoices {
+		report.InvoicedTotal += invoice.Total
+		for _, detail := range invoice.PurchaseDetails {
+			line := lineFor(detail.ProductID)
+			line.InvoicedQuantity += detail.Quantity
+			invoicedValue[detail.ProductID] += detail.PurchasePrice * float64(detail.Quantity)
+		}
+	}
+
+	for _, productID := range products {
+		line := linesByProduct[productID]
+		if line.InvoicedQuantity > 0 {
+			line.InvoicePrice = invoicedValue[productID] / float64(line.InvoicedQuantity)
+		}
+
+		if line.OrderedQuantity == 0 {
+			line.Discrepancies = append(line.Discrepancies, "invoiced product was not ordered")
+		}
+		if line.ReceivedQuantity < line.OrderedQuantity && order.Status != entities.PurchaseOrderStatusClosed {
+			line.Discrepancies = append(line.Discrepancies, fmt.Sprintf("%d ordered but not yet received", line.OrderedQuantity-line.ReceivedQuantity))
+		}
+		if line.InvoicedQuantity > line.ReceivedQuantity {
+			line.Discrepancies = append(line.Discrepancies, fmt.Sprintf("%d invoiced but not received", line.InvoicedQuantity-line.ReceivedQuantity))
+		}
+		if line.InvoicedQuantity < line.ReceivedQuantity {
+			line.Discrepancies = append(line.Discrepancies, fmt.Sprintf("%d received but not invoiced", line.ReceivedQuantity-line.InvoicedQuantity))
+		}
+		if line.InvoicedQuantity > 0 && line.OrderedQuantity > 0 && line.InvoicePrice != line.OrderPrice {
+			line.Discrepancies = append(line.Discrepancies, fmt.Sprintf("invoice price %.2f differs from order price %.2f", line.InvoicePrice, line.OrderPrice))
+		}
+
+		if len(line.Discrepancies) > 0 {
+			report.Matched = false
+		}
+		report.Lines = append(report.Lines, *line)
+	}
+
+	return report, nil
+}
@@ -1,26 +1,48 @@
-
 package usecases
 
 import (
+	"Sheikh-Enterprise-Backend/internal/config"
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/google/uuid"
 )
 
+var (
+	ErrPurchaseShopRequired    = errors.New("purchase must specify the receiving shop")
+	ErrInvalidPurchaseQuantity = errors.New("purchase quantity must be greater than zero")
+	ErrPurchaseOrderMismatch   = errors.New("purchase order belongs to a different supplier")
+)
+
 type PurchaseService interface {
 	GetPurchases(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.PurchaseInvoice, int64, error)
 	GetPurchaseByID(id uuid.UUID) (*entities.PurchaseInvoice, error)
 	CreatePurchase(purchase *entities.PurchaseInvoice) error
 	DeletePurchase(id uuid.UUID) error
+	GetPriceHistory(productID uuid.UUID) (*entities.ProductPriceHistory, error)
+	UpdatePurchase(id uuid.UUID, req *entities.UpdatePurchaseRequest, editedByID uuid.UUID) (*entities.PurchaseInvoice, error)
+	LockPurchase(id uuid.UUID) (*entities.PurchaseInvoice, error)
+	GetRevisions(id uuid.UUID) ([]entities.PurchaseRevision, error)
 }
 
 type purchaseService struct {
-	purchaseRepo repository.PurchaseRepository
+	purchaseRepo      repository.PurchaseRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	currencyRepo      repository.CurrencyRepository
+	config            config.PurchaseConfig
 }
 
-func NewPurchaseService(purchaseRepo repository.PurchaseRepository) PurchaseService {
+func NewPurchaseService(purchaseRepo repository.PurchaseRepository, purchaseOrderRepo repository.PurchaseOrderRepository, currencyRepo repository.CurrencyRepository, cfg config.PurchaseConfig) PurchaseService {
 	return &purchaseService{
-		purchaseRepo: purchaseRepo,
+		purchaseRepo:      purchaseRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		currencyRepo:      currencyRepo,
+		config:            cfg,
 	}
 }
 
@@ -29,20 +51,277 @@ func (s *purchaseService) GetPurchases(page, pageSize int, filters map[string]in
 }
 
 func (s *purchaseService) GetPurchaseByID(id uuid.UUID) (*entities.PurchaseInvoice, error) {
-	return s.purchaseRepo.GetByID(id)
+	return s.purchaseRepo.GetWithDetails(id)
 }
 
 func (s *purchaseService) CreatePurchase(purchase *entities.PurchaseInvoice) error {
-	// Calculate total
-	var total float64
+	// An invoice against a purchase order is billed to the order's shop
+	if purchase.PurchaseOrderID != nil {
+		order, err := s.purchaseOrderRepo.GetByID(*purchase.PurchaseOrderID)
+		if err != nil {
+			return err
+		}
+		if order.SupplierID != purchase.SupplierID {
+			return ErrPurchaseOrderMismatch
+		}
+		purchase.ShopID = order.ShopID
+	}
+
+	if purchase.ShopID == uuid.Nil {
+		return ErrPurchaseShopRequired
+	}
+
+	code, rate, err := exchangeRateFor(s.currencyRepo, purchase.CurrencyCode, purchase.ExchangeRate, purchase.PurchaseDateTime)
+	if err != nil {
+		return err
+	}
+	purchase.CurrencyCode = code
+	purchase.ExchangeRate = rate
+
 	for i := range purchase.PurchaseDetails {
 		detail := &purchase.PurchaseDetails[i]
+		if detail.Quantity <= 0 {
+			return ErrInvalidPurchaseQuantity
+		}
+
+		// Landed costs are added to the purchase afterwards
+		detail.LandedCost = 0
+	}
+	applyExchangeRate(purchase)
+
+	warnings, err := s.checkPrices(purchase.PurchaseDetails)
+	if err != nil {
+		return err
+	}
+	purchase.PriceWarnings = warnings
+
+	// Save the invoice and receive its lines into the shop's stock together
+	return s.purchaseRepo.CreateWithStock(purchase)
+}
+
+// UpdatePurchase applies an edit on top of the current purchase. Header
+// fields left out of the request keep their values; the lines always
+// replace the purchase's lines.
+func (s *purchaseService) UpdatePurchase(id uuid.UUID, req *entities.UpdatePurchaseRequest, editedByID uuid.UUID) (*entities.PurchaseInvoice, error) {
+	purchase, err := s.purchaseRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SupplierID != "" {
+		supplierID := uuid.MustParse(req.SupplierID)
+		// The supplier of an invoice against a purchase order is fixed
+		if purchase.PurchaseOrderID != nil && supplierID != purchase.SupplierID {
+			return nil, ErrPurchaseOrderMismatch
+		}
+		purchase.SupplierID = supplierID
+	}
+	if req.PurchaseDateTime != nil {
+		purchase.PurchaseDateTime = *req.PurchaseDateTime
+	}
+	if req.PaymentType != "" {
+		purchase.PaymentType = entities.PaymentType(req.PaymentType)
+	}
+	if req.Remarks != nil {
+		purchase.Remarks = *req.Remarks
+	}
+
+	// The invoice keeps the rate it was entered at
+	purchase.PurchaseDetails = make([]entities.PurchaseDetail, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidPurchaseQuantity
+		}
+		detail := entities.PurchaseDetail{
+			ProductID:     uuid.MustParse(line.ProductID),
+			Quantity:      line.Quantity,
+			PurchasePrice: line.PurchasePrice,
+			CurrencyPrice: line.CurrencyPrice,
+			Weight:        line.Weight,
+			LotNumber:     line.LotNumber,
+			SerialNumbers: line.SerialNumbers,
+		}
+		if line.ID != "" {
+			detail.ID = uuid.MustParse(line.ID)
+		}
+		purchase.PurchaseDetails = append(purchase.PurchaseDetails, detail)
+	}
+	applyExchangeRate(purchase)
+
+	if err := s.purchaseRepo.UpdateWithStock(purchase, editedByID, req.Reason); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.purchaseRepo.GetWithDetails(id)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings, err := s.checkPrices(updated.PurchaseDetails)
+	if err != nil {
+		return nil, err
+	}
+	updated.PriceWarnings = warnings
+
+	return updated, nil
+}
+
+// applyExchangeRate prices the purchase's lines in taka and totals them.
+// Lines of a foreign currency invoice are entered at their currency price;
+// taka invoices are entered at the purchase price.
+func applyExchangeRate(purchase *entities.PurchaseInvoice) {
+	var total, currencyTotal float64
+	for i := range purchase.PurchaseDetails {
+		detail := &purchase.PurchaseDetails[i]
+		if purchase.CurrencyCode == entities.BaseCurrency {
+			detail.CurrencyPrice = detail.PurchasePrice
+		} else {
+			detail.PurchasePrice = math.Round(detail.CurrencyPrice*purchase.ExchangeRate*100) / 100
+		}
+		detail.LandedUnitCost = detail.PurchasePrice
+
 		total += detail.PurchasePrice * float64(detail.Quantity)
+		currencyTotal += detail.CurrencyPrice * float64(detail.Quantity)
 	}
 	purchase.Total = total
-	return s.purchaseRepo.Create(purchase)
+	purchase.CurrencyTotal = math.Round(currencyTotal*100) / 100
+}
+
+func (s *purchaseService) LockPurchase(id uuid.UUID) (*entities.PurchaseInvoice, error) {
+	if err := s.purchaseRepo.Lock(id); err != nil {
+		return nil, err
+	}
+	return s.purchaseRepo.GetWithDetails(id)
+}
+
+func (s *purchaseService) GetRevisions(id uuid.UUID) ([]entities.PurchaseRevision, error) {
+	if _, err := s.purchaseRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.purchaseRepo.GetRevisions(id)
 }
 
 func (s *purchaseService) DeletePurchase(id uuid.UUID) error {
-	return s.purchaseRepo.Delete(id)
+	// Reverses the received quantities; refused if the stock is already gone
+	return s.purchaseRepo.DeleteWithStock(id)
+}
+
+func (s *purchaseService) GetPriceHistory(productID uuid.UUID) (*entities.ProductPriceHistory, error) {
+	history, err := s.purchaseRepo.GetPriceHistory([]uuid.UUID{productID}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	// History is oldest first, so the last entry seen per supplier is the latest
+	bySupplier := make(map[uuid.UUID][]entities.PriceHistoryEntry)
+	for _, entry := range history {
+		bySupplier[entry.SupplierID] = append(bySupplier[entry.SupplierID], entry)
+	}
+
+	suppliers := make([]entities.SupplierPriceSummary, 0, len(bySupplier))
+	for supplierID, entries := range bySupplier {
+		last := entries[len(entries)-1]
+		summary := entities.SupplierPriceSummary{
+			SupplierID:      supplierID,
+			SupplierName:    last.SupplierName,
+			LastPrice:       last.UnitPrice,
+			LastPurchasedAt: last.PurchasedAt,
+			MinPrice:        last.UnitPrice,
+			MaxPrice:        last.UnitPrice,
+			AveragePrice:    averagePrice(entries),
+			PurchaseCount:   len(entries),
+			Trend:           entities.PriceTrendStable,
+		}
+		for _, entry := range entries {
+			summary.MinPrice = math.Min(summary.MinPrice, entry.UnitPrice)
+			summary.MaxPrice = math.Max(summary.MaxPrice, entry.UnitPrice)
+		}
+
+		if len(entries) > 1 {
+			if previous := averagePrice(entries[:len(entries)-1]); previous > 0 {
+				summary.TrendPercent = math.Round((last.UnitPrice-previous)/previous*10000) / 100
+			}
+			switch {
+			case summary.TrendPercent > 0:
+				summary.Trend = entities.PriceTrendUp
+			case summary.TrendPercent < 0:
+				summary.Trend = entities.PriceTrendDown
+			}
+		}
+		suppliers = append(suppliers, summary)
+	}
+
+	// Cheapest current supplier first
+	sort.Slice(suppliers, func(i, j int) bool {
+		return suppliers[i].LastPrice < suppliers[j].LastPrice
+	})
+
+	return &entities.ProductPriceHistory{
+		ProductID: productID,
+		Suppliers: suppliers,
+		History:   history,
+	}, nil
+}
+
+// checkPrices warns about lines priced more than the configured percentage
+// above the product's average price over the lookback period. Products
+// with no recent purchases are not checked.
+func (s *purchaseService) checkPrices(details []entities.PurchaseDetail) ([]entities.PriceWarning, error) {
+	if s.config.PriceWarningPercent <= 0 || len(details) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(details))
+	for _, detail := range details {
+		productIDs = append(productIDs, detail.ProductID)
+	}
+
+	since := time.Now().AddDate(0, 0, -s.config.PriceLookbackDays)
+	history, err := s.purchaseRepo.GetPriceHistory(productIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[uuid.UUID][]entities.PriceHistoryEntry)
+	for _, entry := range history {
+		byProduct[entry.ProductID] = append(byProduct[entry.ProductID], entry)
+	}
+
+	var warnings []entities.PriceWarning
+	for _, detail := range details {
+		average := averagePrice(byProduct[detail.ProductID])
+		if average <= 0 {
+			continue
+		}
+		percentAbove := (detail.PurchasePrice - average) / average * 100
+		if percentAbove <= s.config.PriceWarningPercent {
+			continue
+		}
+		percentAbove = math.Round(percentAbove*100) / 100
+		warnings = append(warnings, entities.PriceWarning{
+			ProductID:    detail.ProductID,
+			UnitPrice:    detail.PurchasePrice,
+			AveragePrice: average,
+			PercentAbove: percentAbove,
+			LookbackDays: s.config.PriceLookbackDays,
+			Message: fmt.Sprintf("price %.2f is %.2f%% above the %d day average of %.2f",
+				detail.PurchasePrice, percentAbove, s.config.PriceLookbackDays, average),
+		})
+	}
+
+	return warnings, nil
+}
+
+// averagePrice is the quantity weighted average unit price of the entries
+func averagePrice(entries []entities.PriceHistoryEntry) float64 {
+	var value float64
+	var quantity int
+	for _, entry := range entries {
+		value += entry.UnitPrice * float64(entry.Quantity)
+		quantity += entry.Quantity
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return math.Round(value/float64(quantity)*100) / 100
 }
@@ -0,0 +1,496 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNothingToReorder            = errors.New("no products with a known supplier need reordering")
+	ErrReorderImportMissingColumns = errors.New("file must have code, min, max and reorder_point columns")
+	ErrReorderImportEmpty          = errors.New("file has no reorder rules")
+)
+
+// Header names accepted for each column of a reorder rule file
+var reorderImportColumns = map[string][]string{
+	"code":          {"code", "product_code", "sku"},
+	"min":           {"min", "min_quantity", "minimum"},
+	"max":           {"max", "max_quantity", "maximum"},
+	"reorder_point": {"reorder_point", "reorder", "rop"},
+}
+
+// Defaults for a replenishment run when the caller leaves a setting out
+const (
+	defaultVelocityDays = 30
+	defaultLeadTimeDays = 7
+	defaultCoverDays    = 30
+)
+
+type ReplenishmentService interface {
+	GetRules(shopID uuid.UUID) ([]entities.ReorderRule, error)
+	SaveRule(rule *entities.ReorderRule) error
+	ImportRules(shopID uuid.UUID, fileName string, reader io.Reader) (*entities.ReorderRuleImportResult, error)
+	GetCategoryDefaults(shopID uuid.UUID) ([]entities.CategoryReorderDefault, error)
+	SaveCategoryDefault(categoryDefault *entities.CategoryReorderDefault) error
+	GetSuggestions(shopID uuid.UUID, params entities.ReplenishmentParams) (*entities.ReplenishmentPlan, error)
+	CreateDraftOrders(shopID uuid.UUID, params entities.ReplenishmentParams, productIDs []uuid.UUID, createdByID uuid.UUID) ([]entities.PurchaseOrder, error)
+}
+
+type replenishmentService struct {
+	replenishmentRepo   repository.ReplenishmentRepository
+	purchaseRepo        repository.PurchaseRepository
+	supplierProductRepo repository.SupplierProductRepository
+	productRepo         repository.ProductRepository
+	notificationService NotificationService
+}
+
+func NewReplenishmentService(replenishmentRepo repository.ReplenishmentRepository, purchaseRepo repository.PurchaseRepository, supplierProductRepo repository.SupplierProductRepository, productRepo repository.ProductRepository, notificationService NotificationService) ReplenishmentService {
+	return &replenishmentService{
+		replenishmentRepo:   replenishmentRepo,
+		purchaseRepo:        purchaseRepo,
+		supplierProductRepo: supplierProductRepo,
+		productRepo:         productRepo,
+		notificationService: notificationService,
+	}
+}
+
+// catalogKey identifies a supplier's catalog entry for a product
+type catalogKey struct {
+	supplierID uuid.UUID
+	productID  uuid.UUID
+}
+
+func (s *replenishmentService) GetRules(shopID uuid.UUID) ([]entities.ReorderRule, error) {
+	return s.replenishmentRepo.GetRules(shopID)
+}
+
+func (s *replenishmentService) SaveRule(rule *entities.ReorderRule) error {
+	rule.IsMarkedToDelete = false
+	return s.replenishmentRepo.UpsertRule(rule)
+}
+
+// ImportRules reads min, max and reorder point per product code from a CSV
+// or XLSX file and saves them as the shop's reorder rules. Rows with an
+// unknown code or bad limits are reported and the rest are saved. The shop's
+// managers are told when a file fails or has rows rejected.
+func (s *replenishmentService) ImportRules(shopID uuid.UUID, fileName string, reader io.Reader) (*entities.ReorderRuleImportResult, error) {
+	result, err := s.importRules(shopID, fileName, reader)
+	switch {
+	case err != nil:
+		raiseAlert(s.notificationService, entities.Alert{
+			Type:    entities.NotificationTypeImportFailed,
+			Title:   "Reorder rule import failed",
+			Message: fmt.Sprintf("%s could not be imported: %v", fileName, err),
+			ShopID:  &shopID,
+		})
+	case result.Rejected > 0:
+		raiseAlert(s.notificationService, entities.Alert{
+			Type:    entities.NotificationTypeImportFailed,
+			Title:   "Reorder rule import had rejected rows",
+			Message: fmt.Sprintf("%d of %d rows of %s were rejected.", result.Rejected, result.Applied+result.Rejected, fileName),
+			ShopID:  &shopID,
+		})
+	}
+	return result, err
+}
+
+func (s *replenishmentService) importRules(shopID uuid.UUID, fileName string, reader io.Reader) (*entities.ReorderRuleImportResult, error) {
+	rows, err := readImportRows(fileName, reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, ErrReorderImportEmpty
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		header = strings.ToLower(strings.TrimSpace(header))
+		for column, names := range reorderImportColumns {
+			if _, seen := columns[column]; !seen && slices.Contains(names, header) {
+				columns[column] = i
+			}
+		}
+	}
+	if len(columns) < len(reorderImportColumns) {
+		return nil, ErrReorderImportMissingColumns
+	}
+
+	cell := func(row []string, column string) string {
+		if i := columns[column]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	result := &entities.ReorderRuleImportResult{
+		ShopID:   shopID,
+		FileName: fileName,
+		Errors:   []entities.ReorderRuleImportError{},
+	}
+	reject := func(row int, code, message string) {
+		result.Rejected++
+		result.Errors = append(result.Errors, entities.ReorderRuleImportError{Row: row, ProductCode: code, Error: message})
+	}
+
+	type ruleRow struct {
+		row  int
+		code string
+		rule entities.ReorderRule
+	}
+	var parsed []ruleRow
+	var codes []string
+	seen := make(map[string]int)
+	for i, row := range rows[1:] {
+		rowNumber := i + 2 // Rows are numbered from 1, after the header
+		code := cell(row, "code")
+		if code == "" {
+			if strings.Join(row, "") != "" {
+				reject(rowNumber, code, "missing code")
+			}
+			continue
+		}
+		if first, ok := seen[code]; ok {
+			reject(rowNumber, code, fmt.Sprintf("code already given on row %d", first))
+			continue
+		}
+		seen[code] = rowNumber
+
+		limits := make(map[string]int, 3)
+		var bad string
+		for _, column := range []string{"min", "max", "reorder_point"} {
+			value, err := strconv.Atoi(cell(row, column))
+			if err != nil || value < 0 {
+				bad = fmt.Sprintf("invalid %s %q", column, cell(row, column))
+				break
+			}
+			limits[column] = value
+		}
+		if bad == "" && limits["max"] < limits["min"] {
+			bad = "max is below min"
+		}
+		if bad != "" {
+			reject(rowNumber, code, bad)
+			continue
+		}
+
+		parsed = append(parsed, ruleRow{
+			row:  rowNumber,
+			code: code,
+			rule: entities.ReorderRule{
+				ShopID:       shopID,
+				MinQuantity:  limits["min"],
+				MaxQuantity:  limits["max"],
+				ReorderPoint: limits["reorder_point"],
+			},
+		})
+		codes = append(codes, code)
+	}
+	if len(parsed) == 0 && result.Rejected == 0 {
+		return nil, ErrReorderImportEmpty
+	}
+
+	products, err := s.productRepo.GetByCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]uuid.UUID, len(products))
+	for _, product := range products {
+		byCode[product.Code] = product.ID
+	}
+
+	rules := make([]entities.ReorderRule, 0, len(parsed))
+	for _, line := range parsed {
+		productID, ok := byCode[line.code]
+		if !ok {
+			reject(line.row, line.code, "unknown product code")
+			continue
+		}
+		line.rule.ProductID = productID
+		rules = append(rules, line.rule)
+	}
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	if err := s.replenishmentRepo.UpsertRules(rules); err != nil {
+		return nil, err
+	}
+	result.Applied = len(rules)
+	return result, nil
+}
+
+func (s *replenishmentService) GetCategoryDefaults(shopID uuid.UUID) ([]entities.CategoryReorderDefault, error) {
+	return s.replenishmentRepo.GetCategoryDefaults(shopID)
+}
+
+func (s *replenishmentService) SaveCategoryDefault(categoryDefault *entities.CategoryReorderDefault) error {
+	categoryDefault.IsMarkedToDelete = false
+	return s.replenishmentRepo.UpsertCategoryDefault(categoryDefault)
+}
+
+// GetSuggestions works out what the shop should order and from whom. A
+// product is reordered once its stock plus open orders falls to its reorder
+// point, which is the larger of the rule's point, its minimum and the sales
+// expected during the lead time. Products without a rule use their category's
+// default in the shop. It is ordered up to the maximum, or to cover the lead
+// time and cover days of sales when no maximum is set.
+// The preferred supplier's lead time is used when the catalog has one.
+func (s *replenishmentService) GetSuggestions(shopID uuid.UUID, params entities.ReplenishmentParams) (*entities.ReplenishmentPlan, error) {
+	params = withReplenishmentDefaults(params)
+
+	since := time.Now().AddDate(0, 0, -params.VelocityDays)
+	positions, err := s.replenishmentRepo.GetStockPositions(shopID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(positions))
+	for _, position := range positions {
+		productIDs = append(productIDs, position.ProductID)
+	}
+	links, err := s.supplierProductRepo.GetByProducts(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	preferred := make(map[uuid.UUID]entities.SupplierProduct)
+	catalog := make(map[catalogKey]entities.SupplierProduct, len(links))
+	for _, link := range links {
+		catalog[catalogKey{link.SupplierID, link.ProductID}] = link
+		if link.IsPreferred {
+			preferred[link.ProductID] = link
+		}
+	}
+
+	var suggestions []entities.ReorderSuggestion
+	for _, position := range positions {
+		velocity := float64(position.SoldQuantity) / float64(params.VelocityDays)
+
+		leadTimeDays := *params.LeadTimeDays
+		if link, ok := preferred[position.ProductID]; ok && link.LeadTimeDays > 0 {
+			leadTimeDays = link.LeadTimeDays
+		}
+
+		reorderPoint := position.ReorderPoint
+		if position.MinQuantity > reorderPoint {
+			reorderPoint = position.MinQuantity
+		}
+		if leadTimeDemand := int(math.Ceil(velocity * float64(leadTimeDays))); leadTimeDemand > reorderPoint {
+			reorderPoint = leadTimeDemand
+		}
+
+		available := position.OnHand + position.OnOrder
+		if available > reorderPoint {
+			continue
+		}
+
+		target := position.MaxQuantity
+		if target == 0 {
+			target = int(math.Ceil(velocity * float64(leadTimeDays+params.CoverDays)))
+		}
+		if target < reorderPoint {
+			target = reorderPoint
+		}
+		if target-available <= 0 {
+			continue
+		}
+
+		suggestions = append(suggestions, entities.ReorderSuggestion{
+			ProductID:         position.ProductID,
+			ProductCode:       position.ProductCode,
+			ProductName:       position.ProductName,
+			OnHand:            position.OnHand,
+			OnOrder:           position.OnOrder,
+			MinQuantity:       position.MinQuantity,
+			MaxQuantity:       position.MaxQuantity,
+			ReorderPoint:      reorderPoint,
+			DailyVelocity:     math.Round(velocity*100) / 100,
+			LeadTimeDays:      leadTimeDays,
+			SuggestedQuantity: target - available,
+		})
+	}
+
+	groups, err := s.groupBySupplier(suggestions, preferred, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.ReplenishmentPlan{
+		ShopID: shopID,
+		Params: params,
+		Groups: groups,
+	}, nil
+}
+
+// CreateDraftOrders raises one draft purchase order per supplier from the
+// current suggestions, optionally limited to some products. Products with no
+// known supplier are left for the manager to order by hand.
+func (s *replenishmentService) CreateDraftOrders(shopID uuid.UUID, params entities.ReplenishmentParams, productIDs []uuid.UUID, createdByID uuid.UUID) ([]entities.PurchaseOrder, error) {
+	plan, err := s.GetSuggestions(shopID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(productIDs))
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+
+	var orders []entities.PurchaseOrder
+	for _, group := range plan.Groups {
+		if group.SupplierID == nil {
+			continue
+		}
+
+		order := entities.PurchaseOrder{
+			SupplierID:    *group.SupplierID,
+			ShopID:        shopID,
+			OrderDateTime: time.Now(),
+			Status:        entities.PurchaseOrderStatusDraft,
+			CreatedByID:   createdByID,
+			Remarks:       fmt.Sprintf("Raised by replenishment run (%d days of sales, %d days lead time)", plan.Params.VelocityDays, *plan.Params.LeadTimeDays),
+		}
+		for _, line := range group.Lines {
+			if len(wanted) > 0 && !wanted[line.ProductID] {
+				continue
+			}
+			order.Lines = append(order.Lines, entities.PurchaseOrderLine{
+				ProductID:       line.ProductID,
+				OrderedQuantity: line.SuggestedQuantity,
+				UnitPrice:       line.UnitPrice,
+			})
+			order.Total += line.UnitPrice * float64(line.SuggestedQuantity)
+		}
+		if len(order.Lines) > 0 {
+			orders = append(orders, order)
+		}
+	}
+
+	if len(orders) == 0 {
+		return nil, ErrNothingToReorder
+	}
+
+	if err := s.replenishmentRepo.CreateDraftOrders(orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// groupBySupplier assigns each suggestion to the product's preferred
+// supplier, or else the supplier it was last bought from. The quantity is
+// rounded up to the supplier's minimum order and pack size, and priced at
+// the catalog's last price or the last price actually paid.
+func (s *replenishmentService) groupBySupplier(suggestions []entities.ReorderSuggestion, preferred map[uuid.UUID]entities.SupplierProduct, catalog map[catalogKey]entities.SupplierProduct) ([]entities.ReorderSuggestionGroup, error) {
+	productIDs := make([]uuid.UUID, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		productIDs = append(productIDs, suggestion.ProductID)
+	}
+
+	history, err := s.purchaseRepo.GetPriceHistory(productIDs, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	// History is oldest first, so later entries overwrite earlier ones
+	lastPurchase := make(map[uuid.UUID]entities.PriceHistoryEntry)
+	lastPrice := make(map[catalogKey]float64)
+	for _, entry := range history {
+		lastPurchase[entry.ProductID] = entry
+		lastPrice[catalogKey{entry.SupplierID, entry.ProductID}] = entry.UnitPrice
+	}
+
+	groupIndex := make(map[uuid.UUID]int)
+	groups := []entities.ReorderSuggestionGroup{}
+	var unassigned *entities.ReorderSuggestionGroup
+	for _, suggestion := range suggestions {
+		var supplierID uuid.UUID
+		var supplierName string
+		if link, ok := preferred[suggestion.ProductID]; ok {
+			supplierID = link.SupplierID
+			if link.Supplier != nil {
+				supplierName = link.Supplier.Name
+			}
+		} else if entry, ok := lastPurchase[suggestion.ProductID]; ok {
+			supplierID = entry.SupplierID
+			supplierName = entry.SupplierName
+		} else {
+			if unassigned == nil {
+				unassigned = &entities.ReorderSuggestionGroup{SupplierName: "No known supplier"}
+			}
+			unassigned.Lines = append(unassigned.Lines, suggestion)
+			continue
+		}
+
+		key := catalogKey{supplierID, suggestion.ProductID}
+		suggestion.SupplierID = &supplierID
+		suggestion.UnitPrice = lastPrice[key]
+		if link, ok := catalog[key]; ok {
+			suggestion.SupplierCode = link.SupplierCode
+			suggestion.SuggestedQuantity = orderQuantity(link, suggestion.SuggestedQuantity)
+			if link.LastPrice > 0 {
+				suggestion.UnitPrice = link.LastPrice
+			}
+		}
+
+		i, ok := groupIndex[supplierID]
+		if !ok {
+			i = len(groups)
+			groupIndex[supplierID] = i
+			groups = append(groups, entities.ReorderSuggestionGroup{
+				SupplierID:   &supplierID,
+				SupplierName: supplierName,
+			})
+		}
+		groups[i].Lines = append(groups[i].Lines, suggestion)
+		groups[i].EstimatedTotal += suggestion.UnitPrice * float64(suggestion.SuggestedQuantity)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].SupplierName < groups[j].SupplierName
+	})
+	if unassigned != nil {
+		groups = append(groups, *unassigned)
+	}
+
+	return groups, nil
+}
+
+// orderQuantity rounds a wanted quantity up to the supplier's minimum order
+// quantity and then to a whole number of packs
+func orderQuantity(link entities.SupplierProduct, quantity int) int {
+	if quantity < link.MinOrderQuantity {
+		quantity = link.MinOrderQuantity
+	}
+	if link.PackSize > 1 {
+		if remainder := quantity % link.PackSize; remainder != 0 {
+			quantity += link.PackSize - remainder
+		}
+	}
+	return quantity
+}
+
+func withReplenishmentDefaults(params entities.ReplenishmentParams) entities.ReplenishmentParams {
+	if params.VelocityDays <= 0 {
+		params.VelocityDays = defaultVelocityDays
+	}
+	if params.LeadTimeDays == nil || *params.LeadTimeDays < 0 {
+		leadTimeDays := defaultLeadTimeDays
+		params.LeadTimeDays = &leadTimeDays
+	}
+	if params.CoverDays <= 0 {
+		params.CoverDays = defaultCoverDays
+	}
+	return params
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"Sheikh-Enterprise-Backend/internal/config"
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 
@@ -19,15 +20,20 @@ type SalesService interface {
 	ExportToExcel(filters map[string]interface{}, sorts []string) (*excelize.File, error)
 	GetAnalytics(shopID *uuid.UUID) (*repository.SalesAnalytics, error)
 	GetLast7DaysSales(shopID *uuid.UUID) ([]repository.DailySales, error)
+	GetGrossMargins(groupBy string, filters map[string]interface{}) ([]entities.GrossMargin, error)
 }
 
 type salesService struct {
-	salesRepo repository.SalesRepository
+	salesRepo           repository.SalesRepository
+	notificationService NotificationService
+	config              config.NotificationConfig
 }
 
-func NewSalesService(salesRepo repository.SalesRepository) SalesService {
+func NewSalesService(salesRepo repository.SalesRepository, notificationService NotificationService, cfg config.NotificationConfig) SalesService {
 	return &salesService{
-		salesRepo: salesRepo,
+		salesRepo:           salesRepo,
+		notificationService: notificationService,
+		config:              cfg,
 	}
 }
 
@@ -49,11 +55,35 @@ func (s *salesService) CreateSale(sale *entities.SalesInvoice) error {
 	}
 	sale.Total = total - sale.Discount
 
-	return s.salesRepo.Create(sale)
+	// Issue the goods from stock and cost them in the same transaction
+	if err := s.salesRepo.CreateWithStock(sale); err != nil {
+		return err
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(sale.SalesDetails))
+	for _, detail := range sale.SalesDetails {
+		productIDs = append(productIDs, detail.ProductID)
+	}
+	raiseLowStock(s.notificationService, sale.ShopID, productIDs)
+
+	if total > 0 && s.config.LargeDiscountPercent > 0 {
+		if percent := sale.Discount / total * 100; percent >= s.config.LargeDiscountPercent {
+			raiseAlert(s.notificationService, entities.Alert{
+				Type:       entities.NotificationTypeLargeDiscount,
+				Title:      "Large discount on a sale",
+				Message:    fmt.Sprintf("A sale of %.2f was discounted by %.2f (%.1f%%).", total, sale.Discount, percent),
+				ShopID:     &sale.ShopID,
+				DocumentID: &sale.ID,
+			})
+		}
+	}
+
+	return nil
 }
 
 func (s *salesService) DeleteSale(id uuid.UUID) error {
-	return s.salesRepo.Delete(id)
+	// Puts the goods back into stock at their original cost
+	return s.salesRepo.DeleteWithStock(id)
 }
 
 func (s *salesService) ExportToExcel(filters map[string]interface{}, sorts []string) (*excelize.File, error) {
@@ -105,3 +135,7 @@ func (s *salesService) GetAnalytics(shopID *uuid.UUID) (*repository.SalesAnalyti
 func (s *salesService) GetLast7DaysSales(shopID *uuid.UUID) ([]repository.DailySales, error) {
 	return s.salesRepo.GetLast7DaysSales(shopID)
 }
+
+func (s *salesService) GetGrossMargins(groupBy string, filters map[string]interface{}) ([]entities.GrossMargin, error) {
+	return s.salesRepo.GetGrossMargins(groupBy, filters)
+}
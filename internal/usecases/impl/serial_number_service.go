@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type SerialNumberService interface {
+	GetSerialNumbers(page, pageSize int, filters map[string]interface{}) ([]entities.SerialNumber, int64, error)
+	GetSerialNumberByID(id uuid.UUID) (*entities.SerialLookup, error)
+	LookupSerialNumber(serialNumber string) ([]entities.SerialLookup, error)
+	RegisterSerialNumbers(productID, shopID uuid.UUID, serialNumbers []string, userID uuid.UUID) ([]entities.SerialNumber, error)
+	SetSerialTracking(productID uuid.UUID, enabled bool, warrantyMonths *int) error
+}
+
+type serialNumberService struct {
+	serialNumberRepo repository.SerialNumberRepository
+}
+
+func NewSerialNumberService(serialNumberRepo repository.SerialNumberRepository) SerialNumberService {
+	return &serialNumberService{
+		serialNumberRepo: serialNumberRepo,
+	}
+}
+
+func (s *serialNumberService) GetSerialNumbers(page, pageSize int, filters map[string]interface{}) ([]entities.SerialNumber, int64, error) {
+	return s.serialNumberRepo.GetSerialsWithFilters(filters, page, pageSize)
+}
+
+func (s *serialNumberService) GetSerialNumberByID(id uuid.UUID) (*entities.SerialLookup, error) {
+	serial, err := s.serialNumberRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.SerialLookup{SerialNumber: *serial, UnderWarranty: serial.UnderWarranty(time.Now())}, nil
+}
+
+// LookupSerialNumber finds every unit carrying a serial number, with its
+// history and whether its warranty still runs
+func (s *serialNumberService) LookupSerialNumber(serialNumber string) ([]entities.SerialLookup, error) {
+	serials, err := s.serialNumberRepo.Lookup(serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lookups := make([]entities.SerialLookup, 0, len(serials))
+	for _, serial := range serials {
+		lookups = append(lookups, entities.SerialLookup{SerialNumber: serial, UnderWarranty: serial.UnderWarranty(now)})
+	}
+	return lookups, nil
+}
+
+func (s *serialNumberService) RegisterSerialNumbers(productID, shopID uuid.UUID, serialNumbers []string, userID uuid.UUID) ([]entities.SerialNumber, error) {
+	return s.serialNumberRepo.Register(productID, shopID, serialNumbers, userID)
+}
+
+func (s *serialNumberService) SetSerialTracking(productID uuid.UUID, enabled bool, warrantyMonths *int) error {
+	return s.serialNumberRepo.SetSerialTracking(productID, enabled, warrantyMonths)
+}
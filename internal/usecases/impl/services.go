@@ -2,12 +2,31 @@ package usecases
 
 // Services groups all service instances
 type Services struct {
-	Auth     AuthService
-	User     UserService
-	Product  ProductService
-	Sales    SalesService
-	Purchase PurchaseService
-	Supplier SupplierService
-	Company  CompanyService
-	Shop     ShopService
+	Auth              AuthService
+	User              UserService
+	Product           ProductService
+	Sales             SalesService
+	Purchase          PurchaseService
+	PurchaseOrder     PurchaseOrderService
+	LandedCost        LandedCostService
+	PurchaseImport    PurchaseImportService
+	Supplier          SupplierService
+	SupplierProduct   SupplierProductService
+	SupplierReturn    SupplierReturnService
+	SupplierPayment   SupplierPaymentService
+	Currency          CurrencyService
+	SupplierScorecard SupplierScorecardService
+	Replenishment     ReplenishmentService
+	Company           CompanyService
+	Shop              ShopService
+	Inventory         InventoryService
+	StockAdjustment   StockAdjustmentService
+	StockCount        StockCountService
+	StockReservation  StockReservationService
+	StockLot          StockLotService
+	SerialNumber      SerialNumberService
+	ProductStyle      ProductStyleService
+	Notification      NotificationService
+	StockTransfer     StockTransferService
+	Warehouse         WarehouseService
 }
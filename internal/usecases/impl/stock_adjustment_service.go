@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptyAdjustment        = errors.New("stock adjustment must have at least one line with a quantity")
+	ErrAdjustmentNotPermitted = errors.New("only managers and admins can approve or reject stock adjustments")
+)
+
+type StockAdjustmentService interface {
+	GetStockAdjustments(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockAdjustment, int64, error)
+	GetStockAdjustmentByID(id uuid.UUID) (*entities.StockAdjustment, error)
+	CreateStockAdjustment(adjustment *entities.StockAdjustment, role string) error
+	ApproveStockAdjustment(id, approverID uuid.UUID, role string) (*entities.StockAdjustment, error)
+	RejectStockAdjustment(id, approverID uuid.UUID, role, reason string) (*entities.StockAdjustment, error)
+	GetShrinkageReport(filters map[string]interface{}) (*entities.ShrinkageReport, error)
+}
+
+type stockAdjustmentService struct {
+	stockAdjustmentRepo repository.StockAdjustmentRepository
+	notificationService NotificationService
+	config              config.InventoryConfig
+}
+
+func NewStockAdjustmentService(stockAdjustmentRepo repository.StockAdjustmentRepository, notificationService NotificationService, cfg config.InventoryConfig) StockAdjustmentService {
+	return &stockAdjustmentService{
+		stockAdjustmentRepo: stockAdjustmentRepo,
+		notificationService: notificationService,
+		config:              cfg,
+	}
+}
+
+func (s *stockAdjustmentService) GetStockAdjustments(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockAdjustment, int64, error) {
+	return s.stockAdjustmentRepo.GetStockAdjustmentsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *stockAdjustmentService) GetStockAdjustmentByID(id uuid.UUID) (*entities.StockAdjustment, error) {
+	return s.stockAdjustmentRepo.GetByID(id)
+}
+
+// CreateStockAdjustment applies the adjustment straight away when a manager
+// raises it or it is worth no more than the approval threshold. Anything else
+// waits for a manager to approve it.
+func (s *stockAdjustmentService) CreateStockAdjustment(adjustment *entities.StockAdjustment, role string) error {
+	if len(adjustment.Lines) == 0 {
+		return ErrEmptyAdjustment
+	}
+	for _, line := range adjustment.Lines {
+		if line.Quantity == 0 {
+			return ErrEmptyAdjustment
+		}
+	}
+
+	value, err := s.stockAdjustmentRepo.EstimateValue(adjustment.ShopID, adjustment.Lines)
+	if err != nil {
+		return err
+	}
+
+	// Managers approve their own adjustments. Staff adjustments under the
+	// approval value go through without an approver, so the audit trail
+	// doesn't show staff approving their own work.
+	adjustment.Status = entities.StockAdjustmentStatusPending
+	if canApproveAdjustments(role) || value <= s.config.AdjustmentApprovalValue {
+		adjustment.Status = entities.StockAdjustmentStatusApproved
+		now := adjustment.AdjustmentDateTime
+		adjustment.ApprovedAt = &now
+		if canApproveAdjustments(role) {
+			approverID := adjustment.CreatedByID
+			adjustment.ApprovedByID = &approverID
+		}
+	}
+
+	if err := s.stockAdjustmentRepo.CreateWithStock(adjustment); err != nil {
+		return err
+	}
+
+	if adjustment.Status == entities.StockAdjustmentStatusPending {
+		raiseAlert(s.notificationService, entities.Alert{
+			Type:       entities.NotificationTypeAwaitingApproval,
+			Title:      "Stock adjustment awaiting approval",
+			Message:    fmt.Sprintf("A stock adjustment of %d lines worth %.2f needs a manager's approval.", len(adjustment.Lines), value),
+			ShopID:     &adjustment.ShopID,
+			DocumentID: &adjustment.ID,
+		})
+		return nil
+	}
+	raiseLowStock(s.notificationService, adjustment.ShopID, removedProducts(adjustment))
+	return nil
+}
+
+func (s *stockAdjustmentService) ApproveStockAdjustment(id, approverID uuid.UUID, role string) (*entities.StockAdjustment, error) {
+	if !canApproveAdjustments(role) {
+		return nil, ErrAdjustmentNotPermitted
+	}
+	adjustment, err := s.stockAdjustmentRepo.Approve(id, approverID)
+	if err != nil {
+		return nil, err
+	}
+
+	raiseLowStock(s.notificationService, adjustment.ShopID, removedProducts(adjustment))
+	return adjustment, nil
+}
+
+func (s *stockAdjustmentService) RejectStockAdjustment(id, approverID uuid.UUID, role, reason string) (*entities.StockAdjustment, error) {
+	if !canApproveAdjustments(role) {
+		return nil, ErrAdjustmentNotPermitted
+	}
+	return s.stockAdjustmentRepo.Reject(id, approverID, reason)
+}
+
+// GetShrinkageReport totals approved adjustments by shop and reason
+func (s *stockAdjustmentService) GetShrinkageReport(filters map[string]interface{}) (*entities.ShrinkageReport, error) {
+	rows, err := s.stockAdjustmentRepo.GetShrinkage(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.ShrinkageReport{Rows: rows}
+	for _, row := range rows {
+		report.TotalLost += row.ValueLost
+		report.TotalFound += row.ValueFound
+		report.TotalNetValue += row.NetValue
+	}
+	return report, nil
+}
+
+func canApproveAdjustments(role string) bool {
+	return role == string(entities.RoleAdmin) || role == string(entities.RoleManager)
+}
+
+// removedProducts are the products an adjustment takes stock of
+func removedProducts(adjustment *entities.StockAdjustment) []uuid.UUID {
+	var productIDs []uuid.UUID
+	for _, line := range adjustment.Lines {
+		if line.Quantity < 0 {
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	return productIDs
+}
@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCountCategoryRequired = errors.New("a category count needs a category")
+	ErrCountNotPermitted     = errors.New("only managers and admins can approve stock counts")
+	ErrUnknownProductCode    = errors.New("unknown product code")
+)
+
+// StockCountEntry is a counted quantity for a product given by ID or, from a
+// scanner, by code
+type StockCountEntry struct {
+	ProductID *uuid.UUID
+	Code      string
+	Quantity  int
+}
+
+type StockCountService interface {
+	GetStockCounts(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockCount, int64, error)
+	GetStockCountByID(id uuid.UUID) (*entities.StockCount, error)
+	StartStockCount(count *entities.StockCount) error
+	AddEntries(countID, countedByID uuid.UUID, device string, entries []StockCountEntry) error
+	GetVariance(id uuid.UUID) (*entities.StockCountVariance, error)
+	ApproveStockCount(id, approverID uuid.UUID, role string, zeroUncounted bool) (*entities.StockCount, error)
+	CancelStockCount(id uuid.UUID) error
+}
+
+type stockCountService struct {
+	stockCountRepo repository.StockCountRepository
+	productRepo    repository.ProductRepository
+}
+
+func NewStockCountService(stockCountRepo repository.StockCountRepository, productRepo repository.ProductRepository) StockCountService {
+	return &stockCountService{
+		stockCountRepo: stockCountRepo,
+		productRepo:    productRepo,
+	}
+}
+
+func (s *stockCountService) GetStockCounts(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockCount, int64, error) {
+	return s.stockCountRepo.GetStockCountsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *stockCountService) GetStockCountByID(id uuid.UUID) (*entities.StockCount, error) {
+	return s.stockCountRepo.GetByID(id)
+}
+
+func (s *stockCountService) StartStockCount(count *entities.StockCount) error {
+	if count.Scope == entities.StockCountScopeCategory && count.Category == "" {
+		return ErrCountCategoryRequired
+	}
+	if count.Scope != entities.StockCountScopeCategory {
+		count.Category = ""
+		count.SubCategory = ""
+	}
+	return s.stockCountRepo.Start(count)
+}
+
+// AddEntries resolves scanned codes to products and records the tallies
+func (s *stockCountService) AddEntries(countID, countedByID uuid.UUID, device string, entries []StockCountEntry) error {
+	var codes []string
+	for _, entry := range entries {
+		if entry.ProductID == nil {
+			codes = append(codes, entry.Code)
+		}
+	}
+	productIDs := make(map[string]uuid.UUID, len(codes))
+	if len(codes) > 0 {
+		products, err := s.productRepo.GetByCodes(codes)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			productIDs[product.Code] = product.ID
+		}
+	}
+
+	inputs := make([]repository.StockCountEntryInput, 0, len(entries))
+	for _, entry := range entries {
+		input := repository.StockCountEntryInput{Quantity: entry.Quantity}
+		if entry.ProductID != nil {
+			input.ProductID = *entry.ProductID
+		} else {
+			productID, ok := productIDs[entry.Code]
+			if !ok {
+				return fmt.Errorf("%w: no product with code %q", ErrUnknownProductCode, entry.Code)
+			}
+			input.ProductID = productID
+		}
+		inputs = append(inputs, input)
+	}
+
+	return s.stockCountRepo.AddEntries(countID, countedByID, device, inputs)
+}
+
+func (s *stockCountService) GetVariance(id uuid.UUID) (*entities.StockCountVariance, error) {
+	return s.stockCountRepo.GetVariance(id)
+}
+
+// ApproveStockCount posts the count's differences to inventory as a count
+// correction adjustment
+func (s *stockCountService) ApproveStockCount(id, approverID uuid.UUID, role string, zeroUncounted bool) (*entities.StockCount, error) {
+	if !canApproveAdjustments(role) {
+		return nil, ErrCountNotPermitted
+	}
+	return s.stockCountRepo.Approve(id, approverID, zeroUncounted)
+}
+
+func (s *stockCountService) CancelStockCount(id uuid.UUID) error {
+	return s.stockCountRepo.Cancel(id)
+}
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type StockLotService interface {
+	GetLots(page, pageSize int, filters map[string]interface{}) ([]entities.StockLot, int64, error)
+	GetLotByID(id uuid.UUID) (*entities.StockLot, error)
+	GetLotTrace(id uuid.UUID) (*entities.LotTrace, error)
+	SetLotTracking(productID uuid.UUID, enabled bool) error
+}
+
+type stockLotService struct {
+	stockLotRepo repository.StockLotRepository
+}
+
+func NewStockLotService(stockLotRepo repository.StockLotRepository) StockLotService {
+	return &stockLotService{
+		stockLotRepo: stockLotRepo,
+	}
+}
+
+func (s *stockLotService) GetLots(page, pageSize int, filters map[string]interface{}) ([]entities.StockLot, int64, error) {
+	return s.stockLotRepo.GetLotsWithFilters(filters, page, pageSize)
+}
+
+func (s *stockLotService) GetLotByID(id uuid.UUID) (*entities.StockLot, error) {
+	return s.stockLotRepo.GetByID(id)
+}
+
+func (s *stockLotService) GetLotTrace(id uuid.UUID) (*entities.LotTrace, error) {
+	return s.stockLotRepo.GetLotTrace(id)
+}
+
+func (s *stockLotService) SetLotTracking(productID uuid.UUID, enabled bool) error {
+	return s.stockLotRepo.SetLotTracking(productID, enabled)
+}
@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"errors"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/config"
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var ErrReservationExpiryPassed = errors.New("reservation must expire in the future")
+
+type StockReservationService interface {
+	GetStockReservations(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockReservation, int64, error)
+	GetStockReservationByID(id uuid.UUID) (*entities.StockReservation, error)
+	CreateStockReservation(reservation *entities.StockReservation) error
+	ReleaseStockReservation(id uuid.UUID) (*entities.StockReservation, error)
+	ExpireStockReservations() (int, error)
+}
+
+type stockReservationService struct {
+	stockReservationRepo repository.StockReservationRepository
+	config               config.InventoryConfig
+}
+
+func NewStockReservationService(stockReservationRepo repository.StockReservationRepository, cfg config.InventoryConfig) StockReservationService {
+	return &stockReservationService{
+		stockReservationRepo: stockReservationRepo,
+		config:               cfg,
+	}
+}
+
+func (s *stockReservationService) GetStockReservations(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.StockReservation, int64, error) {
+	return s.stockReservationRepo.GetStockReservationsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *stockReservationService) GetStockReservationByID(id uuid.UUID) (*entities.StockReservation, error) {
+	return s.stockReservationRepo.GetByID(id)
+}
+
+// CreateStockReservation holds stock for a draft, layaway, online order or
+// transfer. Reservations that don't say when they expire hold the stock for
+// the configured expiry period.
+func (s *stockReservationService) CreateStockReservation(reservation *entities.StockReservation) error {
+	now := time.Now()
+	if reservation.ExpiresAt == nil && s.config.ReservationExpiry > 0 {
+		expiresAt := now.Add(s.config.ReservationExpiry)
+		reservation.ExpiresAt = &expiresAt
+	}
+	if reservation.ExpiresAt != nil && !reservation.ExpiresAt.After(now) {
+		return ErrReservationExpiryPassed
+	}
+
+	return s.stockReservationRepo.Reserve(reservation)
+}
+
+func (s *stockReservationService) ReleaseStockReservation(id uuid.UUID) (*entities.StockReservation, error) {
+	return s.stockReservationRepo.Release(id)
+}
+
+// ExpireStockReservations gives back the stock of every reservation that ran
+// out. Sales already ignore such reservations; this keeps reserved
+// quantities in stock lists current between sales.
+func (s *stockReservationService) ExpireStockReservations() (int, error) {
+	return s.stockReservationRepo.ExpireDue(time.Now())
+}
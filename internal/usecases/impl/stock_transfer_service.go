@@ -3,6 +3,7 @@ package usecases
 import (
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+	"Sheikh-Enterprise-Backend/pkg/logger"
 	"errors"
 	"time"
 
@@ -10,7 +11,7 @@ import (
 )
 
 var (
-	ErrInsufficientStock = errors.New("insufficient stock in source shop")
+	ErrInsufficientStock = repository.ErrInsufficientStock
 	ErrSameShopTransfer  = errors.New("cannot transfer stock to the same shop")
 )
 
@@ -24,14 +25,18 @@ type StockTransferService interface {
 }
 
 type stockTransferService struct {
-	stockTransferRepo repository.StockTransferRepository
-	inventoryRepo     repository.InventoryRepository
+	stockTransferRepo   repository.StockTransferRepository
+	inventoryRepo       repository.InventoryRepository
+	warehouseRepo       repository.WarehouseRepository
+	notificationService NotificationService
 }
 
-func NewStockTransferService(stockTransferRepo repository.StockTransferRepository, inventoryRepo repository.InventoryRepository) StockTransferService {
+func NewStockTransferService(stockTransferRepo repository.StockTransferRepository, inventoryRepo repository.InventoryRepository, warehouseRepo repository.WarehouseRepository, notificationService NotificationService) StockTransferService {
 	return &stockTransferService{
-		stockTransferRepo: stockTransferRepo,
-		inventoryRepo:     inventoryRepo,
+		stockTransferRepo:   stockTransferRepo,
+		inventoryRepo:       inventoryRepo,
+		warehouseRepo:       warehouseRepo,
+		notificationService: notificationService,
 	}
 }
 
@@ -49,79 +54,59 @@ func (s *stockTransferService) GetStockTransfersByShopID(shopID uuid.UUID) ([]en
 }
 
 func (s *stockTransferService) CreateStockTransfer(transfer *entities.StockTransfer) error {
+	// Without a source the stock comes from the central warehouse of the
+	// destination's company
+	if transfer.FromShopID == nil {
+		warehouseID, err := s.warehouseRepo.GetCentralWarehouseFor(transfer.ToShopID)
+		if err != nil {
+			return err
+		}
+		transfer.FromShopID = &warehouseID
+	}
+
 	// Check if source and destination shops are different
 	if transfer.FromShopID == nil || *transfer.FromShopID == transfer.ToShopID {
 		return ErrSameShopTransfer
 	}
 
-	// Check if source shop has sufficient stock
-	sourceInventory, err := s.inventoryRepo.GetByProductAndShop(transfer.ProductID, *transfer.FromShopID)
-	if err != nil {
-		return err
-	}
-
-	if sourceInventory.Quantity < transfer.Quantity {
-		return ErrInsufficientStock
-	}
-
-	// Create the transfer record
-	if err := s.stockTransferRepo.Create(transfer); err != nil {
-		return err
-	}
-
-	// Update source shop inventory
-	sourceInventory.Quantity -= transfer.Quantity
-	if err := s.inventoryRepo.UpdateStock(transfer.ProductID, *transfer.FromShopID, -transfer.Quantity); err != nil {
-		return err
-	}
-
-	// Update destination shop inventory
-	if err := s.inventoryRepo.UpdateStock(transfer.ProductID, transfer.ToShopID, transfer.Quantity); err != nil {
+	// The transfer is saved and its stock moved together. A source shop
+	// without enough stock fails with an InsufficientStockError.
+	if err := s.stockTransferRepo.CreateWithStock(transfer); err != nil {
 		return err
 	}
 
+	raiseLowStock(s.notificationService, *transfer.FromShopID, []uuid.UUID{transfer.ProductID})
 	return nil
 }
 
 func (s *stockTransferService) UpdateStockTransfer(transfer *entities.StockTransfer) error {
-	// Get existing transfer to compare changes
-	existing, err := s.stockTransferRepo.GetByID(transfer.ID)
-	if err != nil {
+	// If quantity changed, only the difference is moved
+	if err := s.stockTransferRepo.UpdateWithStock(transfer); err != nil {
 		return err
 	}
 
-	// If quantity changed, update inventories
-	if existing.Quantity != transfer.Quantity {
-		// Update source shop inventory
-		if err := s.inventoryRepo.UpdateStock(transfer.ProductID, *transfer.FromShopID, existing.Quantity-transfer.Quantity); err != nil {
-			return err
-		}
-
-		// Update destination shop inventory
-		if err := s.inventoryRepo.UpdateStock(transfer.ProductID, transfer.ToShopID, transfer.Quantity-existing.Quantity); err != nil {
-			return err
-		}
-	}
-
-	return s.stockTransferRepo.Create(transfer) // Use Create for update as it handles both insert and update
+	// A larger quantity takes stock from the source, a smaller one from the
+	// destination
+	products := []uuid.UUID{transfer.ProductID}
+	raiseLowStock(s.notificationService, *transfer.FromShopID, products)
+	raiseLowStock(s.notificationService, transfer.ToShopID, products)
+	return nil
 }
 
 func (s *stockTransferService) DeleteStockTransfer(id uuid.UUID) error {
-	// Get transfer to update inventories
-	transfer, err := s.stockTransferRepo.GetByID(id)
-	if err != nil {
+	// The stock goes back to the source shop and the transfer is kept as cancelled
+	if err := s.stockTransferRepo.CancelWithStock(id); err != nil {
 		return err
 	}
 
-	// Update source shop inventory
-	if err := s.inventoryRepo.UpdateStock(transfer.ProductID, *transfer.FromShopID, transfer.Quantity); err != nil {
-		return err
+	transfer, err := s.stockTransferRepo.GetByID(id)
+	if err != nil {
+		logger.Error("Failed to load cancelled stock transfer: " + err.Error())
+		return nil
 	}
-
-	// Update destination shop inventory
-	if err := s.inventoryRepo.UpdateStock(transfer.ProductID, transfer.ToShopID, -transfer.Quantity); err != nil {
-		return err
+	if transfer == nil {
+		return nil
 	}
-
-	return s.stockTransferRepo.Create(transfer) // Use Create for soft delete
+	raiseLowStock(s.notificationService, transfer.ToShopID, []uuid.UUID{transfer.ProductID})
+	return nil
 }
@@ -0,0 +1,183 @@
+package usecases
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotSupplierPayment = errors.New("payment is not a supplier payment")
+
+type SupplierPaymentService interface {
+	GetSupplierPayments(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.Payment, int64, error)
+	GetSupplierPaymentByID(id uuid.UUID) (*entities.Payment, error)
+	RecordSupplierPayment(payment *entities.Payment) error
+	AllocatePayment(paymentID uuid.UUID, allocations []entities.PaymentAllocation) (*entities.Payment, error)
+	GetOutstandingInvoices(supplierID *uuid.UUID) ([]entities.OutstandingInvoice, error)
+	GetPayablesAging(supplierID *uuid.UUID) ([]entities.SupplierAging, error)
+	GetFxGainLoss(filters map[string]interface{}) (*entities.FxGainLossReport, error)
+}
+
+type supplierPaymentService struct {
+	paymentRepo  repository.PaymentRepository
+	supplierRepo repository.SupplierRepository
+	currencyRepo repository.CurrencyRepository
+}
+
+func NewSupplierPaymentService(paymentRepo repository.PaymentRepository, supplierRepo repository.SupplierRepository, currencyRepo repository.CurrencyRepository) SupplierPaymentService {
+	return &supplierPaymentService{
+		paymentRepo:  paymentRepo,
+		supplierRepo: supplierRepo,
+		currencyRepo: currencyRepo,
+	}
+}
+
+func (s *supplierPaymentService) GetSupplierPayments(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.Payment, int64, error) {
+	filters["type"] = entities.PaymentEntityTypeSupplier
+	return s.paymentRepo.GetPaymentsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *supplierPaymentService) GetSupplierPaymentByID(id uuid.UUID) (*entities.Payment, error) {
+	payment, err := s.paymentRepo.GetWithAllocations(id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Type != entities.PaymentEntityTypeSupplier {
+		return nil, ErrNotSupplierPayment
+	}
+	return payment, nil
+}
+
+func (s *supplierPaymentService) RecordSupplierPayment(payment *entities.Payment) error {
+	if _, err := s.supplierRepo.GetByID(*payment.SupplierID); err != nil {
+		return err
+	}
+
+	payment.Type = entities.PaymentEntityTypeSupplier
+	payment.CustomerID = nil
+	if payment.PaymentDateTime.IsZero() {
+		payment.PaymentDateTime = time.Now()
+	}
+
+	// The payment is made in CurrencyAmount and booked in taka
+	code, rate, err := exchangeRateFor(s.currencyRepo, payment.CurrencyCode, payment.ExchangeRate, payment.PaymentDateTime)
+	if err != nil {
+		return err
+	}
+	payment.CurrencyCode = code
+	payment.ExchangeRate = rate
+	payment.Amount = math.Round(payment.CurrencyAmount*rate*100) / 100
+
+	return s.paymentRepo.CreateWithAllocations(payment)
+}
+
+func (s *supplierPaymentService) AllocatePayment(paymentID uuid.UUID, allocations []entities.PaymentAllocation) (*entities.Payment, error) {
+	if _, err := s.GetSupplierPaymentByID(paymentID); err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentRepo.AddAllocations(paymentID, allocations); err != nil {
+		return nil, err
+	}
+
+	return s.paymentRepo.GetWithAllocations(paymentID)
+}
+
+func (s *supplierPaymentService) GetOutstandingInvoices(supplierID *uuid.UUID) ([]entities.OutstandingInvoice, error) {
+	invoices, err := s.paymentRepo.GetOutstandingInvoices(supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range invoices {
+		invoices[i].AgeDays = int(now.Sub(invoices[i].PurchaseDateTime).Hours() / 24)
+	}
+	return invoices, nil
+}
+
+// GetPayablesAging buckets each supplier's outstanding invoices by age. Money
+// paid but not yet allocated is shown separately and taken off the total.
+func (s *supplierPaymentService) GetPayablesAging(supplierID *uuid.UUID) ([]entities.SupplierAging, error) {
+	invoices, err := s.GetOutstandingInvoices(supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	unallocated, err := s.paymentRepo.GetUnallocatedAmounts(supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	agingBySupplier := make(map[uuid.UUID]*entities.SupplierAging)
+	var order []uuid.UUID
+	agingFor := func(id uuid.UUID) *entities.SupplierAging {
+		aging, ok := agingBySupplier[id]
+		if !ok {
+			aging = &entities.SupplierAging{SupplierID: id}
+			agingBySupplier[id] = aging
+			order = append(order, id)
+		}
+		return aging
+	}
+
+	for _, invoice := range invoices {
+		aging := agingFor(invoice.SupplierID)
+		switch {
+		case invoice.AgeDays <= 30:
+			aging.Current += invoice.Outstanding
+		case invoice.AgeDays <= 60:
+			aging.Days31To60 += invoice.Outstanding
+		case invoice.AgeDays <= 90:
+			aging.Days61To90 += invoice.Outstanding
+		default:
+			aging.Over90 += invoice.Outstanding
+		}
+	}
+	for id, amount := range unallocated {
+		if amount > 0.005 {
+			agingFor(id).Unallocated = amount
+		}
+	}
+
+	result := make([]entities.SupplierAging, 0, len(order))
+	for _, id := range order {
+		aging := agingBySupplier[id]
+		aging.Outstanding = aging.Current + aging.Days31To60 + aging.Days61To90 + aging.Over90 - aging.Unallocated
+		if supplier, err := s.supplierRepo.GetByID(id); err == nil {
+			aging.SupplierName = supplier.Name
+		}
+		result = append(result, *aging)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SupplierName < result[j].SupplierName
+	})
+	return result, nil
+}
+
+// GetFxGainLoss reports the exchange gains and losses realised by settling
+// foreign currency invoices
+func (s *supplierPaymentService) GetFxGainLoss(filters map[string]interface{}) (*entities.FxGainLossReport, error) {
+	settlements, err := s.paymentRepo.GetFxSettlements(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.FxGainLossReport{Settlements: settlements}
+	for _, settlement := range settlements {
+		if settlement.GainLoss > 0 {
+			report.TotalGain += settlement.GainLoss
+		} else {
+			report.TotalLoss -= settlement.GainLoss
+		}
+	}
+	report.Net = report.TotalGain - report.TotalLoss
+	return report, nil
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"errors"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSupplierProductExists  = errors.New("supplier already has a catalog entry for this product")
+	ErrSupplierProductChanged = errors.New("supplier and product of a catalog entry cannot be changed")
+)
+
+type SupplierProductService interface {
+	GetSupplierProducts(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.SupplierProduct, int64, error)
+	GetSupplierProductByID(id uuid.UUID) (*entities.SupplierProduct, error)
+	CreateSupplierProduct(link *entities.SupplierProduct) error
+	UpdateSupplierProduct(link *entities.SupplierProduct) error
+	DeleteSupplierProduct(id uuid.UUID) error
+}
+
+type supplierProductService struct {
+	supplierProductRepo repository.SupplierProductRepository
+}
+
+func NewSupplierProductService(supplierProductRepo repository.SupplierProductRepository) SupplierProductService {
+	return &supplierProductService{
+		supplierProductRepo: supplierProductRepo,
+	}
+}
+
+func (s *supplierProductService) GetSupplierProducts(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.SupplierProduct, int64, error) {
+	return s.supplierProductRepo.GetSupplierProductsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *supplierProductService) GetSupplierProductByID(id uuid.UUID) (*entities.SupplierProduct, error) {
+	return s.supplierProductRepo.GetByID(id)
+}
+
+func (s *supplierProductService) CreateSupplierProduct(link *entities.SupplierProduct) error {
+	existing, err := s.supplierProductRepo.GetBySupplierAndProduct(link.SupplierID, link.ProductID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// A deleted entry is brought back rather than duplicated
+	if existing != nil {
+		if !existing.IsMarkedToDelete {
+			return ErrSupplierProductExists
+		}
+		link.ID = existing.ID
+		link.CreatedAt = existing.CreatedAt
+	}
+	if link.PackSize == 0 {
+		link.PackSize = 1
+	}
+	link.IsMarkedToDelete = false
+
+	return s.supplierProductRepo.Save(link)
+}
+
+func (s *supplierProductService) UpdateSupplierProduct(link *entities.SupplierProduct) error {
+	existing, err := s.supplierProductRepo.GetByID(link.ID)
+	if err != nil {
+		return err
+	}
+	if existing.SupplierID != link.SupplierID || existing.ProductID != link.ProductID {
+		return ErrSupplierProductChanged
+	}
+
+	link.CreatedAt = existing.CreatedAt
+	if link.PackSize == 0 {
+		link.PackSize = 1
+	}
+
+	return s.supplierProductRepo.Save(link)
+}
+
+func (s *supplierProductService) DeleteSupplierProduct(id uuid.UUID) error {
+	if _, err := s.supplierProductRepo.GetByID(id); err != nil {
+		return err
+	}
+	return s.supplierProductRepo.Delete(id)
+}
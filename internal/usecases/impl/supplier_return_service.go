@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type SupplierReturnService interface {
+	GetSupplierReturns(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.SupplierReturn, int64, error)
+	GetSupplierReturnByID(id uuid.UUID) (*entities.SupplierReturn, error)
+	CreateSupplierReturn(supplierReturn *entities.SupplierReturn) error
+}
+
+type supplierReturnService struct {
+	supplierReturnRepo  repository.SupplierReturnRepository
+	notificationService NotificationService
+}
+
+func NewSupplierReturnService(supplierReturnRepo repository.SupplierReturnRepository, notificationService NotificationService) SupplierReturnService {
+	return &supplierReturnService{
+		supplierReturnRepo:  supplierReturnRepo,
+		notificationService: notificationService,
+	}
+}
+
+func (s *supplierReturnService) GetSupplierReturns(page, pageSize int, filters map[string]interface{}, sorts []string) ([]entities.SupplierReturn, int64, error) {
+	return s.supplierReturnRepo.GetSupplierReturnsWithFilters(filters, sorts, page, pageSize)
+}
+
+func (s *supplierReturnService) GetSupplierReturnByID(id uuid.UUID) (*entities.SupplierReturn, error) {
+	return s.supplierReturnRepo.GetByID(id)
+}
+
+func (s *supplierReturnService) CreateSupplierReturn(supplierReturn *entities.SupplierReturn) error {
+	// Lines are priced from the original purchase and the debit note
+	// amount is their sum
+	if err := s.supplierReturnRepo.CreateWithStock(supplierReturn); err != nil {
+		return err
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(supplierReturn.Lines))
+	for _, line := range supplierReturn.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	raiseLowStock(s.notificationService, supplierReturn.ShopID, productIDs)
+	return nil
+}
@@ -0,0 +1,382 @@
+package usecases
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// Weights of each measure in a supplier's score. A measure with nothing to
+// go on in the period is left out and the others are weighted up.
+const (
+	scoreWeightOnTime = 0.3
+	scoreWeightFill   = 0.3
+	scoreWeightReturn = 0.2
+	scoreWeightPrice  = 0.2
+)
+
+type SupplierScorecardService interface {
+	GetScorecards(from, to time.Time, supplierID *uuid.UUID, category string) (*entities.SupplierScorecardReport, error)
+	GetCategoryRankings(from, to time.Time, category string) ([]entities.SupplierCategoryRanking, error)
+	ExportScorecards(from, to time.Time, supplierID *uuid.UUID, category string) (*excelize.File, error)
+}
+
+type supplierScorecardService struct {
+	scorecardRepo repository.SupplierScorecardRepository
+	supplierRepo  repository.SupplierRepository
+}
+
+func NewSupplierScorecardService(scorecardRepo repository.SupplierScorecardRepository, supplierRepo repository.SupplierRepository) SupplierScorecardService {
+	return &supplierScorecardService{
+		scorecardRepo: scorecardRepo,
+		supplierRepo:  supplierRepo,
+	}
+}
+
+// GetScorecards rates every supplier active between from and to, or just
+// one supplier, optionally on one product category only. Price variance is
+// always measured against every supplier of the same products.
+func (s *supplierScorecardService) GetScorecards(from, to time.Time, supplierID *uuid.UUID, category string) (*entities.SupplierScorecardReport, error) {
+	facts, err := s.scorecardRepo.GetScorecardFacts(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	scorecards := buildScorecards(facts, category)
+	if supplierID != nil {
+		filtered := scorecards[:0]
+		for _, card := range scorecards {
+			if card.SupplierID == *supplierID {
+				filtered = append(filtered, card)
+			}
+		}
+		scorecards = filtered
+	}
+	s.nameSuppliers(scorecards)
+
+	sort.Slice(scorecards, func(i, j int) bool {
+		return scorecards[i].SupplierName < scorecards[j].SupplierName
+	})
+
+	return &entities.SupplierScorecardReport{
+		DateFrom:   from,
+		DateTo:     to.AddDate(0, 0, -1),
+		Category:   category,
+		Scorecards: scorecards,
+	}, nil
+}
+
+// GetCategoryRankings ranks suppliers within each product category, best
+// score first. Suppliers with nothing to score in a category are left out
+// of its ranking.
+func (s *supplierScorecardService) GetCategoryRankings(from, to time.Time, category string) ([]entities.SupplierCategoryRanking, error) {
+	facts, err := s.scorecardRepo.GetScorecardFacts(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []string{category}
+	if category == "" {
+		categories = scorecardCategories(facts)
+	}
+
+	rankings := make([]entities.SupplierCategoryRanking, 0, len(categories))
+	for _, c := range categories {
+		var ranked []entities.SupplierScorecard
+		for _, card := range buildScorecards(facts, c) {
+			if card.Score != nil {
+				ranked = append(ranked, card)
+			}
+		}
+		if len(ranked) == 0 {
+			continue
+		}
+		s.nameSuppliers(ranked)
+
+		sort.SliceStable(ranked, func(i, j int) bool {
+			if *ranked[i].Score != *ranked[j].Score {
+				return *ranked[i].Score > *ranked[j].Score
+			}
+			return ranked[i].SupplierName < ranked[j].SupplierName
+		})
+		for i := range ranked {
+			ranked[i].Rank = i + 1
+		}
+
+		rankings = append(rankings, entities.SupplierCategoryRanking{
+			Category:  c,
+			Suppliers: ranked,
+		})
+	}
+
+	return rankings, nil
+}
+
+// ExportScorecards writes the scorecards to an Excel report
+func (s *supplierScorecardService) ExportScorecards(from, to time.Time, supplierID *uuid.UUID, category string) (*excelize.File, error) {
+	report, err := s.GetScorecards(from, to, supplierID, category)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create new Excel file
+	f := excelize.NewFile()
+
+	title := "Supplier Scorecards"
+	if category != "" {
+		title += " - " + category
+	}
+	f.SetCellValue("Sheet1", "A1", title)
+	f.SetCellValue("Sheet1", "B1", fmt.Sprintf("%s to %s", report.DateFrom.Format("2006-01-02"), report.DateTo.Format("2006-01-02")))
+
+	// Create headers
+	headers := []string{"Supplier", "Orders", "Deliveries", "On Time %", "Avg Lead Days", "Fill Rate %",
+		"Purchased Qty", "Returned Qty", "Return Rate %", "Price Variance %", "Purchase Value", "Score"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c3", 'A'+i)
+		f.SetCellValue("Sheet1", cell, header)
+	}
+
+	// Add data
+	for i, card := range report.Scorecards {
+		row := i + 4 // Start below the headers
+		f.SetCellValue("Sheet1", fmt.Sprintf("A%d", row), card.SupplierName)
+		f.SetCellValue("Sheet1", fmt.Sprintf("B%d", row), card.OrderCount)
+		f.SetCellValue("Sheet1", fmt.Sprintf("C%d", row), card.DeliveryCount)
+		setOptionalCell(f, fmt.Sprintf("D%d", row), card.OnTimeRate)
+		setOptionalCell(f, fmt.Sprintf("E%d", row), card.AverageLeadDays)
+		setOptionalCell(f, fmt.Sprintf("F%d", row), card.FillRate)
+		f.SetCellValue("Sheet1", fmt.Sprintf("G%d", row), card.PurchasedQuantity)
+		f.SetCellValue("Sheet1", fmt.Sprintf("H%d", row), card.ReturnedQuantity)
+		setOptionalCell(f, fmt.Sprintf("I%d", row), card.ReturnRate)
+		setOptionalCell(f, fmt.Sprintf("J%d", row), card.PriceVariance)
+		f.SetCellValue("Sheet1", fmt.Sprintf("K%d", row), card.PurchaseValue)
+		setOptionalCell(f, fmt.Sprintf("L%d", row), card.Score)
+	}
+
+	return f, nil
+}
+
+func (s *supplierScorecardService) nameSuppliers(scorecards []entities.SupplierScorecard) {
+	for i := range scorecards {
+		if supplier, err := s.supplierRepo.GetByID(scorecards[i].SupplierID); err == nil {
+			scorecards[i].SupplierName = supplier.Name
+		}
+	}
+}
+
+// scorecardTally collects one supplier's figures while the facts are read
+type scorecardTally struct {
+	card          entities.SupplierScorecard
+	orders        map[uuid.UUID]bool
+	deliveries    map[uuid.UUID]bool
+	datedCount    int
+	leadDays      float64
+	paidValue     float64
+	marketValue   float64
+	comparedLines int
+}
+
+// buildScorecards rates each supplier on the facts of one product category,
+// or of all categories when category is empty
+func buildScorecards(facts *repository.ScorecardFacts, category string) []entities.SupplierScorecard {
+	tallies := make(map[uuid.UUID]*scorecardTally)
+	var order []uuid.UUID
+	tallyFor := func(id uuid.UUID) *scorecardTally {
+		tally, ok := tallies[id]
+		if !ok {
+			tally = &scorecardTally{
+				card:       entities.SupplierScorecard{SupplierID: id},
+				orders:     make(map[uuid.UUID]bool),
+				deliveries: make(map[uuid.UUID]bool),
+			}
+			tallies[id] = tally
+			order = append(order, id)
+		}
+		return tally
+	}
+	wanted := func(c string) bool {
+		return category == "" || c == category
+	}
+
+	for _, line := range facts.OrderLines {
+		if !wanted(line.Category) {
+			continue
+		}
+		tally := tallyFor(line.SupplierID)
+		tally.orders[line.PurchaseOrderID] = true
+		// Open orders that aren't due yet can't be judged on what arrived
+		if line.Due {
+			tally.card.OrderedQuantity += line.OrderedQuantity
+			tally.card.ReceivedQuantity += line.ReceivedQuantity
+		}
+	}
+
+	for _, delivery := range facts.Deliveries {
+		if !wanted(delivery.Category) {
+			continue
+		}
+		tally := tallyFor(delivery.SupplierID)
+		// A delivery of several categories is listed once per category
+		if tally.deliveries[delivery.GoodsReceiptID] {
+			continue
+		}
+		tally.deliveries[delivery.GoodsReceiptID] = true
+		tally.leadDays += delivery.ReceivedDateTime.Sub(delivery.OrderDateTime).Hours() / 24
+
+		// Anything received on the expected day is on time
+		if delivery.ExpectedDateTime != nil {
+			tally.datedCount++
+			expected := *delivery.ExpectedDateTime
+			due := time.Date(expected.Year(), expected.Month(), expected.Day()+1, 0, 0, 0, 0, expected.Location())
+			if delivery.ReceivedDateTime.Before(due) {
+				tally.card.OnTimeDeliveries++
+			}
+		}
+	}
+
+	// What all suppliers were paid on average for each product
+	type productPrice struct {
+		value     float64
+		quantity  int
+		suppliers map[uuid.UUID]bool
+	}
+	market := make(map[uuid.UUID]*productPrice)
+	for _, line := range facts.PurchaseLines {
+		if !wanted(line.Category) {
+			continue
+		}
+		price, ok := market[line.ProductID]
+		if !ok {
+			price = &productPrice{suppliers: make(map[uuid.UUID]bool)}
+			market[line.ProductID] = price
+		}
+		price.value += line.UnitPrice * float64(line.Quantity)
+		price.quantity += line.Quantity
+		price.suppliers[line.SupplierID] = true
+	}
+
+	for _, line := range facts.PurchaseLines {
+		if !wanted(line.Category) {
+			continue
+		}
+		tally := tallyFor(line.SupplierID)
+		tally.card.PurchasedQuantity += line.Quantity
+		tally.card.PurchaseValue += line.UnitPrice * float64(line.Quantity)
+
+		// Prices only compare on products bought from more than one supplier
+		price := market[line.ProductID]
+		if len(price.suppliers) > 1 && price.quantity > 0 {
+			average := price.value / float64(price.quantity)
+			tally.paidValue += line.UnitPrice * float64(line.Quantity)
+			tally.marketValue += average * float64(line.Quantity)
+			tally.comparedLines++
+		}
+	}
+
+	for _, line := range facts.ReturnLines {
+		if !wanted(line.Category) {
+			continue
+		}
+		tallyFor(line.SupplierID).card.ReturnedQuantity += line.Quantity
+	}
+
+	scorecards := make([]entities.SupplierScorecard, 0, len(order))
+	for _, id := range order {
+		tally := tallies[id]
+		card := tally.card
+		card.OrderCount = len(tally.orders)
+		card.DeliveryCount = len(tally.deliveries)
+		card.PurchaseValue = math.Round(card.PurchaseValue*100) / 100
+
+		card.OnTimeRate = percentOf(float64(card.OnTimeDeliveries), float64(tally.datedCount))
+		if card.DeliveryCount > 0 {
+			leadDays := math.Round(tally.leadDays/float64(card.DeliveryCount)*100) / 100
+			card.AverageLeadDays = &leadDays
+		}
+		card.FillRate = percentOf(float64(card.ReceivedQuantity), float64(card.OrderedQuantity))
+		card.ReturnRate = percentOf(float64(card.ReturnedQuantity), float64(card.PurchasedQuantity))
+		if tally.comparedLines > 0 && tally.marketValue > 0 {
+			card.PriceVariance = percentOf(tally.paidValue-tally.marketValue, tally.marketValue)
+		}
+		card.Score = scorecardScore(card)
+
+		scorecards = append(scorecards, card)
+	}
+	return scorecards
+}
+
+// scorecardScore combines the measures into a score out of 100. Paying the
+// market average scores half marks on price; 20% below scores full marks
+// and 20% above scores none.
+func scorecardScore(card entities.SupplierScorecard) *float64 {
+	var weighted, weights float64
+	add := func(value *float64, weight float64, score func(float64) float64) {
+		if value == nil {
+			return
+		}
+		weighted += weight * math.Max(0, math.Min(1, score(*value)))
+		weights += weight
+	}
+
+	add(card.OnTimeRate, scoreWeightOnTime, func(rate float64) float64 { return rate / 100 })
+	add(card.FillRate, scoreWeightFill, func(rate float64) float64 { return rate / 100 })
+	add(card.ReturnRate, scoreWeightReturn, func(rate float64) float64 { return 1 - rate/100 })
+	add(card.PriceVariance, scoreWeightPrice, func(variance float64) float64 { return 0.5 - variance/40 })
+
+	if weights == 0 {
+		return nil
+	}
+	score := math.Round(weighted/weights*10000) / 100
+	return &score
+}
+
+// scorecardCategories lists the product categories in the facts
+func scorecardCategories(facts *repository.ScorecardFacts) []string {
+	seen := make(map[string]bool)
+	add := func(category string) {
+		seen[category] = true
+	}
+	for _, line := range facts.OrderLines {
+		add(line.Category)
+	}
+	for _, delivery := range facts.Deliveries {
+		add(delivery.Category)
+	}
+	for _, line := range facts.PurchaseLines {
+		add(line.Category)
+	}
+	for _, line := range facts.ReturnLines {
+		add(line.Category)
+	}
+
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// percentOf returns part as a percentage of whole, or nil when there is no
+// whole to measure against
+func percentOf(part, whole float64) *float64 {
+	if whole == 0 {
+		return nil
+	}
+	percent := math.Round(part/whole*10000) / 100
+	return &percent
+}
+
+func setOptionalCell(f *excelize.File, cell string, value *float64) {
+	if value != nil {
+		f.SetCellValue("Sheet1", cell, *value)
+	}
+}
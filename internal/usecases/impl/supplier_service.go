@@ -1,10 +1,15 @@
 package usecases
 
 import (
+	"fmt"
+	"math"
+	"sort"
+
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
 	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
 
 	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
 )
 
 type SupplierService interface {
@@ -13,15 +18,23 @@ type SupplierService interface {
 	CreateSupplier(supplier *entities.Supplier) error
 	UpdateSupplier(supplier *entities.Supplier) error
 	DeleteSupplier(id uuid.UUID) error
+	GetSupplierLedger(id uuid.UUID) (*entities.SupplierLedger, error)
+	ExportStatement(id uuid.UUID) (*excelize.File, error)
 }
 
 type supplierService struct {
-	supplierRepo repository.SupplierRepository
+	supplierRepo       repository.SupplierRepository
+	purchaseRepo       repository.PurchaseRepository
+	supplierReturnRepo repository.SupplierReturnRepository
+	paymentRepo        repository.PaymentRepository
 }
 
-func NewSupplierService(supplierRepo repository.SupplierRepository) SupplierService {
+func NewSupplierService(supplierRepo repository.SupplierRepository, purchaseRepo repository.PurchaseRepository, supplierReturnRepo repository.SupplierReturnRepository, paymentRepo repository.PaymentRepository) SupplierService {
 	return &supplierService{
-		supplierRepo: supplierRepo,
+		supplierRepo:       supplierRepo,
+		purchaseRepo:       purchaseRepo,
+		supplierReturnRepo: supplierReturnRepo,
+		paymentRepo:        paymentRepo,
 	}
 }
 
@@ -44,3 +57,154 @@ func (s *supplierService) UpdateSupplier(supplier *entities.Supplier) error {
 func (s *supplierService) DeleteSupplier(id uuid.UUID) error {
 	return s.supplierRepo.Delete(id)
 }
+
+// GetSupplierLedger lists every document affecting what we owe the supplier
+// in date order with a running balance
+func (s *supplierService) GetSupplierLedger(id uuid.UUID) (*entities.SupplierLedger, error) {
+	if _, err := s.supplierRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	filters := map[string]interface{}{"supplier_id": id}
+
+	purchases, _, err := s.purchaseRepo.GetPurchasesWithFilters(filters, nil, 1, 1000000) // Large page size to get all
+	if err != nil {
+		return nil, err
+	}
+
+	returns, _, err := s.supplierReturnRepo.GetSupplierReturnsWithFilters(filters, nil, 1, 1000000)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentFilters := map[string]interface{}{
+		"type":        entities.PaymentEntityTypeSupplier,
+		"supplier_id": id,
+	}
+	payments, _, err := s.paymentRepo.GetPaymentsWithFilters(paymentFilters, nil, 1, 1000000)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entities.SupplierLedgerEntry
+	for _, purchase := range purchases {
+		description := "Purchase invoice"
+		if purchase.CurrencyCode != "" && purchase.CurrencyCode != entities.BaseCurrency {
+			description = fmt.Sprintf("Purchase invoice, %s %.2f at %g", purchase.CurrencyCode, purchase.CurrencyTotal, purchase.ExchangeRate)
+		}
+		entries = append(entries, entities.SupplierLedgerEntry{
+			Date:         purchase.PurchaseDateTime,
+			DocumentType: "PURCHASE",
+			DocumentID:   purchase.ID,
+			Description:  description,
+			Credit:       purchase.Total,
+		})
+		// Cash purchases are settled on the spot
+		if purchase.PaymentType == entities.PaymentTypeCash {
+			entries = append(entries, entities.SupplierLedgerEntry{
+				Date:         purchase.PurchaseDateTime,
+				DocumentType: "CASH_PAYMENT",
+				DocumentID:   purchase.ID,
+				Description:  "Cash paid on purchase",
+				Debit:        purchase.Total,
+			})
+		}
+	}
+	for _, supplierReturn := range returns {
+		entries = append(entries, entities.SupplierLedgerEntry{
+			Date:         supplierReturn.ReturnDateTime,
+			DocumentType: "DEBIT_NOTE",
+			DocumentID:   supplierReturn.ID,
+			Description:  fmt.Sprintf("Goods returned: %s", supplierReturn.Reason),
+			Debit:        supplierReturn.DebitNoteAmount,
+		})
+	}
+
+	for _, payment := range payments {
+		entries = append(entries, entities.SupplierLedgerEntry{
+			Date:         payment.PaymentDateTime,
+			DocumentType: "PAYMENT",
+			DocumentID:   payment.ID,
+			Description:  payment.Remarks,
+			Debit:        payment.Amount,
+		})
+
+		// Paying a foreign currency invoice at a different rate leaves a
+		// difference to what the invoice was booked at
+		var gainLoss float64
+		for _, allocation := range payment.Allocations {
+			gainLoss += allocation.FxGainLoss
+		}
+		if math.Abs(gainLoss) >= 0.005 {
+			entry := entities.SupplierLedgerEntry{
+				Date:         payment.PaymentDateTime,
+				DocumentType: "FX_GAIN_LOSS",
+				DocumentID:   payment.ID,
+				Description:  fmt.Sprintf("Exchange difference on %s payment", payment.CurrencyCode),
+			}
+			if gainLoss > 0 {
+				entry.Debit = gainLoss
+			} else {
+				entry.Credit = -gainLoss
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	ledger := &entities.SupplierLedger{SupplierID: id}
+	for i := range entries {
+		ledger.Balance += entries[i].Credit - entries[i].Debit
+		entries[i].Balance = ledger.Balance
+	}
+	ledger.Entries = entries
+
+	return ledger, nil
+}
+
+// ExportStatement writes the supplier ledger to an Excel statement
+func (s *supplierService) ExportStatement(id uuid.UUID) (*excelize.File, error) {
+	supplier, err := s.supplierRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger, err := s.GetSupplierLedger(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create new Excel file
+	f := excelize.NewFile()
+
+	f.SetCellValue("Sheet1", "A1", "Supplier Statement")
+	f.SetCellValue("Sheet1", "B1", supplier.Name)
+
+	// Create headers
+	headers := []string{"Date", "Document", "Document ID", "Description", "Debit", "Credit", "Balance"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c3", 'A'+i)
+		f.SetCellValue("Sheet1", cell, header)
+	}
+
+	// Add data
+	for i, entry := range ledger.Entries {
+		row := i + 4 // Start below the headers
+		f.SetCellValue("Sheet1", fmt.Sprintf("A%d", row), entry.Date.Format("2006-01-02 15:04:05"))
+		f.SetCellValue("Sheet1", fmt.Sprintf("B%d", row), entry.DocumentType)
+		f.SetCellValue("Sheet1", fmt.Sprintf("C%d", row), entry.DocumentID.String())
+		f.SetCellValue("Sheet1", fmt.Sprintf("D%d", row), entry.Description)
+		f.SetCellValue("Sheet1", fmt.Sprintf("E%d", row), entry.Debit)
+		f.SetCellValue("Sheet1", fmt.Sprintf("F%d", row), entry.Credit)
+		f.SetCellValue("Sheet1", fmt.Sprintf("G%d", row), entry.Balance)
+	}
+
+	closingRow := len(ledger.Entries) + 4
+	f.SetCellValue("Sheet1", fmt.Sprintf("F%d", closingRow), "Closing Balance")
+	f.SetCellValue("Sheet1", fmt.Sprintf("G%d", closingRow), ledger.Balance)
+
+	return f, nil
+}
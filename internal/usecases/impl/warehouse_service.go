@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"Sheikh-Enterprise-Backend/internal/domain/entities"
+	repository "Sheikh-Enterprise-Backend/internal/infrastructure/persistence"
+
+	"github.com/google/uuid"
+)
+
+type WarehouseService interface {
+	GetWarehouses(page, pageSize int, filters map[string]interface{}) ([]entities.Shop, int64, error)
+	GetWarehouse(id uuid.UUID) (*entities.WarehouseDetail, error)
+	CreateWarehouse(warehouse *entities.Shop, central bool) error
+	SetCentral(id uuid.UUID) (*entities.WarehouseDetail, error)
+}
+
+type warehouseService struct {
+	warehouseRepo repository.WarehouseRepository
+	inventoryRepo repository.InventoryRepository
+}
+
+func NewWarehouseService(warehouseRepo repository.WarehouseRepository, inventoryRepo repository.InventoryRepository) WarehouseService {
+	return &warehouseService{
+		warehouseRepo: warehouseRepo,
+		inventoryRepo: inventoryRepo,
+	}
+}
+
+func (s *warehouseService) GetWarehouses(page, pageSize int, filters map[string]interface{}) ([]entities.Shop, int64, error) {
+	return s.warehouseRepo.GetWarehousesWithFilters(filters, page, pageSize)
+}
+
+// GetWarehouse returns the warehouse with the stock it holds and whether it
+// is its company's central warehouse
+func (s *warehouseService) GetWarehouse(id uuid.UUID) (*entities.WarehouseDetail, error) {
+	warehouse, err := s.warehouseRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(warehouse)
+}
+
+func (s *warehouseService) CreateWarehouse(warehouse *entities.Shop, central bool) error {
+	return s.warehouseRepo.Create(warehouse, central)
+}
+
+func (s *warehouseService) SetCentral(id uuid.UUID) (*entities.WarehouseDetail, error) {
+	warehouse, err := s.warehouseRepo.SetCentral(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(warehouse)
+}
+
+func (s *warehouseService) detail(warehouse *entities.Shop) (*entities.WarehouseDetail, error) {
+	central, err := s.warehouseRepo.IsCentral(warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.inventoryRepo.GetShopTotals(&warehouse.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &entities.WarehouseDetail{Shop: *warehouse, Central: central}
+	if len(totals) > 0 {
+		detail.Stock = &totals[0]
+	}
+	return detail, nil
+}
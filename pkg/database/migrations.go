@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"log"
 
 	"Sheikh-Enterprise-Backend/internal/domain/entities"
@@ -17,16 +18,72 @@ func RunMigrations(db *gorm.DB) error {
 		&entities.User{},
 		&entities.Company{},
 		&entities.Shop{},
+		&entities.ProductStyle{},
 		&entities.Product{},
 		&entities.Inventory{},
 		&entities.Supplier{},
+		&entities.SupplierProduct{},
 		&entities.Customer{},
+		&entities.Currency{},
+		&entities.ExchangeRate{},
 		&entities.PurchaseInvoice{},
 		&entities.PurchaseDetail{},
+		&entities.PurchaseRevision{},
+		&entities.PurchaseRevisionLine{},
+		&entities.LandedCost{},
+		&entities.ReorderRule{},
+		&entities.CategoryReorderDefault{},
+		&entities.PurchaseOrder{},
+		&entities.PurchaseOrderLine{},
+		&entities.GoodsReceipt{},
+		&entities.GoodsReceiptLine{},
+		&entities.SupplierReturn{},
+		&entities.SupplierReturnLine{},
 		&entities.SalesInvoice{},
 		&entities.SalesDetail{},
 		&entities.StockTransfer{},
+		&entities.StockAdjustment{},
+		&entities.StockAdjustmentLine{},
+		&entities.StockCount{},
+		&entities.StockCountLine{},
+		&entities.StockCountEntry{},
+		&entities.StockReservation{},
+		&entities.StockLot{},
+		&entities.LotInventory{},
+		&entities.StockLotMovement{},
+		&entities.SerialNumber{},
+		&entities.SerialNumberEvent{},
 		&entities.Payment{},
+		&entities.CostLayer{},
+		&entities.StockMovement{},
+		&entities.PaymentAllocation{},
+		&entities.Notification{},
+		&entities.NotificationDelivery{},
+		&entities.NotificationPreference{},
+	}
+
+	// Inventory is unique per product and shop, and never negative, from now
+	// on. Existing rows are fixed up before the index and checks are built.
+	if err := mergeDuplicateInventory(db); err != nil {
+		return err
+	}
+	if err := clampNegativeInventory(db); err != nil {
+		return err
+	}
+
+	// Purchases are received into a shop from now on
+	if err := addPurchaseShop(db); err != nil {
+		return err
+	}
+
+	// Each product has at most one preferred supplier from now on
+	if err := clearDuplicatePreferredSuppliers(db); err != nil {
+		return err
+	}
+
+	// Serial numbers are saved trimmed and in upper case from now on
+	if err := normalizeSerialNumbers(db); err != nil {
+		return err
 	}
 
 	// Run migrations
@@ -39,3 +96,191 @@ func RunMigrations(db *gorm.DB) error {
 	log.Println("Database migrations completed successfully")
 	return nil
 }
+
+// duplicateInventoryCTE ranks the active inventory rows of each product and
+// shop, oldest first, with the totals of the group
+const duplicateInventoryCTE = `
+	WITH ranked AS (
+		SELECT id,
+			ROW_NUMBER() OVER w AS rn,
+			COUNT(*) OVER w AS copies,
+			SUM(quantity) OVER w AS total_quantity,
+			SUM(quantity * average_cost) OVER w AS total_value
+		FROM inventories
+		WHERE is_marked_to_delete = false
+		WINDOW w AS (PARTITION BY product_id, shop_id ORDER BY created_at, id
+			ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+	)`
+
+// mergeDuplicateInventory folds duplicate inventory rows for the same product
+// and shop into the oldest one, so the unique index on them can be built.
+// The merged row keeps the combined quantity at the weighted average cost.
+func mergeDuplicateInventory(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.Inventory{}) {
+		return nil
+	}
+	// Tables from before costing don't have the average cost the merge
+	// weighs by yet
+	if !db.Migrator().HasColumn(&entities.Inventory{}, "AverageCost") {
+		if err := db.Migrator().AddColumn(&entities.Inventory{}, "AverageCost"); err != nil {
+			return err
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(duplicateInventoryCTE + `
+			UPDATE inventories
+			SET quantity = ranked.total_quantity,
+				average_cost = CASE WHEN ranked.total_quantity > 0
+					THEN ranked.total_value / ranked.total_quantity
+					ELSE inventories.average_cost END
+			FROM ranked
+			WHERE inventories.id = ranked.id AND ranked.rn = 1 AND ranked.copies > 1`).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Exec(duplicateInventoryCTE + `
+			UPDATE inventories
+			SET is_marked_to_delete = true
+			FROM ranked
+			WHERE inventories.id = ranked.id AND ranked.rn > 1`)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Merged %d duplicate inventory rows", result.RowsAffected)
+		}
+		return nil
+	})
+}
+
+// clearDuplicatePreferredSuppliers drops the preferred flag from deleted
+// catalog entries and from all but the most recently updated preferred
+// supplier of each product, so the partial unique index on them can be built
+func clearDuplicatePreferredSuppliers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.SupplierProduct{}) {
+		return nil
+	}
+
+	result := db.Exec(`
+		UPDATE supplier_products
+		SET is_preferred = false
+		WHERE is_preferred = true AND (is_marked_to_delete = true OR id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY updated_at DESC, id) AS rn
+				FROM supplier_products
+				WHERE is_preferred = true AND is_marked_to_delete = false
+			) ranked
+			WHERE ranked.rn > 1
+		))`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Cleared the preferred flag on %d supplier catalog entries", result.RowsAffected)
+	}
+	return nil
+}
+
+// clampNegativeInventory sets negative inventory quantities to zero so the
+// non-negative check can be added, logging each row it changed so the stock
+// can be counted again
+func clampNegativeInventory(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.Inventory{}) {
+		return nil
+	}
+
+	var negative []entities.Inventory
+	err := db.Unscoped().Select("id", "product_id", "shop_id", "quantity").
+		Where("quantity < 0").
+		Find(&negative).Error
+	if err != nil {
+		return err
+	}
+	if len(negative) == 0 {
+		return nil
+	}
+
+	for _, inventory := range negative {
+		log.Printf("Inventory of product %s in shop %s was %d; setting it to 0, count it again",
+			inventory.ProductID, inventory.ShopID, inventory.Quantity)
+	}
+	return db.Unscoped().Model(&entities.Inventory{}).
+		Where("quantity < 0").
+		Update("quantity", 0).Error
+}
+
+// addPurchaseShop adds the shop purchases are received into. Purchases from
+// before purchases had a shop are put in the oldest shop, so the column can
+// be made NOT NULL.
+func addPurchaseShop(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.PurchaseInvoice{}) || db.Migrator().HasColumn(&entities.PurchaseInvoice{}, "ShopID") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE purchase_invoices ADD COLUMN shop_id uuid").Error; err != nil {
+			return err
+		}
+
+		var purchases int64
+		if err := tx.Unscoped().Model(&entities.PurchaseInvoice{}).Count(&purchases).Error; err != nil {
+			return err
+		}
+		if purchases > 0 {
+			var shop entities.Shop
+			err := tx.Unscoped().Select("shop_id", "name").
+				Order("created_at, shop_id").
+				First(&shop).Error
+			if err != nil {
+				return fmt.Errorf("purchases need a shop to be received into: %w", err)
+			}
+			if err := tx.Exec("UPDATE purchase_invoices SET shop_id = ?", shop.ShopID).Error; err != nil {
+				return err
+			}
+			log.Printf("Put %d existing purchases in shop %s (%s); move any that went elsewhere", purchases, shop.Name, shop.ShopID)
+		}
+
+		return tx.Exec("ALTER TABLE purchase_invoices ALTER COLUMN shop_id SET NOT NULL").Error
+	})
+}
+
+// normalizeSerialNumbers trims serial numbers and puts them in upper case.
+// Numbers whose normalized form another unit of the product already has are
+// left as they are and logged, to be sorted out by hand.
+func normalizeSerialNumbers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.SerialNumber{}) {
+		return nil
+	}
+
+	result := db.Exec(`
+		UPDATE serial_numbers s
+		SET serial_number = UPPER(TRIM(s.serial_number))
+		WHERE s.serial_number <> UPPER(TRIM(s.serial_number))
+			AND NOT EXISTS (
+				SELECT 1 FROM serial_numbers o
+				WHERE o.product_id = s.product_id
+					AND o.id <> s.id
+					AND UPPER(TRIM(o.serial_number)) = UPPER(TRIM(s.serial_number))
+			)`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Normalized %d serial numbers", result.RowsAffected)
+	}
+
+	var clashes []entities.SerialNumber
+	err := db.Select("id", "product_id", "serial_number").
+		Where("serial_number <> UPPER(TRIM(serial_number))").
+		Find(&clashes).Error
+	if err != nil {
+		return err
+	}
+	for _, serial := range clashes {
+		log.Printf("Serial number %q of product %s clashes with another unit once normalized and was left as it is",
+			serial.SerialNumber, serial.ProductID)
+	}
+	return nil
+}